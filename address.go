@@ -1,6 +1,9 @@
 package cardano
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/echovl/bech32"
 	"github.com/tclairet/cardano-go/crypto"
 	"golang.org/x/crypto/blake2b"
@@ -13,10 +16,48 @@ const (
 	Mainnet Network = 1
 )
 
+// CredentialType is the kind of a payment or stake credential.
+type CredentialType byte
+
+const (
+	KeyCredential    CredentialType = 0
+	ScriptCredential CredentialType = 1
+)
+
+// Credential is the key or script hash of a payment or stake credential.
+type Credential struct {
+	_    struct{} `cbor:",toarray"`
+	Type CredentialType
+	Hash []byte // 28 bytes
+}
+
+// NewCredential returns the credential of the key or script hash.
+func NewCredential(credType CredentialType, hash []byte) (Credential, error) {
+	if credType != KeyCredential && credType != ScriptCredential {
+		return Credential{}, fmt.Errorf("unknown credential type %v", credType)
+	}
+	if len(hash) != 28 {
+		return Credential{}, fmt.Errorf("invalid credential hash length %v", len(hash))
+	}
+	return Credential{Type: credType, Hash: hash}, nil
+}
+
+// NewKeyCredential returns the key hash credential of the verification key.
+func NewKeyCredential(xvk crypto.ExtendedVerificationKey) Credential {
+	return Credential{Type: KeyCredential, Hash: keyHash(xvk)}
+}
+
+var (
+	ErrInvalidChecksum    = errors.New("invalid address checksum")
+	ErrWrongNetwork       = errors.New("wrong address network")
+	ErrUnknownAddressType = errors.New("unknown address type")
+)
+
 // Address is the bech32 representation of a cardano address
 type Address string
 
-// Bytes returns the byte slice representation of the address.
+// Bytes returns the header and payload bytes of the address, as encoded in
+// the transaction outputs.
 func (addr *Address) Bytes() []byte {
 	_, bytes, err := bech32.DecodeToBase256(string(*addr))
 	if err != nil {
@@ -25,6 +66,84 @@ func (addr *Address) Bytes() []byte {
 	return bytes
 }
 
+// CredentialType returns the type of the address payment credential, or the
+// stake credential for reward addresses.
+func (addr Address) CredentialType() (CredentialType, error) {
+	_, bytes, err := bech32.DecodeToBase256(string(addr))
+	if err != nil {
+		return 0, err
+	}
+	if len(bytes) == 0 {
+		return 0, fmt.Errorf("empty address")
+	}
+	switch addrType := bytes[0] >> 4; addrType {
+	case 0x00, 0x02, 0x04, 0x06, 0x0E:
+		return KeyCredential, nil
+	case 0x01, 0x03, 0x05, 0x07, 0x0F:
+		return ScriptCredential, nil
+	case 0x08:
+		return 0, fmt.Errorf("byron addresses don't have a credential")
+	default:
+		return 0, fmt.Errorf("unknown address type %v", addrType)
+	}
+}
+
+// IsScript reports whether the address is controlled by a script.
+func (addr Address) IsScript() bool {
+	credType, err := addr.CredentialType()
+	return err == nil && credType == ScriptCredential
+}
+
+// PaymentKeyHash returns the payment key hash of a base, pointer or
+// enterprise address, erroring on script credentials and addresses without
+// payment credential.
+func (addr Address) PaymentKeyHash() ([]byte, error) {
+	_, bytes, err := bech32.DecodeToBase256(string(addr))
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes) < 29 {
+		return nil, fmt.Errorf("invalid address length %v", len(bytes))
+	}
+	switch addrType := bytes[0] >> 4; addrType {
+	case 0x00, 0x02, 0x04, 0x06:
+		return bytes[1:29], nil
+	case 0x01, 0x03, 0x05, 0x07:
+		return nil, fmt.Errorf("address payment credential is a script hash")
+	default:
+		return nil, fmt.Errorf("address type %v has no payment key hash", addrType)
+	}
+}
+
+// StakeKeyHash returns the stake key hash of a base or reward address. Stake
+// script hashes, pointers and enterprise addresses return an error.
+func (addr Address) StakeKeyHash() ([]byte, error) {
+	_, bytes, err := bech32.DecodeToBase256(string(addr))
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes) < 29 {
+		return nil, fmt.Errorf("invalid address length %v", len(bytes))
+	}
+	switch addrType := bytes[0] >> 4; addrType {
+	case 0x00, 0x01:
+		if len(bytes) != 57 {
+			return nil, fmt.Errorf("invalid base address length %v", len(bytes))
+		}
+		return bytes[29:57], nil
+	case 0x0E:
+		return bytes[1:29], nil
+	case 0x02, 0x03, 0x0F:
+		return nil, fmt.Errorf("address stake credential is a script hash")
+	case 0x04, 0x05:
+		return nil, fmt.Errorf("pointer address has no stake key hash")
+	case 0x06, 0x07:
+		return nil, fmt.Errorf("enterprise address has no stake credential")
+	default:
+		return nil, fmt.Errorf("address type %v has no stake key hash", addrType)
+	}
+}
+
 func DecodeAddress(data []byte) (Address, Address, error) {
 	testnet, err := bech32.EncodeFromBase256("addr_test", data)
 	if err != nil {
@@ -37,29 +156,193 @@ func DecodeAddress(data []byte) (Address, Address, error) {
 	return Address(mainnet), Address(testnet), nil
 }
 
-func NewEnterpriseAddress(xvk crypto.ExtendedVerificationKey, network Network) Address {
-	addressBytes := make([]byte, 29)
-	header := 0x60 | (byte(network) & 0xFF)
-	hash, err := blake2b.New(224/8, nil)
+// NewEnterpriseAddress returns the payment only address of the credential,
+// header type 6 for a key hash and 7 for a script hash. It panics if the
+// credential hash isn't 28 bytes.
+func NewEnterpriseAddress(network Network, payment Credential) Address {
+	if len(payment.Hash) != 28 {
+		panic(fmt.Sprintf("invalid credential hash length %v", len(payment.Hash)))
+	}
+	header := 0x60 | byte(payment.Type&1)<<4 | byte(network)&0x0F
+	address, err := bech32.EncodeFromBase256(getHrp(network), append([]byte{header}, payment.Hash...))
 	if err != nil {
 		panic(err)
 	}
+	return Address(address)
+}
 
-	hash.Write(xvk[:32])
-	paymentHash := hash.Sum(nil)
+// NewRewardAddress returns the stake only address of the credential, header
+// type 14 for a key hash and 15 for a script hash, with the stake prefix. It
+// panics if the credential hash isn't 28 bytes.
+func NewRewardAddress(network Network, stake Credential) Address {
+	if len(stake.Hash) != 28 {
+		panic(fmt.Sprintf("invalid credential hash length %v", len(stake.Hash)))
+	}
+	header := 0xE0 | byte(stake.Type&1)<<4 | byte(network)&0x0F
+	address, err := bech32.EncodeFromBase256(getStakeHrp(network), append([]byte{header}, stake.Hash...))
+	if err != nil {
+		panic(err)
+	}
+	return Address(address)
+}
 
-	addressBytes[0] = header
-	copy(addressBytes[1:], paymentHash)
+// NewBaseAddress returns the base address of the payment and stake
+// credentials. The header high nibble is 0 to 3, the payment credential type
+// in bit 4 and the stake credential type in bit 5. It panics if a credential
+// hash isn't 28 bytes.
+func NewBaseAddress(network Network, payment, stake Credential) Address {
+	if len(payment.Hash) != 28 || len(stake.Hash) != 28 {
+		panic(fmt.Sprintf("invalid credential hash lengths %v and %v", len(payment.Hash), len(stake.Hash)))
+	}
+	header := byte(payment.Type&1)<<4 | byte(stake.Type&1)<<5 | byte(network)&0x0F
+	addressBytes := append([]byte{header}, payment.Hash...)
+	addressBytes = append(addressBytes, stake.Hash...)
 
-	hrp := getHrp(network)
-	address, err := bech32.EncodeFromBase256(hrp, addressBytes)
+	address, err := bech32.EncodeFromBase256(getHrp(network), addressBytes)
 	if err != nil {
 		panic(err)
 	}
-
 	return Address(address)
 }
 
+// NewScriptAddress returns the enterprise address of the script hash.
+func NewScriptAddress(network Network, scriptHash []byte) (Address, error) {
+	return newScriptAddress(0x70|(byte(network)&0x0F), scriptHash, nil)
+}
+
+// NewScriptAddressWithStake returns the base address of the script hash
+// delegating to the stake key hash.
+func NewScriptAddressWithStake(network Network, scriptHash, stakeKeyHash []byte) (Address, error) {
+	if len(stakeKeyHash) != 28 {
+		return "", fmt.Errorf("invalid stake key hash length %v", len(stakeKeyHash))
+	}
+	return newScriptAddress(0x10|(byte(network)&0x0F), scriptHash, stakeKeyHash)
+}
+
+func newScriptAddress(header byte, scriptHash, stakeKeyHash []byte) (Address, error) {
+	if len(scriptHash) != 28 {
+		return "", fmt.Errorf("invalid script hash length %v", len(scriptHash))
+	}
+	addressBytes := append([]byte{header}, scriptHash...)
+	addressBytes = append(addressBytes, stakeKeyHash...)
+
+	address, err := bech32.EncodeFromBase256(getHrp(Network(header&0x0F)), addressBytes)
+	if err != nil {
+		return "", err
+	}
+	return Address(address), nil
+}
+
+// StakeAddressFromRoot derives the CIP-1852 staking key m/1852'/1815'/account'/2/0
+// from the root key and returns its reward address.
+func StakeAddressFromRoot(root crypto.ExtendedSigningKey, account uint32, network Network) (Address, error) {
+	stakeKey, err := crypto.DeriveCIP1852(root, account, crypto.StakingRole, 0)
+	if err != nil {
+		return "", err
+	}
+	return NewRewardAddress(network, NewKeyCredential(stakeKey.ExtendedVerificationKey())), nil
+}
+
+// RewardAccount returns the 29 bytes reward account of the stake verification
+// key, the body of its reward address and the key of its withdrawals.
+func RewardAccount(network Network, stakeVKey []byte) ([]byte, error) {
+	if len(stakeVKey) != 32 {
+		return nil, fmt.Errorf("invalid stake verification key length %v", len(stakeVKey))
+	}
+	account := make([]byte, 29)
+	account[0] = 0xE0 | (byte(network) & 0x0F)
+	copy(account[1:], keyHash(stakeVKey))
+	return account, nil
+}
+
+// ValidateAddressString checks the bech32 checksum, the prefix, the type and
+// the network of a shelley or reward address.
+func ValidateAddressString(s string, expected Network) error {
+	hrp, data, err := bech32.DecodeToBase256(s)
+	if err != nil {
+		if errors.As(err, &bech32.ErrInvalidChecksum{}) {
+			return fmt.Errorf("%w: %v", ErrInvalidChecksum, err)
+		}
+		return fmt.Errorf("invalid address %v: %v", s, err)
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("empty address")
+	}
+
+	wantHrp, hrps := getHrp(expected), []string{"addr", "addr_test"}
+	var minLength, maxLength int
+	switch addrType := data[0] >> 4; addrType {
+	case 0x00, 0x01, 0x02, 0x03:
+		minLength, maxLength = 57, 57
+	case 0x04, 0x05:
+		// Pointers are variable length natural numbers
+		minLength, maxLength = 32, len(data)
+	case 0x06, 0x07:
+		minLength, maxLength = 29, 29
+	case 0x0E, 0x0F:
+		wantHrp, hrps = getStakeHrp(expected), []string{"stake", "stake_test"}
+		minLength, maxLength = 29, 29
+	default:
+		return fmt.Errorf("%w %v", ErrUnknownAddressType, addrType)
+	}
+
+	if hrp != wantHrp {
+		if hrp == hrps[0] || hrp == hrps[1] {
+			return fmt.Errorf("%w, got prefix %v want %v", ErrWrongNetwork, hrp, wantHrp)
+		}
+		return fmt.Errorf("invalid address prefix %v", hrp)
+	}
+	if network := Network(data[0] & 0x0F); network != expected {
+		return fmt.Errorf("%w, got %v want %v", ErrWrongNetwork, network, expected)
+	}
+	if len(data) < minLength || len(data) > maxLength {
+		return fmt.Errorf("invalid address length %v", len(data))
+	}
+	return nil
+}
+
+// NewAddress parses a bech32 shelley or reward address, checking that its
+// prefix matches the network of its header.
+func NewAddress(bech32Addr string) (Address, error) {
+	hrp, data, err := bech32.DecodeToBase256(bech32Addr)
+	if err != nil {
+		if errors.As(err, &bech32.ErrInvalidChecksum{}) {
+			return "", fmt.Errorf("%w: %v", ErrInvalidChecksum, err)
+		}
+		return "", fmt.Errorf("invalid address %v: %v", bech32Addr, err)
+	}
+	if len(data) == 0 {
+		return "", fmt.Errorf("empty address")
+	}
+	network := Network(data[0] & 0x0F)
+	wantHrp := getHrp(network)
+	if addrType := data[0] >> 4; addrType == 0x0E || addrType == 0x0F {
+		wantHrp = getStakeHrp(network)
+	}
+	if hrp != wantHrp {
+		return "", fmt.Errorf("%w, got prefix %v want %v", ErrWrongNetwork, hrp, wantHrp)
+	}
+	return Address(bech32Addr), nil
+}
+
+// Bech32 returns the bech32 encoding of the address.
+func (addr Address) Bech32() string {
+	return string(addr)
+}
+
+// Network returns the network id of the address header, or for Byron
+// addresses Testnet if their attributes have a protocol magic. The address
+// must be valid, see NewAddress, invalid ones are reported on Mainnet.
+func (addr Address) Network() Network {
+	if _, bytes, err := bech32.DecodeToBase256(string(addr)); err == nil {
+		if len(bytes) == 0 {
+			return Mainnet
+		}
+		return Network(bytes[0] & 0x0F)
+	}
+	return byronNetwork(addr)
+}
+
 // Bech32ToAddress creates an Address from a bech32 encoded string.
 func Bech32ToAddress(addr string) (Address, error) {
 	_, _, err := bech32.DecodeToBase256(addr)
@@ -73,11 +356,78 @@ func Bech32ToAddress(addr string) (Address, error) {
 func BytesToAddress(addr []byte, network Network) (Address, error) {
 	encoded, err := bech32.EncodeFromBase256(getHrp(network), addr)
 	if err != nil {
-		return "", nil
+		return "", err
 	}
 	return Address(encoded), nil
 }
 
+// AddressBech32 returns the bech32 encoding of the output address, or the
+// base58 encoding for Byron addresses.
+func (txOut TransactionOutput) AddressBech32(network Network) (string, error) {
+	if len(txOut.Address) == 0 {
+		return "", fmt.Errorf("empty output address")
+	}
+	if txOut.Address[0]>>4 == 0x08 {
+		return base58Encode(txOut.Address), nil
+	}
+	addr, err := BytesToAddress(txOut.Address, network)
+	if err != nil {
+		return "", err
+	}
+	return string(addr), nil
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Encode encodes data with the bitcoin alphabet used by Byron addresses.
+func base58Encode(data []byte) string {
+	zeros := 0
+	for zeros < len(data) && data[zeros] == 0 {
+		zeros++
+	}
+
+	// Repeatedly divide the big endian number by 58, little endian digits
+	digits := []byte{}
+	for _, b := range data[zeros:] {
+		carry := int(b)
+		for i := range digits {
+			carry += int(digits[i]) << 8
+			digits[i] = byte(carry % 58)
+			carry /= 58
+		}
+		for carry > 0 {
+			digits = append(digits, byte(carry%58))
+			carry /= 58
+		}
+	}
+
+	encoded := make([]byte, zeros+len(digits))
+	for i := 0; i < zeros; i++ {
+		encoded[i] = base58Alphabet[0]
+	}
+	for i, digit := range digits {
+		encoded[len(encoded)-1-i] = base58Alphabet[digit]
+	}
+	return string(encoded)
+}
+
+// keyHash returns the blake2b-224 hash of the verification key.
+func keyHash(xvk crypto.ExtendedVerificationKey) []byte {
+	hash, err := blake2b.New(224/8, nil)
+	if err != nil {
+		panic(err)
+	}
+	hash.Write(xvk[:32])
+	return hash.Sum(nil)
+}
+
+func getStakeHrp(network Network) string {
+	if network == Testnet {
+		return "stake_test"
+	}
+	return "stake"
+}
+
 func getHrp(network Network) string {
 	if network == Testnet {
 		return "addr_test"
@@ -0,0 +1,505 @@
+package cardano
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/echovl/bech32"
+	"github.com/tclairet/cardano-go/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// CIP-19 test mnemonic
+const addressTestMnemonic = "test walk nut penalty hip pave soap entry language right filter choice"
+
+func TestStakeAddressFromRoot(t *testing.T) {
+	entropy, err := bip39.EntropyFromMnemonic(addressTestMnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := crypto.NewExtendedSigningKey(entropy, "")
+
+	tests := []struct {
+		network Network
+		want    Address
+	}{
+		{Mainnet, "stake1uyevw2xnsc0pvn9t9r9c7qryfqfeerchgrlm3ea2nefr9hqxdekzz"},
+		{Testnet, "stake_test1uqevw2xnsc0pvn9t9r9c7qryfqfeerchgrlm3ea2nefr9hqp8n5xl"},
+	}
+	for _, tt := range tests {
+		got, err := StakeAddressFromRoot(root, 0, tt.network)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("got %v want %v", got, tt.want)
+		}
+	}
+
+	if _, err := StakeAddressFromRoot(root, 0x80000000, Mainnet); err == nil {
+		t.Errorf("expected invalid account error")
+	}
+}
+
+func TestRewardAccount(t *testing.T) {
+	// Stake key m/1852'/1815'/0'/2/0 of addressTestMnemonic
+	stakeVKey, _ := hex.DecodeString("2c041c9c6a676ac54d25e2fdce44c56581e316ae43adc4c7bf17f23214d8d892")
+
+	tests := []struct {
+		network Network
+		want    string
+	}{
+		{Mainnet, "e132c728d3861e164cab28cb8f006448139c8f1740ffb8e7aa9e5232dc"},
+		{Testnet, "e032c728d3861e164cab28cb8f006448139c8f1740ffb8e7aa9e5232dc"},
+	}
+	for _, tt := range tests {
+		got, err := RewardAccount(tt.network, stakeVKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("got %x want %v", got, tt.want)
+		}
+	}
+
+	if _, err := RewardAccount(Mainnet, stakeVKey[:31]); err == nil {
+		t.Errorf("expected invalid stake verification key error")
+	}
+}
+
+func TestAddress_CredentialType(t *testing.T) {
+	hash := make([]byte, 28)
+	tests := []struct {
+		name     string
+		header   byte
+		size     int
+		want     CredentialType
+		isScript bool
+		wantErr  bool
+	}{
+		{name: "base key/key", header: 0x00, size: 57, want: KeyCredential},
+		{name: "base script/key", header: 0x10, size: 57, want: ScriptCredential, isScript: true},
+		{name: "base key/script", header: 0x20, size: 57, want: KeyCredential},
+		{name: "base script/script", header: 0x30, size: 57, want: ScriptCredential, isScript: true},
+		{name: "enterprise key", header: 0x60, size: 29, want: KeyCredential},
+		{name: "enterprise script", header: 0x70, size: 29, want: ScriptCredential, isScript: true},
+		{name: "reward key", header: 0xE0, size: 29, want: KeyCredential},
+		{name: "unknown", header: 0x90, size: 29, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := make([]byte, tt.size)
+			data[0] = tt.header
+			copy(data[1:], hash)
+			addr, err := BytesToAddress(data, Testnet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := addr.CredentialType()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CredentialType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %v want %v", got, tt.want)
+			}
+			if got := addr.IsScript(); got != tt.isScript {
+				t.Errorf("got %v want %v", got, tt.isScript)
+			}
+		})
+	}
+
+	key := crypto.NewExtendedSigningKey([]byte("enterprise address"), "foo")
+	if NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey())).IsScript() {
+		t.Errorf("enterprise key address reported as script")
+	}
+}
+
+func TestTransactionOutput_AddressBech32(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		network Network
+		want    string
+	}{
+		{
+			name:    "base",
+			address: "019493315cd92eb5d8c4304e67b7e16ae36d61d34502694657811a2c8e337b62cfff6403a06a3acbc34f8c46003c69fe79a3628cefa9c47251",
+			network: Mainnet,
+			want:    "addr1qx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3n0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgse35a3x",
+		},
+		{
+			name:    "enterprise",
+			address: "619493315cd92eb5d8c4304e67b7e16ae36d61d34502694657811a2c8e",
+			network: Mainnet,
+			want:    "addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8",
+		},
+		{
+			name:    "enterprise testnet",
+			address: "609493315cd92eb5d8c4304e67b7e16ae36d61d34502694657811a2c8e",
+			network: Testnet,
+			want:    "addr_test1vz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzerspjrlsz",
+		},
+		{
+			name:    "byron",
+			address: "82d818582183581cba970ad36654d8dd8f74274b733452ddeab9a62a397746be3c42ccdda0001a9026da5b",
+			network: Mainnet,
+			want:    "Ae2tdPwUPEZFRbyhz3cpfC2CumGzNkFBN2L42rcUc2yjQpEkxDbkPodpMAi",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, err := hex.DecodeString(tt.address)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := TransactionOutput{Address: address, Amount: 1000000}.AddressBech32(tt.network)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := (TransactionOutput{}).AddressBech32(Mainnet); err == nil {
+		t.Errorf("expected empty address error")
+	}
+}
+
+func TestNewScriptAddress(t *testing.T) {
+	scriptHash, _ := hex.DecodeString("c37b1b5dc0669f1d3c61a6fddb2e8fde96be87b881c60bce8e8d542f")
+	stakeKeyHash, _ := hex.DecodeString("337b62cfff6403a06a3acbc34f8c46003c69fe79a3628cefa9c47251")
+
+	got, err := NewScriptAddress(Mainnet, scriptHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := Address("addr1w8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtcyjy7wx"); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if !got.IsScript() {
+		t.Errorf("script address not reported as script")
+	}
+
+	got, err = NewScriptAddressWithStake(Mainnet, scriptHash, stakeKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := Address("addr1z8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gten0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgs9yc0hh"); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	if _, err := NewScriptAddress(Testnet, scriptHash[:27]); err == nil {
+		t.Errorf("expected invalid script hash length error")
+	}
+	if _, err := NewScriptAddressWithStake(Testnet, scriptHash, stakeKeyHash[:1]); err == nil {
+		t.Errorf("expected invalid stake key hash length error")
+	}
+}
+
+func TestValidateAddressString(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		network Network
+		wantErr error
+	}{
+		{name: "base", address: "addr1qx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3n0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgse35a3x", network: Mainnet},
+		{name: "enterprise testnet", address: "addr_test1vqgjd0t02q9yglcjwdc8dht9tz6gkfpqqm7evs5csrklakcqmwv40", network: Testnet},
+		{name: "script", address: "addr1w8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtcyjy7wx", network: Mainnet},
+		{name: "reward", address: "stake1uyevw2xnsc0pvn9t9r9c7qryfqfeerchgrlm3ea2nefr9hqxdekzz", network: Mainnet},
+		{name: "bad checksum", address: "addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl9", network: Mainnet, wantErr: ErrInvalidChecksum},
+		{name: "wrong network prefix", address: "addr_test1vqgjd0t02q9yglcjwdc8dht9tz6gkfpqqm7evs5csrklakcqmwv40", network: Mainnet, wantErr: ErrWrongNetwork},
+		{name: "wrong reward network", address: "stake_test1uqevw2xnsc0pvn9t9r9c7qryfqfeerchgrlm3ea2nefr9hqp8n5xl", network: Mainnet, wantErr: ErrWrongNetwork},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAddressString(tt.address, tt.network)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("ValidateAddressString() error = %v", err)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	hash := make([]byte, 28)
+	encode := func(hrp string, data []byte) string {
+		encoded, err := bech32.EncodeFromBase256(hrp, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return encoded
+	}
+	if err := ValidateAddressString(encode("addr", append([]byte{0x90}, hash...)), Mainnet); !errors.Is(err, ErrUnknownAddressType) {
+		t.Errorf("got error %v want %v", err, ErrUnknownAddressType)
+	}
+	// Mainnet prefix with a testnet header
+	if err := ValidateAddressString(encode("addr", append([]byte{0x60}, hash...)), Mainnet); !errors.Is(err, ErrWrongNetwork) {
+		t.Errorf("got error %v want %v", err, ErrWrongNetwork)
+	}
+	if err := ValidateAddressString(encode("addr", append([]byte{0x61}, hash[:20]...)), Mainnet); err == nil {
+		t.Errorf("expected invalid length error")
+	}
+	if err := ValidateAddressString(encode("pool", append([]byte{0x61}, hash...)), Mainnet); err == nil {
+		t.Errorf("expected invalid prefix error")
+	}
+	if err := ValidateAddressString("not an address", Mainnet); err == nil {
+		t.Errorf("expected invalid address error")
+	}
+}
+
+func TestNewAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		bytes   string
+		network Network
+		wantErr error
+	}{
+		{
+			name:    "enterprise",
+			address: "addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8",
+			bytes:   "619493315cd92eb5d8c4304e67b7e16ae36d61d34502694657811a2c8e",
+			network: Mainnet,
+		},
+		{
+			name:    "enterprise testnet",
+			address: "addr_test1vz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzerspjrlsz",
+			bytes:   "609493315cd92eb5d8c4304e67b7e16ae36d61d34502694657811a2c8e",
+			network: Testnet,
+		},
+		{
+			name:    "reward",
+			address: "stake1uyevw2xnsc0pvn9t9r9c7qryfqfeerchgrlm3ea2nefr9hqxdekzz",
+			bytes:   "e132c728d3861e164cab28cb8f006448139c8f1740ffb8e7aa9e5232dc",
+			network: Mainnet,
+		},
+		{name: "bad checksum", address: "addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl9", wantErr: ErrInvalidChecksum},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := NewAddress(tt.address)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got := addr.Bech32(); got != tt.address {
+				t.Errorf("got %v want %v", got, tt.address)
+			}
+			if got := hex.EncodeToString(addr.Bytes()); got != tt.bytes {
+				t.Errorf("got bytes %v want %v", got, tt.bytes)
+			}
+			if network := addr.Network(); network != tt.network {
+				t.Errorf("got network %v want %v", network, tt.network)
+			}
+		})
+	}
+
+	// Mainnet prefix with a testnet header
+	data, _ := hex.DecodeString("609493315cd92eb5d8c4304e67b7e16ae36d61d34502694657811a2c8e")
+	encoded, err := bech32.EncodeFromBase256("addr", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewAddress(encoded); !errors.Is(err, ErrWrongNetwork) {
+		t.Errorf("got error %v want %v", err, ErrWrongNetwork)
+	}
+}
+
+func TestNewBaseAddress(t *testing.T) {
+	// CIP-19 test vectors
+	paymentHash, _ := hex.DecodeString("9493315cd92eb5d8c4304e67b7e16ae36d61d34502694657811a2c8e")
+	stakeHash, _ := hex.DecodeString("337b62cfff6403a06a3acbc34f8c46003c69fe79a3628cefa9c47251")
+	scriptHash, _ := hex.DecodeString("c37b1b5dc0669f1d3c61a6fddb2e8fde96be87b881c60bce8e8d542f")
+	credential := func(credType CredentialType, hash []byte) Credential {
+		cred, err := NewCredential(credType, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cred
+	}
+
+	tests := []struct {
+		name    string
+		payment Credential
+		stake   Credential
+		want    Address
+	}{
+		{
+			name:    "key/key",
+			payment: credential(KeyCredential, paymentHash),
+			stake:   credential(KeyCredential, stakeHash),
+			want:    "addr1qx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3n0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgse35a3x",
+		},
+		{
+			name:    "script/key",
+			payment: credential(ScriptCredential, scriptHash),
+			stake:   credential(KeyCredential, stakeHash),
+			want:    "addr1z8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gten0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgs9yc0hh",
+		},
+		{
+			name:    "key/script",
+			payment: credential(KeyCredential, paymentHash),
+			stake:   credential(ScriptCredential, scriptHash),
+			want:    "addr1yx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzerkr0vd4msrxnuwnccdxlhdjar77j6lg0wypcc9uar5d2shs2z78ve",
+		},
+		{
+			name:    "script/script",
+			payment: credential(ScriptCredential, scriptHash),
+			stake:   credential(ScriptCredential, scriptHash),
+			want:    "addr1x8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gt7r0vd4msrxnuwnccdxlhdjar77j6lg0wypcc9uar5d2shskhj42g",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewBaseAddress(Mainnet, tt.payment, tt.stake); got != tt.want {
+				t.Errorf("got %v want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewCredential(KeyCredential, paymentHash[:27]); err == nil {
+		t.Errorf("expected invalid credential hash length error")
+	}
+}
+
+func TestNewEnterpriseAndRewardAddress(t *testing.T) {
+	// CIP-19 test vectors
+	paymentHash, _ := hex.DecodeString("9493315cd92eb5d8c4304e67b7e16ae36d61d34502694657811a2c8e")
+	stakeHash, _ := hex.DecodeString("337b62cfff6403a06a3acbc34f8c46003c69fe79a3628cefa9c47251")
+	scriptHash, _ := hex.DecodeString("c37b1b5dc0669f1d3c61a6fddb2e8fde96be87b881c60bce8e8d542f")
+
+	tests := []struct {
+		name    string
+		network Network
+		got     Address
+		want    Address
+	}{
+		{
+			name:    "enterprise key",
+			network: Mainnet,
+			got:     NewEnterpriseAddress(Mainnet, Credential{Type: KeyCredential, Hash: paymentHash}),
+			want:    "addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8",
+		},
+		{
+			name:    "enterprise script",
+			network: Mainnet,
+			got:     NewEnterpriseAddress(Mainnet, Credential{Type: ScriptCredential, Hash: scriptHash}),
+			want:    "addr1w8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtcyjy7wx",
+		},
+		{
+			name:    "enterprise testnet",
+			network: Testnet,
+			got:     NewEnterpriseAddress(Testnet, Credential{Type: KeyCredential, Hash: paymentHash}),
+			want:    "addr_test1vz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzerspjrlsz",
+		},
+		{
+			name:    "reward key",
+			network: Mainnet,
+			got:     NewRewardAddress(Mainnet, Credential{Type: KeyCredential, Hash: stakeHash}),
+			want:    "stake1uyehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gh6ffgw",
+		},
+		{
+			name:    "reward script",
+			network: Mainnet,
+			got:     NewRewardAddress(Mainnet, Credential{Type: ScriptCredential, Hash: scriptHash}),
+			want:    "stake178phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtcccycj5",
+		},
+		{
+			name:    "reward testnet",
+			network: Testnet,
+			got:     NewRewardAddress(Testnet, Credential{Type: KeyCredential, Hash: stakeHash}),
+			want:    "stake_test1uqehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gssrtvn",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v want %v", tt.got, tt.want)
+			}
+			if err := ValidateAddressString(string(tt.got), tt.network); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestAddress_PaymentKeyHash(t *testing.T) {
+	// CIP-19 test vectors
+	paymentHash, _ := hex.DecodeString("9493315cd92eb5d8c4304e67b7e16ae36d61d34502694657811a2c8e")
+
+	tests := []struct {
+		name    string
+		address Address
+		wantErr bool
+	}{
+		{name: "base", address: "addr1qx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3n0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgse35a3x"},
+		{name: "base script stake", address: "addr1yx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzerkr0vd4msrxnuwnccdxlhdjar77j6lg0wypcc9uar5d2shs2z78ve"},
+		{name: "enterprise", address: "addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8"},
+		{name: "script", address: "addr1w8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtcyjy7wx", wantErr: true},
+		{name: "reward", address: "stake1uyehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gh6ffgw", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.address.PaymentKeyHash()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, paymentHash) {
+				t.Errorf("got %x want %x", got, paymentHash)
+			}
+		})
+	}
+
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	addr := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	if got, err := addr.PaymentKeyHash(); err != nil || !bytes.Equal(got, keyHash(key.ExtendedVerificationKey())) {
+		t.Errorf("got %x, %v want the key hash", got, err)
+	}
+}
+
+func TestAddress_StakeKeyHash(t *testing.T) {
+	// CIP-19 test vectors
+	stakeHash, _ := hex.DecodeString("337b62cfff6403a06a3acbc34f8c46003c69fe79a3628cefa9c47251")
+
+	tests := []struct {
+		name    string
+		address Address
+		wantErr bool
+	}{
+		{name: "base", address: "addr1qx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3n0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgse35a3x"},
+		{name: "reward", address: "stake1uyehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gh6ffgw"},
+		{name: "base script stake", address: "addr1yx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzerkr0vd4msrxnuwnccdxlhdjar77j6lg0wypcc9uar5d2shs2z78ve", wantErr: true},
+		{name: "enterprise", address: "addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.address.StakeKeyHash()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, stakeHash) {
+				t.Errorf("got %x want %x", got, stakeHash)
+			}
+		})
+	}
+}
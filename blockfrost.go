@@ -0,0 +1,256 @@
+package cardano
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Blockfrost API URLs of the networks.
+const (
+	BlockfrostMainnetURL = "https://cardano-mainnet.blockfrost.io/api/v0"
+	BlockfrostPreprodURL = "https://cardano-preprod.blockfrost.io/api/v0"
+	BlockfrostPreviewURL = "https://cardano-preview.blockfrost.io/api/v0"
+)
+
+const (
+	// blockfrostPageSize is the maximum number of items of a page.
+	blockfrostPageSize = 100
+	// blockfrostSubmittedSize is the number of submitted transaction ids
+	// tracked by an idempotent Blockfrost.
+	blockfrostSubmittedSize = 1000
+)
+
+// blockfrostDuplicateErrors are parts of the submit error messages of a
+// transaction already in the mempool or the ledger.
+var blockfrostDuplicateErrors = []string{"AlreadyInMempool", "already in the mempool", "already in the ledger"}
+
+// Blockfrost queries and submits transactions to a node through the
+// Blockfrost API.
+type Blockfrost struct {
+	projectID string
+	url       string
+	client    *http.Client
+
+	mu         sync.Mutex
+	idempotent bool
+	submitted  []TransactionID
+}
+
+// NewBlockfrost returns a Blockfrost authenticated with the project id of the
+// network of the API url, e.g. BlockfrostPreviewURL. NewBlockfrostNetwork
+// picks the url of the network.
+func NewBlockfrost(projectID, url string) *Blockfrost {
+	return &Blockfrost{projectID: projectID, url: url, client: http.DefaultClient}
+}
+
+// blockfrostURLs are the API URLs of the network prefixes of the project ids.
+var blockfrostURLs = map[string]string{
+	"mainnet": BlockfrostMainnetURL,
+	"preprod": BlockfrostPreprodURL,
+	"preview": BlockfrostPreviewURL,
+}
+
+// NewBlockfrostNetwork returns a Blockfrost using the API url of the network
+// of the project id, the project ids of each network are prefixed by its name.
+// An error is returned if it isn't a project of the network, the testnet is
+// preprod or preview.
+func NewBlockfrostNetwork(projectID string, network Network) (*Blockfrost, error) {
+	for prefix, url := range blockfrostURLs {
+		if !strings.HasPrefix(projectID, prefix) {
+			continue
+		}
+		if isMainnet := prefix == "mainnet"; isMainnet != (network == Mainnet) {
+			return nil, fmt.Errorf("%w, got a %v project id", ErrNetworkMismatch, prefix)
+		}
+		return NewBlockfrost(projectID, url), nil
+	}
+	return nil, fmt.Errorf("unknown network of the blockfrost project id")
+}
+
+type blockfrostError struct {
+	StatusCode int    `json:"status_code"`
+	Err        string `json:"error"`
+	Message    string `json:"message"`
+}
+
+func (err *blockfrostError) Error() string {
+	return fmt.Sprintf("blockfrost error %v: %v", err.StatusCode, err.Message)
+}
+
+type blockfrostUtxo struct {
+	Address     Address      `json:"address"`
+	TxHash      string       `json:"tx_hash"`
+	OutputIndex uint64       `json:"output_index"`
+	Amount      []AmountUnit `json:"amount"`
+}
+
+type blockfrostBlock struct {
+	Slot   uint64 `json:"slot"`
+	Height uint64 `json:"height"`
+	Epoch  uint64 `json:"epoch"`
+}
+
+func (b *Blockfrost) UTxOs(address Address) ([]Utxo, error) {
+	utxos := []Utxo{}
+	for page := 1; ; page++ {
+		blockfrostUtxos := []blockfrostUtxo{}
+		path := fmt.Sprintf("/addresses/%v/utxos?count=%v&page=%v", address, blockfrostPageSize, page)
+		if err := b.call(http.MethodGet, path, "", nil, &blockfrostUtxos); err != nil {
+			// Addresses without any transaction aren't found
+			if apiErr, ok := err.(*blockfrostError); ok && apiErr.StatusCode == http.StatusNotFound {
+				return utxos, nil
+			}
+			return nil, err
+		}
+
+		for _, utxo := range blockfrostUtxos {
+			value, err := ParseValueFromUnits(utxo.Amount)
+			if err != nil {
+				return nil, fmt.Errorf("invalid utxo %v#%v amount: %w", utxo.TxHash, utxo.OutputIndex, err)
+			}
+			utxos = append(utxos, Utxo{
+				Address: utxo.Address,
+				TxId:    TransactionID(utxo.TxHash),
+				Amount:  value.Coin,
+				Index:   utxo.OutputIndex,
+				Assets:  value.Assets,
+			})
+		}
+		if len(blockfrostUtxos) < blockfrostPageSize {
+			return utxos, nil
+		}
+	}
+}
+
+func (b *Blockfrost) QueryTip() (NodeTip, error) {
+	block := blockfrostBlock{}
+	if err := b.call(http.MethodGet, "/blocks/latest", "", nil, &block); err != nil {
+		return NodeTip{}, err
+	}
+	return NodeTip{
+		Epoch: block.Epoch,
+		Block: block.Height,
+		Slot:  block.Slot,
+	}, nil
+}
+
+// Tip returns the slot of the latest block.
+func (b *Blockfrost) Tip() (uint64, error) {
+	return b.TipContext(context.Background())
+}
+
+// TipContext returns the slot of the latest block, the request is canceled
+// when the context is done.
+func (b *Blockfrost) TipContext(ctx context.Context) (uint64, error) {
+	block := blockfrostBlock{}
+	if err := b.callContext(ctx, http.MethodGet, "/blocks/latest", "", nil, &block); err != nil {
+		return 0, err
+	}
+	return block.Slot, nil
+}
+
+// SetIdempotentSubmit makes a resubmit of a transaction already in the
+// mempool or the ledger succeed with its id, e.g. when retrying a submit whose
+// response was lost. The inputs of a transaction previously submitted by b are
+// spent once accepted, so its resubmit failing with BadInputsUTxO succeeds too.
+func (b *Blockfrost) SetIdempotentSubmit(enabled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.idempotent = enabled
+}
+
+func (b *Blockfrost) SubmitTx(tx *Transaction) (TransactionID, error) {
+	var id TransactionID
+	want := tx.ID()
+	if err := b.call(http.MethodPost, "/tx/submit", "application/cbor", bytes.NewReader(tx.Bytes()), &id); err != nil {
+		if b.isDuplicate(want, err) {
+			return want, nil
+		}
+		return "", err
+	}
+	if id != want {
+		return "", fmt.Errorf("submitted transaction id mismatch, got %v want %v", id, want)
+	}
+	b.trackSubmitted(id)
+	return id, nil
+}
+
+// isDuplicate returns whether err is the submit error of the transaction of
+// the id already in the mempool or the ledger.
+func (b *Blockfrost) isDuplicate(id TransactionID, err error) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	apiErr, ok := err.(*blockfrostError)
+	if !b.idempotent || !ok || apiErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	for _, duplicate := range blockfrostDuplicateErrors {
+		if strings.Contains(apiErr.Message, duplicate) {
+			return true
+		}
+	}
+	if strings.Contains(apiErr.Message, "BadInputsUTxO") {
+		for _, submitted := range b.submitted {
+			if submitted == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// trackSubmitted records the id of a submitted transaction, the oldest ids are
+// dropped after blockfrostSubmittedSize.
+func (b *Blockfrost) trackSubmitted(id TransactionID) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.idempotent {
+		return
+	}
+	b.submitted = append(b.submitted, id)
+	if len(b.submitted) > blockfrostSubmittedSize {
+		b.submitted = b.submitted[len(b.submitted)-blockfrostSubmittedSize:]
+	}
+}
+
+// call sends a request to the API and decodes its JSON result.
+func (b *Blockfrost) call(method, path, contentType string, body io.Reader, result interface{}) error {
+	return b.callContext(context.Background(), method, path, contentType, body, result)
+}
+
+// callContext is call with a request canceled when the context is done.
+func (b *Blockfrost) callContext(ctx context.Context, method, path, contentType string, body io.Reader, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, b.url+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("project_id", b.projectID)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	res, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		apiErr := &blockfrostError{}
+		if err := json.Unmarshal(data, apiErr); err != nil || apiErr.StatusCode == 0 {
+			apiErr = &blockfrostError{StatusCode: res.StatusCode, Message: string(data)}
+		}
+		return apiErr
+	}
+	return json.Unmarshal(data, result)
+}
@@ -0,0 +1,238 @@
+package cardano
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newBlockfrostTestServer(t *testing.T, address Address) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("project_id") != "preprodtest" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"status_code":403,"error":"Forbidden","message":"Invalid project token."}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/blocks/latest":
+			w.Write([]byte(`{"hash":"4ea1ba291e8eef538635a53e59fddba7810d1679631cc3aed7c8e6c4091a516a","epoch":93,"slot":39916796,"height":1665927}`))
+		case "/addresses/" + string(address) + "/utxos":
+			w.Write([]byte(`[
+				{"address":"` + string(address) + `","tx_hash":"6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1","output_index":0,"amount":[{"unit":"lovelace","quantity":"42000000"}]},
+				{"address":"` + string(address) + `","tx_hash":"a3d2c16e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4","output_index":1,"amount":[{"unit":"lovelace","quantity":"1500000"},{"unit":"b0d07d45fe9514f80213f4020e5a61241458be626841cde717cb38a7","quantity":"5"}]}
+			]`))
+		case "/tx/submit":
+			if got, want := r.Header.Get("Content-Type"), "application/cbor"; got != want {
+				t.Errorf("got content type %v want %v", got, want)
+			}
+			data, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			tx, err := DecodeTransactionBytes(data)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			json.NewEncoder(w).Encode(tx.ID())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status_code":404,"error":"Not Found","message":"The requested component has not been found."}`))
+		}
+	}))
+}
+
+func TestBlockfrost(t *testing.T) {
+	address := Address("addr_test1vqgjd0t02q9yglcjwdc8dht9tz6gkfpqqm7evs5csrklakcqmwv40")
+	server := newBlockfrostTestServer(t, address)
+	defer server.Close()
+	blockfrost := NewBlockfrost("preprodtest", server.URL)
+
+	tip, err := blockfrost.QueryTip()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (NodeTip{Epoch: 93, Block: 1665927, Slot: 39916796}); tip != want {
+		t.Errorf("got %v want %v", tip, want)
+	}
+	slot, err := blockfrost.Tip()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := slot, uint64(39916796); got != want {
+		t.Errorf("got slot %v want %v", got, want)
+	}
+
+	utxos, err := blockfrost.UTxOs(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(utxos), 2; got != want {
+		t.Fatalf("got %v utxos want %v", got, want)
+	}
+	want := Utxo{
+		Address: address,
+		TxId:    "a3d2c16e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4",
+		Amount:  1500000,
+		Index:   1,
+		Assets:  MultiAsset{"b0d07d45fe9514f80213f4020e5a61241458be626841cde717cb38a7": {"": 5}},
+	}
+	if !reflect.DeepEqual(utxos[1], want) {
+		t.Errorf("got %v want %v", utxos[1], want)
+	}
+
+	// Unused addresses aren't found
+	utxos, err = blockfrost.UTxOs(Address("addr_test1vz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzermtvs0e6"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(utxos) != 0 {
+		t.Errorf("got %v utxos want none", len(utxos))
+	}
+
+	tx := Transaction{Body: TransactionBody{
+		Inputs:  []TransactionInput{{ID: make([]byte, 32), Index: 0}},
+		Outputs: []TransactionOutput{{Address: address.Bytes(), Amount: 1000000}},
+		Fee:     170000,
+		Ttl:     100,
+	}}
+	if id, err := blockfrost.SubmitTx(&tx); err != nil {
+		t.Error(err)
+	} else if id != tx.ID() {
+		t.Errorf("got id %v want %v", id, tx.ID())
+	}
+
+	blockfrost.projectID = "mainnettest"
+	if _, err := blockfrost.QueryTip(); err == nil {
+		t.Errorf("expected invalid project error")
+	} else if apiErr, ok := err.(*blockfrostError); !ok || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("got error %v want %v", err, http.StatusForbidden)
+	}
+}
+
+func TestBlockfrost_DuplicateSubmit(t *testing.T) {
+	address := Address("addr_test1vqgjd0t02q9yglcjwdc8dht9tz6gkfpqqm7evs5csrklakcqmwv40")
+	accepted := map[TransactionID]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		tx, err := DecodeTransactionBytes(data)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if accepted[tx.ID()] || tx.Body.Fee == 180000 {
+			// The inputs of the accepted transaction are spent
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status_code":400,"error":"Bad Request","message":"\"transaction submit error ShelleyTxValidationError ShelleyBasedEraBabbage (ApplyTxError [UtxowFailure (UtxoFailure (FromAlonzoUtxoFail (BadInputsUTxO (fromList [TxIn (TxId {unTxId = SafeHash \\\"0000000000000000000000000000000000000000000000000000000000000000\\\"}) (TxIx 0)]))))])\""}`))
+			return
+		}
+		if tx.Body.Fee == 190000 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status_code":400,"error":"Bad Request","message":"transaction submit error: the transaction is already in the mempool"}`))
+			return
+		}
+		accepted[tx.ID()] = true
+		json.NewEncoder(w).Encode(tx.ID())
+	}))
+	defer server.Close()
+	blockfrost := NewBlockfrost("preprodtest", server.URL)
+
+	tx := Transaction{Body: TransactionBody{
+		Inputs:  []TransactionInput{{ID: make([]byte, 32), Index: 0}},
+		Outputs: []TransactionOutput{{Address: address.Bytes(), Amount: 1000000}},
+		Fee:     170000,
+		Ttl:     100,
+	}}
+	if _, err := blockfrost.SubmitTx(&tx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := blockfrost.SubmitTx(&tx); err == nil {
+		t.Error("expected an error without idempotent submit")
+	}
+
+	blockfrost.SetIdempotentSubmit(true)
+	tx.Body.Ttl = 200
+	for i := 0; i < 2; i++ {
+		if id, err := blockfrost.SubmitTx(&tx); err != nil {
+			t.Error(err)
+		} else if id != tx.ID() {
+			t.Errorf("got id %v want %v", id, tx.ID())
+		}
+	}
+
+	tx.Body.Fee = 190000
+	if id, err := blockfrost.SubmitTx(&tx); err != nil {
+		t.Error(err)
+	} else if id != tx.ID() {
+		t.Errorf("got id %v want %v", id, tx.ID())
+	}
+
+	// Another transaction spending the same inputs is a double spend
+	tx.Body.Fee = 180000
+	if _, err := blockfrost.SubmitTx(&tx); err == nil {
+		t.Error("expected a double spend error")
+	}
+}
+
+func TestBlockfrost_TipContext(t *testing.T) {
+	canceled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(canceled)
+	}))
+	defer server.Close()
+	blockfrost := NewBlockfrost("preprodtest", server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := NewTxBuilder(ShelleyProtocol).SetTTLFromTip(ctx, blockfrost, 7200); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v want %v", err, context.DeadlineExceeded)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("tip request not canceled")
+	}
+}
+
+func TestNewBlockfrostNetwork(t *testing.T) {
+	tests := []struct {
+		projectID string
+		network   Network
+		url       string
+	}{
+		{"mainnetabc", Mainnet, BlockfrostMainnetURL},
+		{"preprodabc", Testnet, BlockfrostPreprodURL},
+		{"previewabc", Testnet, BlockfrostPreviewURL},
+	}
+	for _, tt := range tests {
+		blockfrost, err := NewBlockfrostNetwork(tt.projectID, tt.network)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := blockfrost.url, tt.url; got != want {
+			t.Errorf("got url %v want %v", got, want)
+		}
+	}
+
+	if _, err := NewBlockfrostNetwork("mainnetabc", Testnet); !errors.Is(err, ErrNetworkMismatch) {
+		t.Errorf("got error %v want %v", err, ErrNetworkMismatch)
+	}
+	if _, err := NewBlockfrostNetwork("preprodabc", Mainnet); !errors.Is(err, ErrNetworkMismatch) {
+		t.Errorf("got error %v want %v", err, ErrNetworkMismatch)
+	}
+	if _, err := NewBlockfrostNetwork("abc", Mainnet); err == nil {
+		t.Error("expected an unknown network error")
+	}
+}
@@ -1,30 +1,103 @@
 package cardano
 
-import "time"
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
 
 const (
 	shelleyStartTimestamp = 1596491091
 	shelleyStartSlot      = 4924800
+	shelleySlotLength     = time.Second
 	slotMargin            = 1200
+
+	// maxTTLDuration is the stability window (3k/f slots), past it the
+	// conversion between slots and time isn't guaranteed.
+	maxTTLDuration = 129600 * shelleySlotLength
 )
 
 var ShelleyProtocol = ProtocolParams{
 	MinimumUtxoValue: 1000000,
 	MinFeeA:          44,
 	MinFeeB:          155381,
+	KeyDeposit:       2000000,
+	PoolDeposit:      500000000,
+	MaxTxSize:        16384,
 }
 
+// LiveTTL returns the current mainnet slot, NetworkConfig.SlotAt returns it
+// for the other networks.
 func LiveTTL() uint64 {
-	shelleyStart := time.Unix(shelleyStartTimestamp, 0)
-	return uint64(shelleyStartSlot + time.Since(shelleyStart).Seconds())
+	return MainnetConfig.SlotAt(time.Now())
 }
 
 type TXBodyBuilder struct {
 	Protocol ProtocolParams
 	TTL      uint64
+	Strategy SelectionStrategy
+
+	// Network overrides the protocol parameters and the slot timing used for
+	// the default TTL when set.
+	Network *NetworkConfig
+
+	// Metadata is hashed in the body and accounted for in the fee, it's
+	// attached to the transaction by AddSignatures.
+	Metadata transactionMetadata
 }
 
 func (builder TXBodyBuilder) Build(receiver Address, pickedUtxos []Utxo, amount uint64, change Address) (*TransactionBody, error) {
+	return builder.BuildMultiOutput(receiverOutputs(receiver, amount), pickedUtxos, change)
+}
+
+// BuildMultiOutput builds the transaction body paying the outputs from the
+// picked utxos, the change output is the first one.
+func (builder TXBodyBuilder) BuildMultiOutput(outputs []TransactionOutput, pickedUtxos []Utxo, change Address) (*TransactionBody, error) {
+	body, inputAmount, err := builder.body(outputs, pickedUtxos)
+	if err != nil {
+		return nil, err
+	}
+	if err := body.addFee(inputAmount, change, nil, builder.protocol(), 0); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// BuildWithSelection picks the inputs from utxos using the builder's
+// SelectionStrategy and builds the transaction body.
+func (builder TXBodyBuilder) BuildWithSelection(receiver Address, utxos []Utxo, amount uint64, change Address) (*TransactionBody, error) {
+	target := amount
+	for {
+		pickedUtxos, err := builder.strategy().Select(utxos, target)
+		if err != nil {
+			return nil, err
+		}
+		body, err := builder.Build(receiver, pickedUtxos, amount, change)
+		if err == nil {
+			return body, nil
+		}
+
+		// The picked utxos don't cover the fee, select again including it
+		tmpBody, _, bodyErr := builder.body(receiverOutputs(receiver, amount), pickedUtxos)
+		if bodyErr != nil {
+			return nil, bodyErr
+		}
+		tmpBody.Fee = 200000
+		fee := tmpBody.calculateMinFee(builder.protocol())
+		if amount+fee <= target {
+			return nil, err
+		}
+		target = amount + fee
+	}
+}
+
+// receiverOutputs returns the single output paying amount to the receiver.
+func receiverOutputs(receiver Address, amount uint64) []TransactionOutput {
+	return []TransactionOutput{{Address: receiver.Bytes(), Amount: amount}}
+}
+
+func (builder TXBodyBuilder) body(outputs []TransactionOutput, pickedUtxos []Utxo) (*TransactionBody, uint64, error) {
 	var inputAmount uint64
 	var inputs []TransactionInput
 	for _, utxo := range pickedUtxos {
@@ -35,34 +108,75 @@ func (builder TXBodyBuilder) Build(receiver Address, pickedUtxos []Utxo, amount
 		inputAmount += utxo.Amount
 	}
 
-	var outputs []TransactionOutput
-	outputs = append(outputs, TransactionOutput{
-		Address: receiver.Bytes(),
-		Amount:  amount,
-	})
-
 	body := TransactionBody{
 		Inputs:  inputs,
-		Outputs: outputs,
+		Outputs: append([]TransactionOutput(nil), outputs...),
 		Ttl:     builder.ttl(),
 	}
-	if err := body.addFee(inputAmount, change, builder.protocol()); err != nil {
-		return nil, err
+	if err := body.SetMetadata(builder.Metadata); err != nil {
+		return nil, 0, err
 	}
 
-	return &body, nil
+	return &body, inputAmount, nil
 }
 
 func (builder TXBodyBuilder) ttl() uint64 {
+	if builder.TTL == 0 && builder.Network != nil {
+		margin := slotMargin * shelleySlotLength / builder.Network.SlotLength
+		return builder.Network.SlotAt(time.Now()) + uint64(margin)
+	}
 	if builder.TTL == 0 {
 		return LiveTTL() + slotMargin
 	}
 	return builder.TTL
 }
 
+func (builder TXBodyBuilder) strategy() SelectionStrategy {
+	if builder.Strategy == nil {
+		return LargestFirst{}
+	}
+	return builder.Strategy
+}
+
 func (builder TXBodyBuilder) protocol() ProtocolParams {
-	if builder.Protocol == (ProtocolParams{}) {
+	if builder.Network != nil {
+		return builder.Network.Protocol
+	}
+	if reflect.DeepEqual(builder.Protocol, ProtocolParams{}) {
 		return ShelleyProtocol
 	}
 	return builder.Protocol
 }
+
+// BuildExact assembles a body from the given inputs, outputs, fee and TTL
+// without any fee or change adjustment, returning an error if the input
+// values don't equal the outputs plus the fee.
+func BuildExact(inputs []TransactionInput, inputValues []uint64, outputs []TransactionOutput, fee, ttl uint64) (*TransactionBody, error) {
+	if len(inputs) != len(inputValues) {
+		return nil, fmt.Errorf("missmatch length of inputs and input values")
+	}
+
+	var inputAmount, outputAmount uint64
+	for _, value := range inputValues {
+		if inputAmount+value < inputAmount {
+			return nil, fmt.Errorf("input values overflow")
+		}
+		inputAmount += value
+	}
+	for _, txOut := range outputs {
+		if outputAmount+txOut.Amount < outputAmount {
+			return nil, fmt.Errorf("output amounts overflow")
+		}
+		outputAmount += txOut.Amount
+	}
+	if outputAmount+fee < outputAmount || inputAmount != outputAmount+fee {
+		return nil, fmt.Errorf("unbalanced transaction, got inputs %v want outputs %v plus fee %v", inputAmount, outputAmount, fee)
+	}
+
+	return &TransactionBody{
+		Inputs:  inputs,
+		Outputs: outputs,
+		Fee:     fee,
+		Ttl:     ttl,
+	}, nil
+}
@@ -0,0 +1,82 @@
+package cardano
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+func TestBuildExact(t *testing.T) {
+	inputs := []TransactionInput{{ID: make([]byte, 32), Index: 0}, {ID: make([]byte, 32), Index: 1}}
+	outputs := []TransactionOutput{
+		{Address: make([]byte, 29), Amount: 2000000},
+		{Address: make([]byte, 29), Amount: 2831287},
+	}
+
+	body, err := BuildExact(inputs, []uint64{3000000, 2000000}, outputs, 168713, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body.Fee != 168713 || body.Ttl != 100 || len(body.Outputs) != 2 {
+		t.Errorf("got fee %v ttl %v and %v outputs want 168713, 100 and 2", body.Fee, body.Ttl, len(body.Outputs))
+	}
+
+	if _, err := BuildExact(inputs, []uint64{3000000, 2000000}, outputs, 168712, 100); err == nil {
+		t.Errorf("expected unbalanced transaction error")
+	}
+	if _, err := BuildExact(inputs, []uint64{3000000}, outputs, 168713, 100); err == nil {
+		t.Errorf("expected input values length error")
+	}
+	if _, err := BuildExact(inputs, []uint64{maxUint64, 2}, outputs, 168713, 100); err == nil {
+		t.Errorf("expected overflow error")
+	}
+}
+
+func TestTXBodyBuilder_BuildMultiOutput(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("change address"), "foo")
+	change := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	outputs := []TransactionOutput{
+		{Address: make([]byte, 29), Amount: 2000000},
+		{Address: make([]byte, 29), Amount: 1500000},
+		{Address: make([]byte, 29), Amount: 1000000},
+	}
+	builder := TXBodyBuilder{Protocol: ShelleyProtocol, TTL: 100}
+
+	utxos := testUtxos(4000000, 3000000)
+	body, err := builder.BuildMultiOutput(outputs, utxos, change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(body.Outputs), 4; got != want {
+		t.Fatalf("got %v outputs want %v", got, want)
+	}
+	if got, want := body.Outputs[0].Address, change.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("got change address %x want %x", got, want)
+	}
+	if got, want := body.Outputs[0].Amount+body.Fee+4500000, sumUtxos(utxos); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if !reflect.DeepEqual(body.Outputs[1:], outputs) {
+		t.Errorf("got outputs %v want %v", body.Outputs[1:], outputs)
+	}
+
+	// The change below the min utxo value is burned
+	utxos = testUtxos(5000000)
+	body, err = builder.BuildMultiOutput(outputs, utxos, change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(body.Outputs), 3; got != want {
+		t.Fatalf("got %v outputs want %v", got, want)
+	}
+	if got, want := body.Fee, uint64(500000); got != want {
+		t.Errorf("got fee %v want %v", got, want)
+	}
+
+	if _, err := builder.BuildMultiOutput(outputs, testUtxos(4000000), change); !errors.Is(err, ErrInsufficientInput) {
+		t.Errorf("got error %v want %v", err, ErrInsufficientInput)
+	}
+}
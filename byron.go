@@ -0,0 +1,128 @@
+package cardano
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/tclairet/cardano-go/crypto"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+// byronAddress is the cbor representation of a Byron address, the payload is
+// the cbor of a byronAddressPayload wrapped in tag 24.
+type byronAddress struct {
+	_       struct{} `cbor:",toarray"`
+	Payload cbor.Tag
+	CRC     uint32
+}
+
+type byronAddressPayload struct {
+	_          struct{} `cbor:",toarray"`
+	Root       []byte   // 28 bytes
+	Attributes cbor.RawMessage
+	Type       uint64
+}
+
+// parseByronAddress returns the root and the cbor of the attributes of the
+// base58 encoded Byron address.
+func parseByronAddress(addr Address) (root, attributes []byte, err error) {
+	data, err := base58Decode(string(addr))
+	if err != nil {
+		return nil, nil, err
+	}
+	decoded := byronAddress{}
+	if err := cbor.Unmarshal(data, &decoded); err != nil {
+		return nil, nil, fmt.Errorf("invalid byron address %v: %v", addr, err)
+	}
+	payloadBytes, ok := decoded.Payload.Content.([]byte)
+	if decoded.Payload.Number != 24 || !ok {
+		return nil, nil, fmt.Errorf("invalid byron address %v payload", addr)
+	}
+	if got := crc32.ChecksumIEEE(payloadBytes); got != decoded.CRC {
+		return nil, nil, fmt.Errorf("invalid byron address %v checksum, got %x want %x", addr, got, decoded.CRC)
+	}
+	payload := byronAddressPayload{}
+	if err := cbor.Unmarshal(payloadBytes, &payload); err != nil {
+		return nil, nil, fmt.Errorf("invalid byron address %v: %v", addr, err)
+	}
+	if len(payload.Root) != 28 {
+		return nil, nil, fmt.Errorf("invalid byron address %v root length %v", addr, len(payload.Root))
+	}
+	return payload.Root, payload.Attributes, nil
+}
+
+// byronProtocolMagicAttribute is the key of the protocol magic in the
+// attributes of a Byron address, only set for testnet addresses.
+const byronProtocolMagicAttribute = 2
+
+// byronNetwork returns the network of the Byron address, Testnet if its
+// attributes have a protocol magic, Mainnet otherwise or if it's invalid.
+func byronNetwork(addr Address) Network {
+	_, attributes, err := parseByronAddress(addr)
+	if err != nil {
+		return Mainnet
+	}
+	decoded := map[uint64]cbor.RawMessage{}
+	if err := cbor.Unmarshal(attributes, &decoded); err != nil {
+		return Mainnet
+	}
+	if _, ok := decoded[byronProtocolMagicAttribute]; ok {
+		return Testnet
+	}
+	return Mainnet
+}
+
+// byronAddressRoot returns the root of the public key Byron address of the
+// verification key with the attributes, the hash of its spending data.
+func byronAddressRoot(xvk crypto.ExtendedVerificationKey, attributes []byte) ([]byte, error) {
+	spendingData, err := cbor.Marshal([]interface{}{
+		uint64(0), // public key address
+		[]interface{}{uint64(0), []byte(xvk)},
+		cbor.RawMessage(attributes),
+	})
+	if err != nil {
+		return nil, err
+	}
+	sha := sha3.Sum256(spendingData)
+	hash, err := blake2b.New(224/8, nil)
+	if err != nil {
+		return nil, err
+	}
+	hash.Write(sha[:])
+	return hash.Sum(nil), nil
+}
+
+// base58Decode decodes data encoded with the bitcoin alphabet.
+func base58Decode(s string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+
+	// Repeatedly multiply the big endian number by 58, little endian bytes
+	decoded := []byte{}
+	for i := zeros; i < len(s); i++ {
+		carry := bytes.IndexByte([]byte(base58Alphabet), s[i])
+		if carry < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", s[i])
+		}
+		for j := range decoded {
+			carry += int(decoded[j]) * 58
+			decoded[j] = byte(carry)
+			carry >>= 8
+		}
+		for carry > 0 {
+			decoded = append(decoded, byte(carry))
+			carry >>= 8
+		}
+	}
+
+	out := make([]byte, zeros+len(decoded))
+	for i, b := range decoded {
+		out[len(out)-1-i] = b
+	}
+	return out, nil
+}
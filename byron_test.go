@@ -0,0 +1,131 @@
+package cardano
+
+import (
+	"bytes"
+	"encoding/hex"
+	"hash/crc32"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+// newByronAddress returns the public key Byron address of the key without
+// attributes.
+func newByronAddress(t *testing.T, xvk crypto.ExtendedVerificationKey) Address {
+	return newByronAddressWithAttributes(t, xvk, []byte{0xa0})
+}
+
+// newByronAddressWithAttributes returns the public key Byron address of the
+// key with the cbor map of attributes.
+func newByronAddressWithAttributes(t *testing.T, xvk crypto.ExtendedVerificationKey, attributes []byte) Address {
+	root, err := byronAddressRoot(xvk, attributes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := cbor.Marshal(byronAddressPayload{Root: root, Attributes: attributes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := cbor.Marshal(byronAddress{
+		Payload: cbor.Tag{Number: 24, Content: payload},
+		CRC:     crc32.ChecksumIEEE(payload),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Address(base58Encode(data))
+}
+
+func TestParseByronAddress(t *testing.T) {
+	// CIP-19 test vector
+	root, attributes, err := parseByronAddress("Ae2tdPwUPEZFRbyhz3cpfC2CumGzNkFBN2L42rcUc2yjQpEkxDbkPodpMAi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := hex.EncodeToString(root), "ba970ad36654d8dd8f74274b733452ddeab9a62a397746be3c42ccdd"; got != want {
+		t.Errorf("got root %v want %v", got, want)
+	}
+	if !bytes.Equal(attributes, []byte{0xa0}) {
+		t.Errorf("got attributes %x want a0", attributes)
+	}
+
+	if _, _, err := parseByronAddress("Ae2tdPwUPEZFRbyhz3cpfC2CumGzNkFBN2L42rcUc2yjQpEkxDbkPodpMAj"); err == nil {
+		t.Errorf("expected invalid checksum error")
+	}
+	if _, _, err := parseByronAddress("addr0"); err == nil {
+		t.Errorf("expected invalid base58 error")
+	}
+}
+
+func TestTXBuilder_AddByronInput(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("byron"), "foo")
+	byron := newByronAddress(t, key.ExtendedVerificationKey())
+	receiverKey := crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(receiverKey.ExtendedVerificationKey()))
+
+	utxo := Utxo{
+		Address: byron,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  10000000,
+	}
+	builder := NewTxBuilder(ShelleyProtocol)
+	if err := builder.AddByronInput(key.ExtendedVerificationKey(), utxo); err != nil {
+		t.Fatal(err)
+	}
+	builder.AddOutput(receiver, 2000000)
+	builder.SetChangeAddress(receiver)
+	builder.SetTtl(100)
+	builder.Sign(key)
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(tx.WitnessSet.Bootstrap), 1; got != want {
+		t.Fatalf("got %v bootstrap witnesses want %v", got, want)
+	}
+	if got, want := len(tx.WitnessSet.VKeyWitnessSet), 0; got != want {
+		t.Errorf("got %v vkey witnesses want %v", got, want)
+	}
+	if got, want := tx.Body.Fee, CalculateFee(&tx, ShelleyProtocol); got < want {
+		t.Errorf("got fee %v want atleast %v", got, want)
+	}
+
+	decoded, err := DecodeTransaction(tx.CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := decoded.CborHex(), tx.CborHex(); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if err := decoded.VerifySignatures(); err != nil {
+		t.Error(err)
+	}
+
+	otherKey := crypto.NewExtendedSigningKey([]byte("other"), "foo")
+	if err := NewTxBuilder(ShelleyProtocol).AddByronInput(otherKey.ExtendedVerificationKey(), utxo); err == nil {
+		t.Errorf("expected key mismatch error")
+	}
+}
+
+func TestAddress_NetworkByron(t *testing.T) {
+	if got, want := Address("Ae2tdPwUPEZFRbyhz3cpfC2CumGzNkFBN2L42rcUc2yjQpEkxDbkPodpMAi").Network(), Mainnet; got != want {
+		t.Errorf("got network %v want %v", got, want)
+	}
+
+	// The testnet protocol magic is cbor wrapped in the attributes
+	magic, err := cbor.Marshal(uint32(testnetMagic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	attributes, err := cbor.Marshal(map[uint64][]byte{byronProtocolMagicAttribute: magic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := crypto.NewExtendedSigningKey([]byte("byron"), "foo")
+	addr := newByronAddressWithAttributes(t, key.ExtendedVerificationKey(), attributes)
+	if got, want := addr.Network(), Testnet; got != want {
+		t.Errorf("got network %v want %v", got, want)
+	}
+}
@@ -2,7 +2,9 @@ package cardano
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -14,10 +16,66 @@ const (
 	testnetMagic = 1097911063
 )
 
-type cardanoNode interface {
-	QueryUtxos(Address) ([]Utxo, error)
-	QueryTip() (NodeTip, error)
-	SubmitTx(Transaction) error
+// Node is a cardano node backend, like the cardano-cli, Ogmios or Blockfrost,
+// querying the utxos to spend and submitting the transactions.
+type Node interface {
+	// UTxOs returns the unspent outputs of the address.
+	UTxOs(Address) ([]Utxo, error)
+	// Tip returns the slot of the node tip.
+	Tip() (slot uint64, err error)
+	// SubmitTx submits the transaction and returns its id.
+	SubmitTx(*Transaction) (TransactionID, error)
+}
+
+// TipContexter is implemented by the nodes whose tip query stops when the
+// context is done, like the cardano-cli, Ogmios and Blockfrost.
+type TipContexter interface {
+	TipContext(ctx context.Context) (slot uint64, err error)
+}
+
+// tipContext queries the node tip until the context is done. The query of a
+// node not implementing TipContexter can't be stopped, it keeps running in a
+// goroutine until the node Tip returns.
+func tipContext(ctx context.Context, node Node) (uint64, error) {
+	if node, ok := node.(TipContexter); ok {
+		return node.TipContext(ctx)
+	}
+	type result struct {
+		slot uint64
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		slot, err := node.Tip()
+		done <- result{slot, err}
+	}()
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case res := <-done:
+		return res.slot, res.err
+	}
+}
+
+// ErrNetworkMismatch is returned when submitting a transaction paying to an
+// address of another network.
+var ErrNetworkMismatch = errors.New("network mismatch")
+
+// networkGuard refuses to submit transactions with outputs to other networks.
+type networkGuard struct {
+	Node
+	network Network
+}
+
+func (guard *networkGuard) SubmitTx(tx *Transaction) (TransactionID, error) {
+	if err := tx.CheckNetwork(guard.network); err != nil {
+		return "", err
+	}
+	return guard.Node.SubmitTx(tx)
+}
+
+func (guard *networkGuard) TipContext(ctx context.Context) (uint64, error) {
+	return tipContext(ctx, guard.Node)
 }
 
 type Utxo struct {
@@ -25,6 +83,7 @@ type Utxo struct {
 	TxId    TransactionID
 	Amount  uint64
 	Index   uint64
+	Assets  MultiAsset // native tokens, nil for lovelace only utxos
 }
 
 type NodeTip struct {
@@ -56,7 +115,7 @@ func newCli() *cardanoCli {
 }
 
 //TODO: add ability to use mainnet and testnet
-func (cli *cardanoCli) QueryUtxos(address Address) ([]Utxo, error) {
+func (cli *cardanoCli) UTxOs(address Address) ([]Utxo, error) {
 	out, err := runCommand("cardano-cli", "query", "utxo", "--address", string(address), "--testnet-magic", "1097911063")
 	if err != nil {
 		return nil, err
@@ -100,7 +159,11 @@ func (cli *cardanoCli) QueryUtxos(address Address) ([]Utxo, error) {
 
 //TODO: add ability to use mainnet and testnet
 func (cli *cardanoCli) QueryTip() (NodeTip, error) {
-	out, err := runCommand("cardano-cli", "query", "tip", "--testnet-magic", "1097911063")
+	return cli.queryTip(context.Background())
+}
+
+func (cli *cardanoCli) queryTip(ctx context.Context) (NodeTip, error) {
+	out, err := runCommandContext(ctx, "cardano-cli", "query", "tip", "--testnet-magic", "1097911063")
 	if err != nil {
 		return NodeTip{}, err
 	}
@@ -118,23 +181,31 @@ func (cli *cardanoCli) QueryTip() (NodeTip, error) {
 	}, nil
 }
 
-//TODO: add ability to use mainnet and testnet
-func (cli *cardanoCli) SubmitTx(tx Transaction) error {
-	const txFileName = "txsigned.temp"
-	txPayload := cardanoCliTx{
-		Type:        "Tx MaryEra",
-		Description: "",
-		CborHex:     tx.CborHex(),
+func (cli *cardanoCli) Tip() (uint64, error) {
+	return cli.TipContext(context.Background())
+}
+
+// TipContext returns the slot of the node tip, the cardano-cli is killed when
+// the context is done.
+func (cli *cardanoCli) TipContext(ctx context.Context) (uint64, error) {
+	tip, err := cli.queryTip(ctx)
+	if err != nil {
+		return 0, err
 	}
+	return tip.Slot, nil
+}
 
-	txPayloadJson, err := json.Marshal(txPayload)
+//TODO: add ability to use mainnet and testnet
+func (cli *cardanoCli) SubmitTx(tx *Transaction) (TransactionID, error) {
+	const txFileName = "txsigned.temp"
+	txPayloadJson, err := tx.TextEnvelope()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	err = ioutil.WriteFile(txFileName, txPayloadJson, 777)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	out, err := runCommand("cardano-cli", "transaction", "submit", "--tx-file", txFileName, "--testnet-magic", "1097911063")
@@ -142,12 +213,16 @@ func (cli *cardanoCli) SubmitTx(tx Transaction) error {
 
 	err = os.Remove(txFileName)
 
-	return err
+	return tx.ID(), err
 }
 
 func runCommand(cmd string, arg ...string) (*bytes.Buffer, error) {
+	return runCommandContext(context.Background(), cmd, arg...)
+}
+
+func runCommandContext(ctx context.Context, cmd string, arg ...string) (*bytes.Buffer, error) {
 	out := &bytes.Buffer{}
-	command := exec.Command(cmd, arg...)
+	command := exec.CommandContext(ctx, cmd, arg...)
 	command.Stdout = out
 	command.Stderr = os.Stderr
 
@@ -0,0 +1,108 @@
+// Package cardanotest provides deterministic fixtures to test code using
+// cardano transactions.
+package cardanotest
+
+import (
+	"github.com/tclairet/cardano-go"
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+// InputTxId is the id of the transaction spent by the fixture.
+const InputTxId cardano.TransactionID = "6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"
+
+// PayerKey returns the key signing the fixture's input.
+func PayerKey() crypto.ExtendedSigningKey {
+	return crypto.NewExtendedSigningKey([]byte("cardanotest payer"), "")
+}
+
+// ReceiverKey returns the key of the fixture's receiver address.
+func ReceiverKey() crypto.ExtendedSigningKey {
+	return crypto.NewExtendedSigningKey([]byte("cardanotest receiver"), "")
+}
+
+type config struct {
+	network cardano.Network
+	key     crypto.ExtendedSigningKey
+	input   uint64
+	amount  uint64
+	ttl     uint64
+}
+
+type TestOpt interface {
+	apply(*config)
+}
+
+type optionFunc func(*config)
+
+func (f optionFunc) apply(cfg *config) {
+	f(cfg)
+}
+
+// WithNetwork sets the network of the addresses, defaults to Testnet.
+func WithNetwork(network cardano.Network) TestOpt {
+	return optionFunc(func(cfg *config) {
+		cfg.network = network
+	})
+}
+
+// WithSigningKey sets the key owning and signing the input, defaults to PayerKey.
+func WithSigningKey(key crypto.ExtendedSigningKey) TestOpt {
+	return optionFunc(func(cfg *config) {
+		cfg.key = key
+	})
+}
+
+// WithInputAmount sets the amount of the spent utxo, defaults to 10 ADA.
+func WithInputAmount(amount uint64) TestOpt {
+	return optionFunc(func(cfg *config) {
+		cfg.input = amount
+	})
+}
+
+// WithAmount sets the amount sent to the receiver, defaults to 2 ADA.
+func WithAmount(amount uint64) TestOpt {
+	return optionFunc(func(cfg *config) {
+		cfg.amount = amount
+	})
+}
+
+// WithTTL sets the TTL, defaults to 1000.
+func WithTTL(ttl uint64) TestOpt {
+	return optionFunc(func(cfg *config) {
+		cfg.ttl = ttl
+	})
+}
+
+// TestTransaction returns a signed transaction spending a single utxo of
+// InputTxId to the receiver, with the change sent back to the payer. The same
+// options always produce the same transaction.
+//
+// It panics if the options don't allow building the transaction.
+func TestTransaction(opts ...TestOpt) *cardano.Transaction {
+	cfg := config{
+		network: cardano.Testnet,
+		key:     PayerKey(),
+		input:   10000000,
+		amount:  2000000,
+		ttl:     1000,
+	}
+	for _, opt := range opts {
+		opt.apply(&cfg)
+	}
+
+	payer := cardano.NewEnterpriseAddress(cfg.network, cardano.NewKeyCredential(cfg.key.ExtendedVerificationKey()))
+	receiverKey := ReceiverKey()
+	receiver := cardano.NewEnterpriseAddress(cfg.network, cardano.NewKeyCredential(receiverKey.ExtendedVerificationKey()))
+
+	builder := cardano.NewTxBuilder(cardano.ShelleyProtocol)
+	builder.AddInput(cfg.key.ExtendedVerificationKey(), InputTxId, 0, cfg.input)
+	builder.AddOutput(receiver, cfg.amount)
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(cfg.ttl)
+	builder.Sign(cfg.key)
+	tx, err := builder.Build()
+	if err != nil {
+		panic(err)
+	}
+	return &tx
+}
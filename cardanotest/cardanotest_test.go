@@ -0,0 +1,31 @@
+package cardanotest
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTestTransaction(t *testing.T) {
+	tx := TestTransaction()
+	if got := TestTransaction(); !bytes.Equal(got.Bytes(), tx.Bytes()) {
+		t.Errorf("got %x want %x", got.Bytes(), tx.Bytes())
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		t.Errorf("VerifySignatures() error = %v", err)
+	}
+	if got, want := len(tx.Body.Outputs), 2; got != want {
+		t.Errorf("got %v outputs want %v", got, want)
+	}
+
+	tx = TestTransaction(WithAmount(3000000), WithTTL(500))
+	if got, want := tx.Body.Ttl, uint64(500); got != want {
+		t.Errorf("got ttl %v want %v", got, want)
+	}
+	var found bool
+	for _, output := range tx.Body.Outputs {
+		found = found || output.Amount == 3000000
+	}
+	if !found {
+		t.Errorf("missing receiver output of 3000000")
+	}
+}
@@ -0,0 +1,641 @@
+package cardano
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// CertificateType is the tag of a certificate.
+type CertificateType uint64
+
+const (
+	StakeRegistrationType   CertificateType = 0
+	StakeDeregistrationType CertificateType = 1
+	StakeDelegationType     CertificateType = 2
+	PoolRegistrationType    CertificateType = 3
+
+	GenesisKeyDelegationType     CertificateType = 5
+	MoveInstantaneousRewardsType CertificateType = 6
+)
+
+// StakeRegistration registers a stake credential, taking the key deposit.
+type StakeRegistration struct {
+	StakeCredential Credential
+}
+
+// StakeDeregistration deregisters a stake credential, refunding the key
+// deposit.
+type StakeDeregistration struct {
+	StakeCredential Credential
+}
+
+// StakeDelegation delegates a registered stake credential to a pool.
+type StakeDelegation struct {
+	StakeCredential Credential
+	PoolKeyHash     []byte // 28 bytes
+}
+
+// UnitInterval is a rational number between 0 and 1, e.g. a pool margin.
+type UnitInterval struct {
+	Numerator   uint64
+	Denominator uint64
+}
+
+// MarshalCBOR implements cbor.Marshaler.
+func (ui UnitInterval) MarshalCBOR() ([]byte, error) {
+	if ui.Denominator == 0 || ui.Numerator > ui.Denominator {
+		return nil, fmt.Errorf("invalid unit interval %v/%v", ui.Numerator, ui.Denominator)
+	}
+	return cbor.Marshal(cbor.Tag{Number: 30, Content: []uint64{ui.Numerator, ui.Denominator}})
+}
+
+// UnmarshalCBOR implements cbor.Unmarshaler.
+func (ui *UnitInterval) UnmarshalCBOR(data []byte) error {
+	tag := cbor.RawTag{}
+	if err := cbor.Unmarshal(data, &tag); err != nil {
+		return err
+	}
+	if tag.Number != 30 {
+		return fmt.Errorf("invalid unit interval tag %v", tag.Number)
+	}
+	fraction := []uint64{}
+	if err := cbor.Unmarshal(tag.Content, &fraction); err != nil {
+		return err
+	}
+	if len(fraction) != 2 || fraction[1] == 0 || fraction[0] > fraction[1] {
+		return fmt.Errorf("invalid unit interval %v", fraction)
+	}
+	*ui = UnitInterval{Numerator: fraction[0], Denominator: fraction[1]}
+	return nil
+}
+
+// RelayType is the kind of a pool relay.
+type RelayType uint64
+
+const (
+	SingleHostAddr RelayType = 0
+	SingleHostName RelayType = 1
+	MultiHostName  RelayType = 2
+)
+
+// Relay is a pool relay, reachable at its IP addresses and port, at its DNS
+// A/AAAA record name and port, or at its DNS SRV record name.
+type Relay struct {
+	Type    RelayType
+	Port    *uint16 // SingleHostAddr and SingleHostName, optional
+	IPv4    []byte  // SingleHostAddr, optional 4 bytes
+	IPv6    []byte  // SingleHostAddr, optional 16 bytes
+	DNSName string  // SingleHostName and MultiHostName, up to 64 bytes
+}
+
+// MarshalCBOR implements cbor.Marshaler.
+func (relay Relay) MarshalCBOR() ([]byte, error) {
+	var port interface{}
+	if relay.Port != nil {
+		port = *relay.Port
+	}
+	switch relay.Type {
+	case SingleHostAddr:
+		if relay.IPv4 != nil && len(relay.IPv4) != 4 {
+			return nil, fmt.Errorf("invalid relay ipv4 length %v", len(relay.IPv4))
+		}
+		if relay.IPv6 != nil && len(relay.IPv6) != 16 {
+			return nil, fmt.Errorf("invalid relay ipv6 length %v", len(relay.IPv6))
+		}
+		return cbor.Marshal([]interface{}{relay.Type, port, nullableBytes(relay.IPv4), nullableBytes(relay.IPv6)})
+	case SingleHostName, MultiHostName:
+		if len(relay.DNSName) > 64 {
+			return nil, fmt.Errorf("invalid relay dns name length %v", len(relay.DNSName))
+		}
+		if relay.Type == MultiHostName {
+			return cbor.Marshal([]interface{}{relay.Type, relay.DNSName})
+		}
+		return cbor.Marshal([]interface{}{relay.Type, port, relay.DNSName})
+	}
+	return nil, fmt.Errorf("unknown relay type %v", uint64(relay.Type))
+}
+
+// UnmarshalCBOR implements cbor.Unmarshaler.
+func (relay *Relay) UnmarshalCBOR(data []byte) error {
+	fields := []cbor.RawMessage{}
+	if err := cbor.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("empty relay")
+	}
+	decoded := Relay{}
+	if err := cbor.Unmarshal(fields[0], &decoded.Type); err != nil {
+		return err
+	}
+
+	var want int
+	var err error
+	switch decoded.Type {
+	case SingleHostAddr:
+		want = 4
+		if len(fields) == want {
+			err = unmarshalFields(fields[1:], &decoded.Port, &decoded.IPv4, &decoded.IPv6)
+		}
+	case SingleHostName:
+		want = 3
+		if len(fields) == want {
+			err = unmarshalFields(fields[1:], &decoded.Port, &decoded.DNSName)
+		}
+	case MultiHostName:
+		want = 2
+		if len(fields) == want {
+			err = unmarshalFields(fields[1:], &decoded.DNSName)
+		}
+	default:
+		return fmt.Errorf("unknown relay type %v", uint64(decoded.Type))
+	}
+	if err != nil {
+		return err
+	}
+	if len(fields) != want {
+		return fmt.Errorf("invalid relay %v length %v", uint64(decoded.Type), len(fields))
+	}
+	if _, err := decoded.MarshalCBOR(); err != nil {
+		return err
+	}
+	*relay = decoded
+	return nil
+}
+
+// PoolMetadata is the location and the blake2b-256 hash of the pool metadata
+// JSON.
+type PoolMetadata struct {
+	_    struct{} `cbor:",toarray"`
+	URL  string   // up to 64 bytes
+	Hash []byte   // 32 bytes
+}
+
+// PoolRegistration registers or updates a stake pool. The ledger takes the
+// pool deposit on registration only, a certificate of an already registered
+// pool updating its parameters must be built with UpdatePool so the deposit
+// isn't counted.
+type PoolRegistration struct {
+	Operator      []byte // pool key hash, 28 bytes
+	VRFKeyHash    []byte // 32 bytes
+	Pledge        uint64
+	Cost          uint64
+	Margin        UnitInterval
+	RewardAccount Address  // reward address
+	Owners        [][]byte // stake key hashes, 28 bytes
+	Relays        []Relay
+	Metadata      *PoolMetadata // optional
+}
+
+// poolRegistration is the cbor representation of a PoolRegistration
+// certificate, the pool parameters follow the tag.
+type poolRegistration struct {
+	_             struct{} `cbor:",toarray"`
+	Type          CertificateType
+	Operator      []byte
+	VRFKeyHash    []byte
+	Pledge        uint64
+	Cost          uint64
+	Margin        UnitInterval
+	RewardAccount []byte
+	Owners        [][]byte
+	Relays        []Relay
+	Metadata      *PoolMetadata
+}
+
+// validate checks the length of the pool registration hashes.
+func (pool *PoolRegistration) validate() error {
+	if len(pool.Operator) != 28 {
+		return fmt.Errorf("invalid pool operator length %v", len(pool.Operator))
+	}
+	if len(pool.VRFKeyHash) != 32 {
+		return fmt.Errorf("invalid pool vrf key hash length %v", len(pool.VRFKeyHash))
+	}
+	if _, err := rewardAccount(pool.RewardAccount); err != nil {
+		return fmt.Errorf("invalid pool reward account: %v", err)
+	}
+	for i, owner := range pool.Owners {
+		if len(owner) != 28 {
+			return fmt.Errorf("invalid pool owner %v length %v", i, len(owner))
+		}
+	}
+	if pool.Metadata != nil && (len(pool.Metadata.URL) > 64 || len(pool.Metadata.Hash) != 32) {
+		return fmt.Errorf("invalid pool metadata")
+	}
+	return nil
+}
+
+// signers returns the key hashes which must sign the registration, the
+// operator and the owners.
+func (pool *PoolRegistration) signers() [][]byte {
+	signers := [][]byte{pool.Operator}
+	for _, owner := range pool.Owners {
+		if !bytes.Equal(owner, pool.Operator) {
+			signers = append(signers, owner)
+		}
+	}
+	return signers
+}
+
+// GenesisKeyDelegation delegates a genesis key to a genesis delegate key and
+// its VRF key.
+type GenesisKeyDelegation struct {
+	GenesisHash         []byte // 28 bytes
+	GenesisDelegateHash []byte // 28 bytes
+	VRFKeyHash          []byte // 32 bytes
+}
+
+// genesisKeyDelegation is the cbor representation of a GenesisKeyDelegation
+// certificate.
+type genesisKeyDelegation struct {
+	_                   struct{} `cbor:",toarray"`
+	Type                CertificateType
+	GenesisHash         []byte
+	GenesisDelegateHash []byte
+	VRFKeyHash          []byte
+}
+
+// validate checks the length of the genesis key delegation hashes.
+func (delegation *GenesisKeyDelegation) validate() error {
+	if len(delegation.GenesisHash) != 28 {
+		return fmt.Errorf("invalid genesis hash length %v", len(delegation.GenesisHash))
+	}
+	if len(delegation.GenesisDelegateHash) != 28 {
+		return fmt.Errorf("invalid genesis delegate hash length %v", len(delegation.GenesisDelegateHash))
+	}
+	if len(delegation.VRFKeyHash) != 32 {
+		return fmt.Errorf("invalid vrf key hash length %v", len(delegation.VRFKeyHash))
+	}
+	return nil
+}
+
+// MIRPot is the pot the instantaneous rewards are taken from.
+type MIRPot uint64
+
+const (
+	ReservesPot MIRPot = 0
+	TreasuryPot MIRPot = 1
+)
+
+// MIRReward is the reward of a stake credential, negative amounts take back
+// the rewards moved earlier in the epoch.
+type MIRReward struct {
+	StakeCredential Credential
+	Amount          int64
+}
+
+// MoveInstantaneousRewards moves lovelace from a pot either to the reward
+// accounts of Rewards or, when Rewards is nil, Transfer lovelace to the other
+// pot.
+type MoveInstantaneousRewards struct {
+	Pot      MIRPot
+	Rewards  []MIRReward
+	Transfer uint64
+}
+
+// MarshalCBOR implements cbor.Marshaler. The rewards are a map keyed by the
+// credentials, with signed amounts, sorted canonically.
+func (mir MoveInstantaneousRewards) MarshalCBOR() ([]byte, error) {
+	if mir.Pot != ReservesPot && mir.Pot != TreasuryPot {
+		return nil, fmt.Errorf("invalid mir pot %v", uint64(mir.Pot))
+	}
+	out := cborHead(cborMajorArray, 2)
+	out = append(out, cborHead(cborMajorUint, uint64(mir.Pot))...)
+	if mir.Rewards == nil {
+		return append(out, cborHead(cborMajorUint, mir.Transfer)...), nil
+	}
+
+	keys := make([][]byte, 0, len(mir.Rewards))
+	amounts := map[string]int64{}
+	for _, reward := range mir.Rewards {
+		if len(reward.StakeCredential.Hash) != 28 {
+			return nil, fmt.Errorf("invalid stake credential hash length %v", len(reward.StakeCredential.Hash))
+		}
+		key, err := cbor.Marshal(reward.StakeCredential)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := amounts[string(key)]; ok {
+			return nil, fmt.Errorf("duplicated mir stake credential %x", reward.StakeCredential.Hash)
+		}
+		keys = append(keys, key)
+		amounts[string(key)] = reward.Amount
+	}
+	sortCanonical(keys)
+	out = append(out, cborHead(cborMajorMap, uint64(len(keys)))...)
+	for _, key := range keys {
+		amount, err := cbor.Marshal(amounts[string(key)])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, key...)
+		out = append(out, amount...)
+	}
+	return out, nil
+}
+
+// UnmarshalCBOR implements cbor.Unmarshaler.
+func (mir *MoveInstantaneousRewards) UnmarshalCBOR(data []byte) error {
+	fields := []cbor.RawMessage{}
+	if err := cbor.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if len(fields) != 2 {
+		return fmt.Errorf("invalid mir length %v", len(fields))
+	}
+	decoded := MoveInstantaneousRewards{}
+	if err := cbor.Unmarshal(fields[0], &decoded.Pot); err != nil {
+		return err
+	}
+	if decoded.Pot != ReservesPot && decoded.Pot != TreasuryPot {
+		return fmt.Errorf("invalid mir pot %v", uint64(decoded.Pot))
+	}
+	if len(fields[1]) > 0 && fields[1][0]>>5 == cborMajorUint {
+		if err := cbor.Unmarshal(fields[1], &decoded.Transfer); err != nil {
+			return err
+		}
+		*mir = decoded
+		return nil
+	}
+
+	decoded.Rewards = []MIRReward{}
+	_, err := readCborMap(fields[1], func(data []byte) (int, error) {
+		decoder := cbor.NewDecoder(bytes.NewReader(data))
+		reward := MIRReward{}
+		if err := decoder.Decode(&reward.StakeCredential); err != nil {
+			return 0, err
+		}
+		if len(reward.StakeCredential.Hash) != 28 {
+			return 0, fmt.Errorf("invalid stake credential hash length %v", len(reward.StakeCredential.Hash))
+		}
+		if err := decoder.Decode(&reward.Amount); err != nil {
+			return 0, err
+		}
+		decoded.Rewards = append(decoded.Rewards, reward)
+		return decoder.NumBytesRead(), nil
+	})
+	if err != nil {
+		return err
+	}
+	*mir = decoded
+	return nil
+}
+
+// Certificate is one of the certificates of a transaction body, encoded as a
+// tagged array. Other certificates of a decoded body are kept as is.
+type Certificate struct {
+	StakeRegistration        *StakeRegistration
+	StakeDeregistration      *StakeDeregistration
+	StakeDelegation          *StakeDelegation
+	PoolRegistration         *PoolRegistration
+	GenesisKeyDelegation     *GenesisKeyDelegation
+	MoveInstantaneousRewards *MoveInstantaneousRewards
+
+	raw cbor.RawMessage
+
+	// poolUpdate marks the PoolRegistration of a registered pool, taking no
+	// deposit.
+	poolUpdate bool
+}
+
+// RegisterStake returns the registration certificate of the credential.
+func RegisterStake(credential Credential) Certificate {
+	return Certificate{StakeRegistration: &StakeRegistration{StakeCredential: credential}}
+}
+
+// DeregisterStake returns the deregistration certificate of the credential.
+func DeregisterStake(credential Credential) Certificate {
+	return Certificate{StakeDeregistration: &StakeDeregistration{StakeCredential: credential}}
+}
+
+// RegisterPool returns the registration certificate of a new pool, the pool
+// deposit is taken.
+func RegisterPool(pool PoolRegistration) Certificate {
+	return Certificate{PoolRegistration: &pool}
+}
+
+// UpdatePool returns the registration certificate updating the parameters of
+// an already registered pool, no deposit is taken.
+func UpdatePool(pool PoolRegistration) Certificate {
+	return Certificate{PoolRegistration: &pool, poolUpdate: true}
+}
+
+// DelegateStake returns the certificate delegating the credential to the pool.
+func DelegateStake(credential Credential, poolKeyHash []byte) Certificate {
+	return Certificate{StakeDelegation: &StakeDelegation{StakeCredential: credential, PoolKeyHash: poolKeyHash}}
+}
+
+// credential returns the stake credential of the certificate, or nil for
+// certificates which aren't modelled.
+func (cert Certificate) credential() *Credential {
+	switch {
+	case cert.StakeRegistration != nil:
+		return &cert.StakeRegistration.StakeCredential
+	case cert.StakeDeregistration != nil:
+		return &cert.StakeDeregistration.StakeCredential
+	case cert.StakeDelegation != nil:
+		return &cert.StakeDelegation.StakeCredential
+	}
+	return nil
+}
+
+// MarshalCBOR implements cbor.Marshaler.
+func (cert Certificate) MarshalCBOR() ([]byte, error) {
+	if credential := cert.credential(); credential != nil && len(credential.Hash) != 28 {
+		return nil, fmt.Errorf("invalid stake credential hash length %v", len(credential.Hash))
+	}
+	switch {
+	case cert.StakeRegistration != nil:
+		return cbor.Marshal([]interface{}{StakeRegistrationType, cert.StakeRegistration.StakeCredential})
+	case cert.StakeDeregistration != nil:
+		return cbor.Marshal([]interface{}{StakeDeregistrationType, cert.StakeDeregistration.StakeCredential})
+	case cert.StakeDelegation != nil:
+		if len(cert.StakeDelegation.PoolKeyHash) != 28 {
+			return nil, fmt.Errorf("invalid pool key hash length %v", len(cert.StakeDelegation.PoolKeyHash))
+		}
+		return cbor.Marshal([]interface{}{StakeDelegationType, cert.StakeDelegation.StakeCredential, cert.StakeDelegation.PoolKeyHash})
+	case cert.PoolRegistration != nil:
+		pool := cert.PoolRegistration
+		if err := pool.validate(); err != nil {
+			return nil, err
+		}
+		owners := pool.Owners
+		if owners == nil {
+			owners = [][]byte{}
+		}
+		relays := pool.Relays
+		if relays == nil {
+			relays = []Relay{}
+		}
+		account, err := rewardAccount(pool.RewardAccount)
+		if err != nil {
+			return nil, err
+		}
+		return cbor.Marshal(poolRegistration{
+			Type:          PoolRegistrationType,
+			Operator:      pool.Operator,
+			VRFKeyHash:    pool.VRFKeyHash,
+			Pledge:        pool.Pledge,
+			Cost:          pool.Cost,
+			Margin:        pool.Margin,
+			RewardAccount: account,
+			Owners:        owners,
+			Relays:        relays,
+			Metadata:      pool.Metadata,
+		})
+	case cert.GenesisKeyDelegation != nil:
+		delegation := cert.GenesisKeyDelegation
+		if err := delegation.validate(); err != nil {
+			return nil, err
+		}
+		return cbor.Marshal(genesisKeyDelegation{
+			Type:                GenesisKeyDelegationType,
+			GenesisHash:         delegation.GenesisHash,
+			GenesisDelegateHash: delegation.GenesisDelegateHash,
+			VRFKeyHash:          delegation.VRFKeyHash,
+		})
+	case cert.MoveInstantaneousRewards != nil:
+		return cbor.Marshal([]interface{}{MoveInstantaneousRewardsType, cert.MoveInstantaneousRewards})
+	case cert.raw != nil:
+		return cert.raw, nil
+	}
+	return nil, fmt.Errorf("empty certificate")
+}
+
+// UnmarshalCBOR implements cbor.Unmarshaler.
+func (cert *Certificate) UnmarshalCBOR(data []byte) error {
+	fields := []cbor.RawMessage{}
+	if err := cbor.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("empty certificate")
+	}
+	var certType CertificateType
+	if err := cbor.Unmarshal(fields[0], &certType); err != nil {
+		return err
+	}
+
+	want := 2
+	decoded := Certificate{}
+	credential := Credential{}
+	switch certType {
+	case StakeRegistrationType:
+		decoded.StakeRegistration = &StakeRegistration{}
+	case StakeDeregistrationType:
+		decoded.StakeDeregistration = &StakeDeregistration{}
+	case StakeDelegationType:
+		want = 3
+		decoded.StakeDelegation = &StakeDelegation{}
+	case PoolRegistrationType:
+		pool := poolRegistration{}
+		if err := cbor.Unmarshal(data, &pool); err != nil {
+			return err
+		}
+		rewardAddress, err := rewardAddressFromAccount(pool.RewardAccount)
+		if err != nil {
+			return err
+		}
+		decoded.PoolRegistration = &PoolRegistration{
+			Operator:      pool.Operator,
+			VRFKeyHash:    pool.VRFKeyHash,
+			Pledge:        pool.Pledge,
+			Cost:          pool.Cost,
+			Margin:        pool.Margin,
+			RewardAccount: rewardAddress,
+			Owners:        pool.Owners,
+			Relays:        pool.Relays,
+			Metadata:      pool.Metadata,
+		}
+		if err := decoded.PoolRegistration.validate(); err != nil {
+			return err
+		}
+		*cert = decoded
+		return nil
+	case GenesisKeyDelegationType:
+		delegation := genesisKeyDelegation{}
+		if err := cbor.Unmarshal(data, &delegation); err != nil {
+			return err
+		}
+		decoded.GenesisKeyDelegation = &GenesisKeyDelegation{
+			GenesisHash:         delegation.GenesisHash,
+			GenesisDelegateHash: delegation.GenesisDelegateHash,
+			VRFKeyHash:          delegation.VRFKeyHash,
+		}
+		if err := decoded.GenesisKeyDelegation.validate(); err != nil {
+			return err
+		}
+		*cert = decoded
+		return nil
+	case MoveInstantaneousRewardsType:
+		if len(fields) != 2 {
+			return fmt.Errorf("invalid certificate %v length %v", certType, len(fields))
+		}
+		decoded.MoveInstantaneousRewards = &MoveInstantaneousRewards{}
+		if err := cbor.Unmarshal(fields[1], decoded.MoveInstantaneousRewards); err != nil {
+			return err
+		}
+		*cert = decoded
+		return nil
+	default:
+		*cert = Certificate{raw: append(cbor.RawMessage(nil), data...)}
+		return nil
+	}
+	if len(fields) != want {
+		return fmt.Errorf("invalid certificate %v length %v", certType, len(fields))
+	}
+	if err := cbor.Unmarshal(fields[1], &credential); err != nil {
+		return err
+	}
+	if len(credential.Hash) != 28 {
+		return fmt.Errorf("invalid stake credential hash length %v", len(credential.Hash))
+	}
+	*decoded.credential() = credential
+	if decoded.StakeDelegation != nil {
+		if err := cbor.Unmarshal(fields[2], &decoded.StakeDelegation.PoolKeyHash); err != nil {
+			return err
+		}
+		if len(decoded.StakeDelegation.PoolKeyHash) != 28 {
+			return fmt.Errorf("invalid pool key hash length %v", len(decoded.StakeDelegation.PoolKeyHash))
+		}
+	}
+	*cert = decoded
+	return nil
+}
+
+// deposits returns the key and pool deposits taken by the registrations and
+// the key deposits refunded by the deregistrations of the body. Pool updates
+// take no deposit.
+func (body *TransactionBody) deposits(protocol ProtocolParams) (deposit, refund uint64) {
+	for _, cert := range body.Certificates {
+		switch {
+		case cert.StakeRegistration != nil:
+			deposit += protocol.KeyDeposit
+		case cert.StakeDeregistration != nil:
+			refund += protocol.KeyDeposit
+		case cert.PoolRegistration != nil && !cert.poolUpdate:
+			deposit += protocol.PoolDeposit
+		}
+	}
+	return deposit, refund
+}
+
+// nullableBytes returns nil for a nil slice so it's encoded as null.
+func nullableBytes(b []byte) interface{} {
+	if b == nil {
+		return nil
+	}
+	return b
+}
+
+// unmarshalFields decodes each field into the value at the same index.
+func unmarshalFields(fields []cbor.RawMessage, values ...interface{}) error {
+	for i, value := range values {
+		if err := cbor.Unmarshal(fields[i], value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
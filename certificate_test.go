@@ -0,0 +1,357 @@
+package cardano
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+func TestCertificate_CBOR(t *testing.T) {
+	keyCredential, err := NewCredential(KeyCredential, bytes.Repeat([]byte{0x01}, 28))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scriptCredential, err := NewCredential(ScriptCredential, bytes.Repeat([]byte{0x02}, 28))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := bytes.Repeat([]byte{0x03}, 28)
+
+	testcases := []struct {
+		name    string
+		cert    Certificate
+		cborHex string
+	}{
+		{
+			name:    "registration",
+			cert:    RegisterStake(keyCredential),
+			cborHex: "82008200581c" + strings.Repeat("01", 28),
+		},
+		{
+			name:    "deregistration",
+			cert:    DeregisterStake(scriptCredential),
+			cborHex: "82018201581c" + strings.Repeat("02", 28),
+		},
+		{
+			name:    "delegation",
+			cert:    DelegateStake(keyCredential, pool),
+			cborHex: "83028200581c" + strings.Repeat("01", 28) + "581c" + strings.Repeat("03", 28),
+		},
+		{
+			name: "genesis key delegation",
+			cert: Certificate{GenesisKeyDelegation: &GenesisKeyDelegation{
+				GenesisHash:         bytes.Repeat([]byte{0x01}, 28),
+				GenesisDelegateHash: bytes.Repeat([]byte{0x02}, 28),
+				VRFKeyHash:          bytes.Repeat([]byte{0x04}, 32),
+			}},
+			cborHex: "8405581c" + strings.Repeat("01", 28) + "581c" + strings.Repeat("02", 28) + "5820" + strings.Repeat("04", 32),
+		},
+		{
+			name: "mir rewards",
+			cert: Certificate{MoveInstantaneousRewards: &MoveInstantaneousRewards{
+				Pot: TreasuryPot,
+				Rewards: []MIRReward{
+					{StakeCredential: keyCredential, Amount: -5},
+					{StakeCredential: scriptCredential, Amount: 1000000},
+				},
+			}},
+			cborHex: "82068201a28200581c" + strings.Repeat("01", 28) + "248201581c" + strings.Repeat("02", 28) + "1a000f4240",
+		},
+		{
+			name:    "mir transfer",
+			cert:    Certificate{MoveInstantaneousRewards: &MoveInstantaneousRewards{Pot: ReservesPot, Transfer: 1000000}},
+			cborHex: "820682001a000f4240",
+		},
+		{
+			name:    "pool retirement",
+			cert:    Certificate{raw: cbor.RawMessage{0x83, 0x04, 0x41, 0x00, 0x01}},
+			cborHex: "8304410001",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			encoded, err := cbor.Marshal(tc.cert)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := hex.EncodeToString(encoded), tc.cborHex; got != want {
+				t.Errorf("got %v want %v", got, want)
+			}
+
+			decoded := Certificate{}
+			if err := cbor.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded, tc.cert) {
+				t.Errorf("got certificate %+v want %+v", decoded, tc.cert)
+			}
+		})
+	}
+
+	// The rewards are sorted by credential
+	reversed := Certificate{MoveInstantaneousRewards: &MoveInstantaneousRewards{
+		Pot: TreasuryPot,
+		Rewards: []MIRReward{
+			{StakeCredential: scriptCredential, Amount: 1000000},
+			{StakeCredential: keyCredential, Amount: -5},
+		},
+	}}
+	encoded, err := cbor.Marshal(reversed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := hex.EncodeToString(encoded), testcases[4].cborHex; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	reversed.MoveInstantaneousRewards.Rewards[0].StakeCredential = keyCredential
+	if _, err := cbor.Marshal(reversed); err == nil {
+		t.Error("expected a duplicated credential error")
+	}
+
+	if _, err := NewCredential(KeyCredential, []byte{0x01}); err == nil {
+		t.Error("expected an error for an invalid credential hash")
+	}
+}
+
+func TestTXBuilder_RegisterAndDelegate(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	stakeKey := crypto.NewExtendedSigningKey([]byte("stake"), "foo")
+	credential, err := NewCredential(KeyCredential, keyHash(stakeKey.ExtendedVerificationKey()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputAmount := uint64(10000000)
+	builder := NewTxBuilder(ShelleyProtocol)
+	builder.AddUtxo(Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  inputAmount,
+	})
+	builder.AddCertificate(RegisterStake(credential))
+	builder.AddCertificate(DelegateStake(credential, bytes.Repeat([]byte{0x03}, 28)))
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(100)
+	if err := builder.SignWith(resolver); err != nil {
+		t.Fatal(err)
+	}
+	builder.Sign(stakeKey)
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeTransaction(tx.CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(decoded.Body.Certificates), 2; got != want {
+		t.Fatalf("got %v certificates want %v", got, want)
+	}
+	if got, want := len(decoded.WitnessSet.VKeyWitnessSet), 2; got != want {
+		t.Errorf("got %v vkey witnesses want %v", got, want)
+	}
+	if got, want := decoded.Body.Outputs[0].Amount+decoded.Body.Fee, inputAmount-ShelleyProtocol.KeyDeposit; got != want {
+		t.Errorf("got change plus fee %v want %v", got, want)
+	}
+	if got, want := tx.Body.Fee, CalculateFee(&tx, ShelleyProtocol); got < want {
+		t.Errorf("got fee %v want atleast %v", got, want)
+	}
+
+	// Deregistering refunds the deposit
+	body := decoded.Body
+	body.Outputs = nil
+	body.Certificates = []Certificate{DeregisterStake(credential)}
+	if err := body.addFee(inputAmount, payer, nil, ShelleyProtocol, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := body.Outputs[0].Amount+body.Fee, inputAmount+ShelleyProtocol.KeyDeposit; got != want {
+		t.Errorf("got change plus fee %v want %v", got, want)
+	}
+}
+
+func TestTXBuilder_DeregisterStakeKeys(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	key = crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	inputAmount := uint64(10000000)
+	builder := NewTxBuilder(ShelleyProtocol)
+	builder.AddUtxo(Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  inputAmount,
+	})
+	builder.AddOutput(receiver, 2000000)
+	for _, seed := range []string{"stake 1", "stake 2", "stake 3"} {
+		stakeKey := crypto.NewExtendedSigningKey([]byte(seed), "foo")
+		builder.AddCertificate(DeregisterStake(NewKeyCredential(stakeKey.ExtendedVerificationKey())))
+		builder.Sign(stakeKey)
+	}
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(100)
+	if err := builder.SignWith(resolver); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	change, err := tx.ChangeUtxo(payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := change.Amount, inputAmount-2000000-tx.Body.Fee+3*ShelleyProtocol.KeyDeposit; got != want {
+		t.Errorf("got change %v want %v", got, want)
+	}
+	if got, want := len(tx.WitnessSet.VKeyWitnessSet), 4; got != want {
+		t.Errorf("got %v vkey witnesses want %v", got, want)
+	}
+	if got, want := tx.Body.Fee, CalculateFee(&tx, ShelleyProtocol); got < want {
+		t.Errorf("got fee %v want atleast %v", got, want)
+	}
+}
+
+func TestPoolRegistration_DecodeTransaction(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	coldKey := crypto.NewExtendedSigningKey([]byte("cold"), "foo")
+	ownerKey := crypto.NewExtendedSigningKey([]byte("owner"), "foo")
+	port := uint16(3001)
+
+	testcases := []struct {
+		name     string
+		metadata *PoolMetadata
+		relays   []Relay
+		update   bool
+	}{
+		{
+			name: "relays and metadata",
+			metadata: &PoolMetadata{
+				URL:  "https://example.com/pool.json",
+				Hash: bytes.Repeat([]byte{0x04}, 32),
+			},
+			relays: []Relay{
+				{Type: SingleHostAddr, Port: &port, IPv4: []byte{127, 0, 0, 1}},
+				{Type: SingleHostAddr, Port: &port, IPv6: append(bytes.Repeat([]byte{0x00}, 15), 0x01)},
+				{Type: SingleHostName, DNSName: "relay.example.com"},
+				{Type: MultiHostName, DNSName: "relays.example.com"},
+			},
+		},
+		{
+			name:   "no metadata",
+			relays: []Relay{{Type: SingleHostAddr, IPv6: bytes.Repeat([]byte{0xfe}, 16)}},
+		},
+		{
+			name:   "update",
+			relays: []Relay{{Type: SingleHostName, DNSName: "relay.example.com"}},
+			update: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			pool := &PoolRegistration{
+				Operator:      keyHash(coldKey.ExtendedVerificationKey()),
+				VRFKeyHash:    bytes.Repeat([]byte{0x05}, 32),
+				Pledge:        100000000,
+				Cost:          340000000,
+				Margin:        UnitInterval{Numerator: 1, Denominator: 100},
+				RewardAccount: NewRewardAddress(Testnet, NewKeyCredential(ownerKey.ExtendedVerificationKey())),
+				Owners:        [][]byte{keyHash(ownerKey.ExtendedVerificationKey())},
+				Relays:        tc.relays,
+				Metadata:      tc.metadata,
+			}
+
+			inputAmount := uint64(600000000)
+			builder := NewTxBuilder(ShelleyProtocol)
+			builder.AddUtxo(Utxo{
+				Address: payer,
+				TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+				Index:   0,
+				Amount:  inputAmount,
+			})
+			cert, deposit := RegisterPool(*pool), ShelleyProtocol.PoolDeposit
+			if tc.update {
+				cert, deposit = UpdatePool(*pool), 0
+			}
+			builder.AddCertificate(cert)
+			builder.SetChangeAddress(payer)
+			builder.SetTtl(100)
+			if err := builder.SignWith(resolver); err != nil {
+				t.Fatal(err)
+			}
+			builder.Sign(coldKey)
+			builder.Sign(ownerKey)
+			tx, err := builder.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decoded, err := DecodeTransaction(tx.CborHex())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := decoded.Body.Certificates[0].PoolRegistration; !reflect.DeepEqual(got, pool) {
+				t.Errorf("got pool %+v want %+v", got, pool)
+			}
+			if got, want := decoded.Body.Outputs[0].Amount+decoded.Body.Fee, inputAmount-deposit; got != want {
+				t.Errorf("got change plus fee %v want %v", got, want)
+			}
+			if got, want := tx.Body.Fee, CalculateFee(&tx, ShelleyProtocol); got < want {
+				t.Errorf("got fee %v want atleast %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMoveInstantaneousRewards_DecodeTransaction(t *testing.T) {
+	credential, err := NewCredential(KeyCredential, bytes.Repeat([]byte{0x01}, 28))
+	if err != nil {
+		t.Fatal(err)
+	}
+	certs := []Certificate{
+		{GenesisKeyDelegation: &GenesisKeyDelegation{
+			GenesisHash:         bytes.Repeat([]byte{0x01}, 28),
+			GenesisDelegateHash: bytes.Repeat([]byte{0x02}, 28),
+			VRFKeyHash:          bytes.Repeat([]byte{0x04}, 32),
+		}},
+		{MoveInstantaneousRewards: &MoveInstantaneousRewards{
+			Pot:     ReservesPot,
+			Rewards: []MIRReward{{StakeCredential: credential, Amount: -1000000}},
+		}},
+		{MoveInstantaneousRewards: &MoveInstantaneousRewards{Pot: TreasuryPot, Transfer: 5000000}},
+	}
+	tx := &Transaction{Body: TransactionBody{
+		Inputs:       []TransactionInput{{ID: make([]byte, 32), Index: 0}},
+		Outputs:      []TransactionOutput{{Address: make([]byte, 29), Amount: 1000000}},
+		Fee:          170000,
+		Ttl:          100,
+		Certificates: certs,
+	}}
+
+	decoded, err := DecodeTransaction(tx.CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Body.Certificates, certs) {
+		t.Errorf("got certificates %+v want %+v", decoded.Body.Certificates, certs)
+	}
+	if got, want := decoded.CborHex(), tx.CborHex(); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
@@ -10,8 +10,9 @@ import (
 // Client provides a clean interface for creating, saving and deleting Wallets.
 type Client struct {
 	db         DB
-	node       cardanoNode
+	node       Node
 	socketPath string
+	network    *Network
 }
 
 // NewClient builds a new Client using cardano-cli as the default connection
@@ -23,6 +24,9 @@ func NewClient(opts ...Options) *Client {
 	for _, opt := range opts {
 		opt.apply(client)
 	}
+	if client.network != nil {
+		client.node = &networkGuard{Node: client.node, network: *client.network}
+	}
 	if client.db == nil {
 		client.db = newBadgerDB()
 	}
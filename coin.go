@@ -0,0 +1,74 @@
+package cardano
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const lovelacePerADA = 1000000
+
+// FormatLovelace formats the amount of lovelace in ADA with thousands
+// separators and six decimals followed by the ADA symbol, e.g. "1,234.567890 ₳".
+func FormatLovelace(amount uint64) string {
+	return FormatLovelaceNoSymbol(amount) + " ₳"
+}
+
+// FormatLovelaceNoSymbol is like FormatLovelace without the ADA symbol,
+// e.g. "1,234.567890".
+func FormatLovelaceNoSymbol(amount uint64) string {
+	whole := strconv.FormatUint(amount/lovelacePerADA, 10)
+	decimals := strconv.FormatUint(amount%lovelacePerADA+lovelacePerADA, 10)[1:]
+
+	grouped := make([]byte, 0, len(whole)+len(whole)/3)
+	for i := 0; i < len(whole); i++ {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped = append(grouped, ',')
+		}
+		grouped = append(grouped, whole[i])
+	}
+	return string(grouped) + "." + decimals
+}
+
+// FormatADA formats the amount of lovelace in ADA with six decimals and no
+// separators, e.g. "1234.567890", which ParseADA parses back.
+func FormatADA(amount uint64) string {
+	return strconv.FormatUint(amount/lovelacePerADA, 10) + "." +
+		strconv.FormatUint(amount%lovelacePerADA+lovelacePerADA, 10)[1:]
+}
+
+// ParseADA parses an amount of ADA with atmost six decimals, e.g. "1.5", and
+// returns it in lovelace.
+func ParseADA(s string) (uint64, error) {
+	whole, decimals := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, decimals = s[:i], s[i+1:]
+	}
+	if whole == "" && decimals == "" || len(decimals) > 6 || !isDigits(whole) || !isDigits(decimals) {
+		return 0, fmt.Errorf("invalid ADA amount %q", s)
+	}
+
+	var ada, lovelace uint64
+	if whole != "" {
+		var err error
+		if ada, err = strconv.ParseUint(whole, 10, 64); err != nil || ada > maxUint64/lovelacePerADA {
+			return 0, fmt.Errorf("ADA amount %q overflows", s)
+		}
+	}
+	if decimals != "" {
+		lovelace, _ = strconv.ParseUint(decimals+strings.Repeat("0", 6-len(decimals)), 10, 64)
+	}
+	if ada*lovelacePerADA > maxUint64-lovelace {
+		return 0, fmt.Errorf("ADA amount %q overflows", s)
+	}
+	return ada*lovelacePerADA + lovelace, nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
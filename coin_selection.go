@@ -0,0 +1,176 @@
+package cardano
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// SelectionStrategy picks a set of utxos whose total amount covers the target.
+type SelectionStrategy interface {
+	Select(utxos []Utxo, target uint64) ([]Utxo, error)
+}
+
+// LargestFirst selects the biggest utxos first until the target is covered.
+type LargestFirst struct{}
+
+// Select implements SelectionStrategy.
+func (LargestFirst) Select(utxos []Utxo, target uint64) ([]Utxo, error) {
+	sorted := make([]Utxo, len(utxos))
+	copy(sorted, utxos)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Amount > sorted[j].Amount
+	})
+
+	var picked []Utxo
+	var pickedAmount uint64
+	for _, utxo := range sorted {
+		if pickedAmount >= target {
+			break
+		}
+		picked = append(picked, utxo)
+		pickedAmount += utxo.Amount
+	}
+	if pickedAmount < target {
+		return nil, fmt.Errorf("insufficient utxos, got %v want atleast %v", pickedAmount, target)
+	}
+	return picked, nil
+}
+
+// RandomImprove implements the random-improve algorithm described in CIP-2.
+//
+// Utxos are first picked at random until the target is covered, then the
+// selection is improved by adding random utxos as long as they move the
+// selected amount closer to twice the target without exceeding three times it.
+type RandomImprove struct {
+	rand *rand.Rand
+}
+
+// NewRandomImprove returns a RandomImprove strategy using the given source of
+// randomness. A nil source is seeded with the current time.
+func NewRandomImprove(source rand.Source) *RandomImprove {
+	if source == nil {
+		source = rand.NewSource(time.Now().UnixNano())
+	}
+	return &RandomImprove{rand: rand.New(source)}
+}
+
+// Select implements SelectionStrategy.
+func (ri *RandomImprove) Select(utxos []Utxo, target uint64) ([]Utxo, error) {
+	available := make([]Utxo, len(utxos))
+	copy(available, utxos)
+
+	// Phase 1: random selection
+	var picked []Utxo
+	var pickedAmount uint64
+	for pickedAmount < target {
+		if len(available) == 0 {
+			return nil, fmt.Errorf("insufficient utxos, got %v want atleast %v", pickedAmount, target)
+		}
+		var utxo Utxo
+		utxo, available = ri.pick(available)
+		picked = append(picked, utxo)
+		pickedAmount += utxo.Amount
+	}
+
+	// Phase 2: improvement
+	ideal, max := 2*target, 3*target
+	for len(available) > 0 {
+		var utxo Utxo
+		utxo, available = ri.pick(available)
+		newAmount := pickedAmount + utxo.Amount
+		if newAmount > max || distance(newAmount, ideal) >= distance(pickedAmount, ideal) {
+			break
+		}
+		picked = append(picked, utxo)
+		pickedAmount = newAmount
+	}
+
+	return picked, nil
+}
+
+func (ri *RandomImprove) pick(utxos []Utxo) (Utxo, []Utxo) {
+	if ri.rand == nil {
+		ri.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	i := ri.rand.Intn(len(utxos))
+	utxo := utxos[i]
+	utxos[i] = utxos[len(utxos)-1]
+	return utxo, utxos[:len(utxos)-1]
+}
+
+func distance(a, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// ErrInsufficientFunds is returned when the utxos don't cover the target and
+// the fee.
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// SelectUTXOs selects the largest utxos until they cover the target and the
+// min fee of a transaction spending them with a payment and a change output,
+// which grows with each input. The selection can then be added to a TXBuilder
+// with AddUtxo.
+func SelectUTXOs(available []Utxo, target uint64, protocol ProtocolParams) ([]Utxo, error) {
+	var fee uint64
+	for {
+		picked, err := LargestFirst{}.Select(available, target+fee)
+		if err != nil {
+			var availableAmount uint64
+			for _, utxo := range available {
+				availableAmount += utxo.Amount
+			}
+			return nil, fmt.Errorf("%w, got %v want atleast %v", ErrInsufficientFunds, availableAmount, target+fee)
+		}
+		var pickedAmount uint64
+		for _, utxo := range picked {
+			pickedAmount += utxo.Amount
+		}
+		// Each input raises the fee, select again until it's covered
+		fee = estimateFee(picked, pickedAmount, target, protocol)
+		if pickedAmount >= target+fee {
+			return picked, nil
+		}
+	}
+}
+
+// MinChange returns the change left by paying payment from the inputs after
+// the min fee of a transaction with a payment and a change output. It's not
+// feasible if the inputs don't cover the payment and the fee, or the change is
+// below the min utxo value. Base address sized outputs are assumed.
+func MinChange(inputs []Utxo, payment uint64, protocol ProtocolParams) (change uint64, feasible bool) {
+	var inputAmount uint64
+	for _, utxo := range inputs {
+		inputAmount += utxo.Amount
+	}
+	if inputAmount < payment {
+		return 0, false
+	}
+	fee := estimateFee(inputs, inputAmount, payment, protocol)
+	if inputAmount < payment+fee {
+		return 0, false
+	}
+	change = inputAmount - payment - fee
+	return change, change >= MinUTXO(TransactionOutput{Address: make([]byte, 57)}, protocol)
+}
+
+// estimateFee returns the min fee of a transaction spending the inputs with a
+// payment and a change output, inputAmount must cover the payment.
+func estimateFee(inputs []Utxo, inputAmount, payment uint64, protocol ProtocolParams) uint64 {
+	// The widest encoded ttl, so the fee doesn't depend on the current slot
+	body := TransactionBody{Ttl: maxUint64}
+	for _, utxo := range inputs {
+		body.Inputs = append(body.Inputs, TransactionInput{ID: utxo.TxId.Bytes(), Index: utxo.Index})
+	}
+	body.Outputs = []TransactionOutput{
+		{Address: make([]byte, 57), Amount: inputAmount - payment},
+		{Address: make([]byte, 57), Amount: payment},
+	}
+	body.Fee = 200000
+	return body.calculateMinFee(protocol)
+}
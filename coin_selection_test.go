@@ -0,0 +1,153 @@
+package cardano
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+func testUtxos(amounts ...uint64) []Utxo {
+	utxos := make([]Utxo, len(amounts))
+	for i, amount := range amounts {
+		utxos[i] = Utxo{
+			TxId:   TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+			Index:  uint64(i),
+			Amount: amount,
+		}
+	}
+	return utxos
+}
+
+func sumUtxos(utxos []Utxo) uint64 {
+	var total uint64
+	for _, utxo := range utxos {
+		total += utxo.Amount
+	}
+	return total
+}
+
+func TestLargestFirst(t *testing.T) {
+	utxos := testUtxos(1000000, 5000000, 2000000, 3000000)
+
+	got, err := LargestFirst{}.Select(utxos, 7000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Amount != 5000000 || got[1].Amount != 3000000 {
+		t.Errorf("got %v want [5000000 3000000]", got)
+	}
+
+	if _, err := (LargestFirst{}).Select(utxos, 12000000); err == nil {
+		t.Errorf("expected insufficient utxos error")
+	}
+}
+
+func TestSelectUTXOs(t *testing.T) {
+	utxos := testUtxos(1000000, 5000000, 2000000, 3000000)
+
+	// The two largest utxos cover the target but not the fee
+	got, err := SelectUTXOs(utxos, 8000000, ShelleyProtocol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0].Amount != 5000000 || got[1].Amount != 3000000 || got[2].Amount != 2000000 {
+		t.Errorf("got %v want [5000000 3000000 2000000]", got)
+	}
+	if _, feasible := MinChange(got, 8000000, ShelleyProtocol); !feasible {
+		t.Errorf("got a selection not covering the fee")
+	}
+
+	if _, err := SelectUTXOs(utxos, sumUtxos(utxos), ShelleyProtocol); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("got error %v want %v", err, ErrInsufficientFunds)
+	}
+}
+
+func TestRandomImprove(t *testing.T) {
+	utxos := testUtxos(1000000, 1000000, 1000000, 1000000, 1000000, 1000000, 1000000, 1000000, 1000000, 1000000)
+	target := uint64(2500000)
+
+	got, err := NewRandomImprove(rand.NewSource(42)).Select(utxos, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := sumUtxos(got)
+	if total < target {
+		t.Errorf("got %v want atleast %v", total, target)
+	}
+	if total > 3*target {
+		t.Errorf("got %v want atmost %v", total, 3*target)
+	}
+	// Improvement phase should move the selection towards 2*target
+	if want := uint64(5000000); total != want {
+		t.Errorf("got %v want %v", total, want)
+	}
+
+	again, err := NewRandomImprove(rand.NewSource(42)).Select(utxos, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, again) {
+		t.Errorf("same seed produced different selections:\ngot: %v\nwant: %v", again, got)
+	}
+
+	if _, err := NewRandomImprove(rand.NewSource(42)).Select(utxos, 11000000); err == nil {
+		t.Errorf("expected insufficient utxos error")
+	}
+}
+
+func TestTXBodyBuilder_BuildWithSelection(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	key = crypto.NewExtendedSigningKey([]byte("change address"), "foo")
+	change := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	utxos := testUtxos(1000000, 1000000, 1000000, 1000000, 1000000, 1000000)
+	builder := TXBodyBuilder{
+		Protocol: ShelleyProtocol,
+		TTL:      100,
+		Strategy: NewRandomImprove(rand.NewSource(1)),
+	}
+	amount := uint64(2000000)
+	body, err := builder.BuildWithSelection(receiver, utxos, amount, change)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The fee must be covered, so at least three inputs are needed
+	if len(body.Inputs) < 3 {
+		t.Errorf("got %v inputs want atleast 3", len(body.Inputs))
+	}
+	var totalOut uint64
+	for _, output := range body.Outputs {
+		totalOut += output.Amount
+	}
+	if got, want := totalOut+body.Fee, uint64(len(body.Inputs))*1000000; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestMinChange(t *testing.T) {
+	payment := uint64(2000000)
+	change, feasible := MinChange(testUtxos(10000000), payment, ShelleyProtocol)
+	if !feasible {
+		t.Fatalf("got infeasible change %v", change)
+	}
+	fee := 10000000 - payment - change
+
+	boundary := payment + fee + ShelleyProtocol.MinimumUtxoValue
+	change, feasible = MinChange(testUtxos(boundary), payment, ShelleyProtocol)
+	if !feasible || change != ShelleyProtocol.MinimumUtxoValue {
+		t.Errorf("got (%v, %v) want (%v, true)", change, feasible, ShelleyProtocol.MinimumUtxoValue)
+	}
+	change, feasible = MinChange(testUtxos(boundary-1), payment, ShelleyProtocol)
+	if feasible || change != ShelleyProtocol.MinimumUtxoValue-1 {
+		t.Errorf("got (%v, %v) want (%v, false)", change, feasible, ShelleyProtocol.MinimumUtxoValue-1)
+	}
+
+	if _, feasible := MinChange(testUtxos(payment+1000), payment, ShelleyProtocol); feasible {
+		t.Errorf("got feasible change without covering the fee")
+	}
+}
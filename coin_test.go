@@ -0,0 +1,64 @@
+package cardano
+
+import "testing"
+
+func TestFormatLovelace(t *testing.T) {
+	tests := []struct {
+		amount uint64
+		want   string
+	}{
+		{0, "0.000000 ₳"},
+		{1, "0.000001 ₳"},
+		{999999, "0.999999 ₳"},
+		{1000000, "1.000000 ₳"},
+		{1234567890, "1,234.567890 ₳"},
+		{45000000000000000, "45,000,000,000.000000 ₳"},
+		{maxUint64, "18,446,744,073,709.551615 ₳"},
+	}
+	for _, tt := range tests {
+		if got := FormatLovelace(tt.amount); got != tt.want {
+			t.Errorf("FormatLovelace(%v) = %v want %v", tt.amount, got, tt.want)
+		}
+	}
+
+	if got, want := FormatLovelaceNoSymbol(123456789), "123.456789"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestParseADA(t *testing.T) {
+	tests := []struct {
+		s    string
+		want uint64
+	}{
+		{"1.5", 1500000},
+		{"1", 1000000},
+		{"0.000001", 1},
+		{".5", 500000},
+		{"2.", 2000000},
+		{"18446744073709.551615", maxUint64},
+	}
+	for _, tt := range tests {
+		got, err := ParseADA(tt.s)
+		if err != nil {
+			t.Errorf("ParseADA(%v) error %v", tt.s, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseADA(%v) = %v want %v", tt.s, got, tt.want)
+		}
+		if parsed, _ := ParseADA(FormatADA(got)); parsed != got {
+			t.Errorf("got %v from %v want %v", parsed, FormatADA(got), got)
+		}
+	}
+
+	for _, s := range []string{"", ".", "1.0000001", "-1", "1,5", "1.5 ", "18446744073709.551616", "18446744073710"} {
+		if _, err := ParseADA(s); err == nil {
+			t.Errorf("ParseADA(%q) expected an error", s)
+		}
+	}
+
+	if got, want := FormatADA(1500000), "1.500000"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
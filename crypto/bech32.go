@@ -0,0 +1,52 @@
+package crypto
+
+import (
+	"fmt"
+
+	"github.com/echovl/bech32"
+)
+
+// Bech32 returns the key encoded with the prefix, as exported by the cardano
+// tooling (root_xsk, acct_xsk, addr_xsk...). It panics if the prefix is invalid.
+func (xsk *ExtendedSigningKey) Bech32(hrp string) string {
+	return encodeBech32(hrp, *xsk)
+}
+
+// Bech32 returns the key encoded with the prefix, as exported by the cardano
+// tooling (acct_xvk, addr_xvk, stake_xvk...). It panics if the prefix is invalid.
+func (xvk *ExtendedVerificationKey) Bech32(hrp string) string {
+	return encodeBech32(hrp, *xvk)
+}
+
+// ParseExtendedSigningKey decodes a bech32 extended signing key, returning
+// its prefix.
+func ParseExtendedSigningKey(s string) (string, ExtendedSigningKey, error) {
+	hrp, data, err := decodeBech32(s, 96)
+	return hrp, data, err
+}
+
+// ParseExtendedVerificationKey decodes a bech32 extended verification key,
+// returning its prefix.
+func ParseExtendedVerificationKey(s string) (string, ExtendedVerificationKey, error) {
+	hrp, data, err := decodeBech32(s, 64)
+	return hrp, data, err
+}
+
+func encodeBech32(hrp string, data []byte) string {
+	encoded, err := bech32.EncodeFromBase256(hrp, data)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+func decodeBech32(s string, length int) (string, []byte, error) {
+	hrp, data, err := bech32.DecodeToBase256(s)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(data) != length {
+		return "", nil, fmt.Errorf("invalid key length, got %v want %v", len(data), length)
+	}
+	return hrp, data, nil
+}
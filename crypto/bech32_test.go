@@ -0,0 +1,47 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestKeyBech32(t *testing.T) {
+	xsk, _ := hex.DecodeString(masterKeyWithoutPassphrase)
+	root := ExtendedSigningKey(xsk)
+	xvk := root.ExtendedVerificationKey()
+
+	hrp, gotXsk, err := ParseExtendedSigningKey(root.Bech32("root_xsk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hrp != "root_xsk" || !bytes.Equal(gotXsk, root) {
+		t.Errorf("got %v %x want root_xsk %x", hrp, gotXsk, root)
+	}
+
+	hrp, gotXvk, err := ParseExtendedVerificationKey(xvk.Bech32("root_xvk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hrp != "root_xvk" || !bytes.Equal(gotXvk, xvk) {
+		t.Errorf("got %v %x want root_xvk %x", hrp, gotXvk, xvk)
+	}
+
+	if _, _, err := ParseExtendedVerificationKey(root.Bech32("root_xsk")); err == nil {
+		t.Errorf("expected invalid key length error")
+	}
+
+	// Keys of the wallet test vectors
+	addrXsk := "addr_xsk1lq2ylz7fhsn0dfmul2pe833cdwvjnvux9uaxuzaz50gs7pnmm9wq343uh5cpfs87tgh9saa86un8e2l266rsge0c5qsmtaud5r64ctndwkyth8q07fgusyr3fldhn6lgd5tat5cmcdzvfzhtd0cpsleuxg3sakhv"
+	addrXvk := "addr_xvk1y3r70ejyadsaplez83p7uhy8p6l08a5sjl860kszevxu0jaxcwmx6avghwwqluj3eqg8zn7m0847smgh6hf3hs6ycj9wk6lsrplncvsxqj6wd"
+	_, key, err := ParseExtendedSigningKey(addrXsk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := key.Bech32("addr_xsk"); got != addrXsk {
+		t.Errorf("got %v want %v", got, addrXsk)
+	}
+	if vkey := key.ExtendedVerificationKey(); vkey.Bech32("addr_xvk") != addrXvk {
+		t.Errorf("got %v want %v", vkey.Bech32("addr_xvk"), addrXvk)
+	}
+}
@@ -9,6 +9,57 @@ import (
 	"github.com/echovl/ed25519"
 )
 
+// HardenedIndex is the offset of the hardened derivation indexes.
+const HardenedIndex uint32 = 0x80000000
+
+// CIP-1852 roles
+const (
+	ExternalRole uint32 = 0 // payment keys
+	InternalRole uint32 = 1 // change keys
+	StakingRole  uint32 = 2 // stake keys
+)
+
+// DeriveChildKey derives the child key of the index, hardened or not, with
+// the Ed25519-BIP32 scheme of the Cardano wallets. The index must be lower
+// than HardenedIndex.
+func DeriveChildKey(parent ExtendedSigningKey, index uint32, hardened bool) (ExtendedSigningKey, error) {
+	if index >= HardenedIndex {
+		return nil, fmt.Errorf("invalid child index %v", index)
+	}
+	if len(parent) != 96 {
+		return nil, fmt.Errorf("invalid parent key length %v", len(parent))
+	}
+	if hardened {
+		index += HardenedIndex
+	}
+	return DeriveSigningKey(parent, index), nil
+}
+
+// DeriveCIP1852 derives the key m/1852'/1815'/account'/role/index of the root
+// key, e.g. the payment keys of role ExternalRole and the stake keys of role
+// StakingRole.
+func DeriveCIP1852(root ExtendedSigningKey, account, role, index uint32) (ExtendedSigningKey, error) {
+	path := []struct {
+		index    uint32
+		hardened bool
+	}{
+		{1852, true},
+		{1815, true},
+		{account, true},
+		{role, false},
+		{index, false},
+	}
+	key := root
+	for _, child := range path {
+		var err error
+		key, err = DeriveChildKey(key, child.index, child.hardened)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
 func DeriveSigningKey(xsk ExtendedSigningKey, index uint32) ExtendedSigningKey {
 	xpriv := xsk[:64]
 	chainCode := xsk[64:]
@@ -3,6 +3,9 @@ package crypto
 import (
 	"bytes"
 	"testing"
+
+	"github.com/echovl/bech32"
+	"github.com/tyler-smith/go-bip39"
 )
 
 var D = []byte{
@@ -55,3 +58,40 @@ func TestDerivePublicKey(t *testing.T) {
 		t.Errorf("invalid derived verification key: \ngot: %v\nwant: %v", got[32:64], want[32:64])
 	}
 }
+
+func TestDeriveCIP1852(t *testing.T) {
+	// CIP-19 test mnemonic and keys
+	entropy, err := bip39.EntropyFromMnemonic("test walk nut penalty hip pave soap entry language right filter choice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := NewExtendedSigningKey(entropy, "")
+
+	tests := []struct {
+		role uint32
+		want string
+	}{
+		{ExternalRole, "addr_vk1w0l2sr2zgfm26ztc6nl9xy8ghsk5sh6ldwemlpmp9xylzy4dtf7st80zhd"},
+		{StakingRole, "stake_vk19szpe8r2va4v2nf9ut7uu3x9vkq7x94wgwkuf3alzlery9xcmzfqvz3ppr"},
+	}
+	for _, tt := range tests {
+		key, err := DeriveCIP1852(root, 0, tt.role, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hrp, want, err := bech32.DecodeToBase256(tt.want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := key.VerificationKey(); !bytes.Equal(got, want) {
+			t.Errorf("got %v key %x want %x", hrp, got, want)
+		}
+	}
+
+	if _, err := DeriveCIP1852(root, HardenedIndex, ExternalRole, 0); err == nil {
+		t.Errorf("expected invalid account error")
+	}
+	if _, err := DeriveChildKey(root[:64], 0, true); err == nil {
+		t.Errorf("expected invalid parent key error")
+	}
+}
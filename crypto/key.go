@@ -2,6 +2,9 @@ package crypto
 
 import (
 	"crypto/sha512"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/echovl/ed25519"
 	"github.com/tyler-smith/go-bip39"
@@ -20,10 +23,19 @@ func (xsk *ExtendedSigningKey) Sign(message []byte) []byte {
 type ExtendedVerificationKey []byte
 
 func (xvk *ExtendedVerificationKey) Verify(message, signature []byte) bool {
+	if len(*xvk) < ed25519.PublicKeySize || len(signature) != ed25519.SignatureSize {
+		return false
+	}
 	pk := ed25519.PublicKey((*xvk)[:32])
 	return ed25519.Verify(pk, message, signature)
 }
 
+// VerificationKey returns the ed25519 public key (32 bytes), without the chain
+// code, as used in vkey witnesses.
+func (xvk *ExtendedVerificationKey) VerificationKey() []byte {
+	return (*xvk)[:ed25519.PublicKeySize]
+}
+
 func NewExtendedSigningKey(entropy []byte, password string) ExtendedSigningKey {
 	key := pbkdf2.Key([]byte(password), entropy, 4096, 96, sha512.New)
 
@@ -41,6 +53,40 @@ func NewMnemonic(entropy []byte) string {
 	return mnemonic
 }
 
+var (
+	ErrInvalidEntropySize = errors.New("invalid entropy size")
+	ErrInvalidMnemonic    = errors.New("invalid mnemonic")
+)
+
+// GenerateMnemonic returns a random BIP39 mnemonic of entropyBits bits of
+// entropy, 128 to 256 bits in steps of 32 (12 to 24 words).
+func GenerateMnemonic(entropyBits int) (string, error) {
+	if entropyBits < 128 || entropyBits > 256 || entropyBits%32 != 0 {
+		return "", fmt.Errorf("%w, got %v want 128, 160, 192, 224 or 256 bits", ErrInvalidEntropySize, entropyBits)
+	}
+	entropy, err := bip39.NewEntropy(entropyBits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// RootKeyFromMnemonic returns the Icarus (CIP-3) root key of the mnemonic,
+// the mnemonic word count and checksum are validated.
+func RootKeyFromMnemonic(mnemonic, passphrase string) (ExtendedSigningKey, error) {
+	words := strings.Fields(mnemonic)
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return nil, fmt.Errorf("%w, got %v words want 12, 15, 18, 21 or 24", ErrInvalidMnemonic, len(words))
+	}
+	entropy, err := bip39.EntropyFromMnemonic(strings.Join(words, " "))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMnemonic, err)
+	}
+	return NewExtendedSigningKey(entropy, passphrase), nil
+}
+
 func (xsk *ExtendedSigningKey) ExtendedVerificationKey() ExtendedVerificationKey {
 	xvk := make([]byte, 64)
 	pk := ed25519.PublicKeyFrom(ed25519.ExtendedPrivateKey((*xsk)[:64]))
@@ -51,3 +97,10 @@ func (xsk *ExtendedSigningKey) ExtendedVerificationKey() ExtendedVerificationKey
 
 	return xvk
 }
+
+// VerificationKey returns the ed25519 public key (32 bytes) of the signing key,
+// as used in vkey witnesses.
+func (xsk *ExtendedSigningKey) VerificationKey() []byte {
+	xvk := xsk.ExtendedVerificationKey()
+	return xvk.VerificationKey()
+}
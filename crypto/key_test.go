@@ -3,6 +3,8 @@ package crypto
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/tyler-smith/go-bip39"
@@ -36,3 +38,56 @@ func TestExtendedSigningKeyWithPassphrase(t *testing.T) {
 		t.Errorf("invalid master key\ngot: %x\nwant: %x\n", got, want)
 	}
 }
+
+func TestExtendedSigningKey_VerificationKey(t *testing.T) {
+	entropy, _ := bip39.EntropyFromMnemonic(mnemonic)
+	xsk := NewExtendedSigningKey(entropy, "")
+
+	vkey := xsk.VerificationKey()
+	if got, want := len(vkey), 32; got != want {
+		t.Fatalf("got length %v want %v", got, want)
+	}
+	xvk := xsk.ExtendedVerificationKey()
+	if !bytes.Equal(vkey, xvk[:32]) {
+		t.Errorf("got %x want %x", vkey, xvk[:32])
+	}
+}
+
+func TestRootKeyFromMnemonic(t *testing.T) {
+	got, err := RootKeyFromMnemonic(mnemonic, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := hex.DecodeString(masterKeyWithPassphrase); !bytes.Equal(got, want) {
+		t.Errorf("invalid master key\ngot: %x\nwant: %x\n", got, want)
+	}
+
+	invalid := []string{
+		"eight country switch draw meat scout mystery blade tip drift useless good keep usage",
+		"eight country switch draw meat scout mystery blade tip drift useless good keep usage usage",
+		"eight country switch draw meat scout mystery blade tip drift useless good keep usage notaword",
+	}
+	for _, phrase := range invalid {
+		if _, err := RootKeyFromMnemonic(phrase, ""); !errors.Is(err, ErrInvalidMnemonic) {
+			t.Errorf("got error %v want %v", err, ErrInvalidMnemonic)
+		}
+	}
+}
+
+func TestGenerateMnemonic(t *testing.T) {
+	for bits, words := range map[int]int{128: 12, 160: 15, 256: 24} {
+		phrase, err := GenerateMnemonic(bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(strings.Fields(phrase)); got != words {
+			t.Errorf("got %v words want %v", got, words)
+		}
+		if _, err := RootKeyFromMnemonic(phrase, ""); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := GenerateMnemonic(100); !errors.Is(err, ErrInvalidEntropySize) {
+		t.Errorf("got error %v want %v", err, ErrInvalidEntropySize)
+	}
+}
@@ -0,0 +1,492 @@
+package cardano
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// maxMetadatumSize is the maximum size of metadata bytes and text strings.
+const maxMetadatumSize = 64
+
+// Cbor map
+type transactionMetadata map[uint64]transactionMetadatum
+
+// MarshalCBOR implements cbor.Marshaler, the labels are encoded in ascending
+// order.
+func (metadata transactionMetadata) MarshalCBOR() ([]byte, error) {
+	labels := make([]uint64, 0, len(metadata))
+	for label := range metadata {
+		labels = append(labels, label)
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i] < labels[j] })
+
+	out := cborHead(cborMajorMap, uint64(len(labels)))
+	for _, label := range labels {
+		value, err := metadata[label].MarshalCBOR()
+		if err != nil {
+			return nil, fmt.Errorf("invalid metadata label %v: %w", label, err)
+		}
+		out = append(out, cborHead(cborMajorUint, label)...)
+		out = append(out, value...)
+	}
+	return out, nil
+}
+
+// Hash returns the blake2b-256 hash of the encoded metadata, committed to by
+// the body metadata hash.
+func (metadata transactionMetadata) Hash() ([]byte, error) {
+	encoded, err := metadata.MarshalCBOR()
+	if err != nil {
+		return nil, err
+	}
+	hash := blake2b.Sum256(encoded)
+	return hash[:], nil
+}
+
+type metadatumKind byte
+
+const (
+	metadatumInt metadatumKind = iota
+	metadatumBytes
+	metadatumText
+	metadatumList
+	metadatumMap
+)
+
+// This could be cbor map, array, int, bytes or a text
+type transactionMetadatum struct {
+	kind metadatumKind
+
+	// The int is n, or -1-n if negative, as in cbor
+	negative bool
+	n        uint64
+
+	bytes []byte
+	text  string
+	list  []transactionMetadatum
+	pairs []MetadatumPair
+
+	raw     []byte // original bytes of a decoded metadatum
+	encoded []byte // encoding of the metadatum when it was decoded
+}
+
+// MetadatumPair is an entry of a metadatum map.
+type MetadatumPair struct {
+	Key   transactionMetadatum
+	Value transactionMetadatum
+}
+
+// MetadatumInt returns an int metadatum.
+func MetadatumInt(v int64) transactionMetadatum {
+	if v < 0 {
+		return transactionMetadatum{kind: metadatumInt, negative: true, n: uint64(-(v + 1))}
+	}
+	return transactionMetadatum{kind: metadatumInt, n: uint64(v)}
+}
+
+// MetadatumBytes returns a bytes metadatum, at most 64 bytes long.
+func MetadatumBytes(b []byte) transactionMetadatum {
+	return transactionMetadatum{kind: metadatumBytes, bytes: b}
+}
+
+// MetadatumText returns a text metadatum, at most 64 bytes long once utf-8
+// encoded.
+func MetadatumText(s string) transactionMetadatum {
+	return transactionMetadatum{kind: metadatumText, text: s}
+}
+
+// MetadatumList returns a list metadatum.
+func MetadatumList(items ...transactionMetadatum) transactionMetadatum {
+	return transactionMetadatum{kind: metadatumList, list: items}
+}
+
+// MetadatumMap returns a map metadatum, the pairs are encoded in order.
+func MetadatumMap(pairs ...MetadatumPair) transactionMetadatum {
+	return transactionMetadatum{kind: metadatumMap, pairs: pairs}
+}
+
+// MarshalCBOR implements cbor.Marshaler. A decoded metadatum is encoded as
+// its original bytes unless it was modified.
+func (m transactionMetadatum) MarshalCBOR() ([]byte, error) {
+	encoded, err := m.encode()
+	if err != nil {
+		return nil, err
+	}
+	if m.raw != nil && bytes.Equal(encoded, m.encoded) {
+		return m.raw, nil
+	}
+	return encoded, nil
+}
+
+func (m transactionMetadatum) encode() ([]byte, error) {
+	switch m.kind {
+	case metadatumInt:
+		if m.negative {
+			return cborHead(cborMajorNegative, m.n), nil
+		}
+		return cborHead(cborMajorUint, m.n), nil
+	case metadatumBytes:
+		if len(m.bytes) > maxMetadatumSize {
+			return nil, fmt.Errorf("metadata bytes too long, got %v want atmost %v", len(m.bytes), maxMetadatumSize)
+		}
+		return append(cborHead(cborMajorBytes, uint64(len(m.bytes))), m.bytes...), nil
+	case metadatumText:
+		if len(m.text) > maxMetadatumSize {
+			return nil, fmt.Errorf("metadata text too long, got %v want atmost %v", len(m.text), maxMetadatumSize)
+		}
+		return append(cborHead(cborMajorText, uint64(len(m.text))), m.text...), nil
+	case metadatumList:
+		out := cborHead(cborMajorArray, uint64(len(m.list)))
+		for _, item := range m.list {
+			itemBytes, err := item.MarshalCBOR()
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, itemBytes...)
+		}
+		return out, nil
+	case metadatumMap:
+		out := cborHead(cborMajorMap, uint64(len(m.pairs)))
+		for _, pair := range m.pairs {
+			keyBytes, err := pair.Key.MarshalCBOR()
+			if err != nil {
+				return nil, err
+			}
+			valueBytes, err := pair.Value.MarshalCBOR()
+			if err != nil {
+				return nil, err
+			}
+			out = append(append(out, keyBytes...), valueBytes...)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unknown metadatum kind %v", m.kind)
+}
+
+// UnmarshalCBOR implements cbor.Unmarshaler.
+func (m *transactionMetadatum) UnmarshalCBOR(data []byte) error {
+	decoded, n, err := decodeMetadatum(data)
+	if err != nil {
+		return err
+	}
+	if n != len(data) {
+		return fmt.Errorf("unexpected data after metadatum")
+	}
+	encoded, err := decoded.encode()
+	if err != nil {
+		return err
+	}
+	*m = decoded
+	m.raw = append([]byte(nil), data...)
+	m.encoded = encoded
+	return nil
+}
+
+// decodeMetadatum decodes the metadatum at the start of data, returning the
+// number of bytes read. Indefinite length items are accepted.
+func decodeMetadatum(data []byte) (transactionMetadatum, int, error) {
+	major, arg, indefinite, n, err := readCborHead(data)
+	if err != nil {
+		return transactionMetadatum{}, 0, err
+	}
+
+	switch major {
+	case cborMajorUint, cborMajorNegative:
+		if indefinite {
+			return transactionMetadatum{}, 0, fmt.Errorf("invalid indefinite length int")
+		}
+		return transactionMetadatum{kind: metadatumInt, negative: major == cborMajorNegative, n: arg}, n, nil
+	case cborMajorBytes, cborMajorText:
+		var b []byte
+		if indefinite {
+			for {
+				if n >= len(data) {
+					return transactionMetadatum{}, 0, fmt.Errorf("unexpected end of metadata")
+				}
+				if data[n] == 0xff {
+					n++
+					break
+				}
+				chunkMajor, chunkLength, chunkIndefinite, headLength, err := readCborHead(data[n:])
+				if err != nil {
+					return transactionMetadatum{}, 0, err
+				}
+				if chunkMajor != major || chunkIndefinite {
+					return transactionMetadatum{}, 0, fmt.Errorf("invalid indefinite length string chunk")
+				}
+				n += headLength
+				if uint64(len(data)-n) < chunkLength {
+					return transactionMetadatum{}, 0, fmt.Errorf("unexpected end of metadata")
+				}
+				b = append(b, data[n:n+int(chunkLength)]...)
+				n += int(chunkLength)
+			}
+		} else {
+			if uint64(len(data)-n) < arg {
+				return transactionMetadatum{}, 0, fmt.Errorf("unexpected end of metadata")
+			}
+			b = append([]byte{}, data[n:n+int(arg)]...)
+			n += int(arg)
+		}
+		if len(b) > maxMetadatumSize {
+			return transactionMetadatum{}, 0, fmt.Errorf("metadata string too long, got %v want atmost %v", len(b), maxMetadatumSize)
+		}
+		if major == cborMajorBytes {
+			return MetadatumBytes(b), n, nil
+		}
+		if !utf8.Valid(b) {
+			return transactionMetadatum{}, 0, fmt.Errorf("invalid utf-8 metadata text")
+		}
+		return MetadatumText(string(b)), n, nil
+	case cborMajorArray, cborMajorMap:
+		items := []transactionMetadatum{}
+		itemsPerEntry := 1
+		if major == cborMajorMap {
+			itemsPerEntry = 2
+		}
+		for i := uint64(0); indefinite || i < arg*uint64(itemsPerEntry); i++ {
+			if n >= len(data) {
+				return transactionMetadatum{}, 0, fmt.Errorf("unexpected end of metadata")
+			}
+			if indefinite && data[n] == 0xff {
+				if len(items)%itemsPerEntry != 0 {
+					return transactionMetadatum{}, 0, fmt.Errorf("missing metadata map value")
+				}
+				n++
+				break
+			}
+			item, itemLength, err := decodeMetadatum(data[n:])
+			if err != nil {
+				return transactionMetadatum{}, 0, err
+			}
+			items = append(items, item)
+			n += itemLength
+		}
+		if major == cborMajorArray {
+			return MetadatumList(items...), n, nil
+		}
+		pairs := make([]MetadatumPair, len(items)/2)
+		for i := range pairs {
+			pairs[i] = MetadatumPair{Key: items[2*i], Value: items[2*i+1]}
+		}
+		return MetadatumMap(pairs...), n, nil
+	}
+	return transactionMetadatum{}, 0, fmt.Errorf("invalid metadatum major type %v", major)
+}
+
+const (
+	cborMajorUint     byte = 0
+	cborMajorNegative byte = 1
+	cborMajorBytes    byte = 2
+	cborMajorText     byte = 3
+	cborMajorArray    byte = 4
+	cborMajorMap      byte = 5
+)
+
+// cborHead encodes the shortest head of a cbor item.
+func cborHead(major byte, arg uint64) []byte {
+	major <<= 5
+	switch {
+	case arg < 24:
+		return []byte{major | byte(arg)}
+	case arg <= 0xff:
+		return []byte{major | 24, byte(arg)}
+	case arg <= 0xffff:
+		return []byte{major | 25, byte(arg >> 8), byte(arg)}
+	case arg <= 0xffffffff:
+		head := []byte{major | 26, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(head[1:], uint32(arg))
+		return head
+	}
+	head := []byte{major | 27, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint64(head[1:], arg)
+	return head
+}
+
+// readCborHead reads the head of a cbor item, returning its major type,
+// argument, whether it's of indefinite length and the size of the head.
+func readCborHead(data []byte) (major byte, arg uint64, indefinite bool, n int, err error) {
+	if len(data) == 0 {
+		return 0, 0, false, 0, fmt.Errorf("unexpected end of metadata")
+	}
+	major, info := data[0]>>5, data[0]&0x1f
+	switch {
+	case info < 24:
+		return major, uint64(info), false, 1, nil
+	case info <= 27:
+		size := 1 << (info - 24)
+		if len(data) < 1+size {
+			return 0, 0, false, 0, fmt.Errorf("unexpected end of metadata")
+		}
+		for _, b := range data[1 : 1+size] {
+			arg = arg<<8 | uint64(b)
+		}
+		return major, arg, false, 1 + size, nil
+	case info == 31 && major >= cborMajorBytes && major <= cborMajorMap:
+		return major, 0, true, 1, nil
+	}
+	return 0, 0, false, 0, fmt.Errorf("invalid cbor head %x", data[0])
+}
+
+// MetadataFromJSON decodes metadata in the cardano-cli detailed schema, e.g.
+// {"674": {"map": [{"k": {"string": "msg"}, "v": {"list": [{"int": 1}]}}]}}.
+func MetadataFromJSON(data []byte) (transactionMetadata, error) {
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	metadata := transactionMetadata{}
+	for key, value := range raw {
+		label, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metadata label %q, want an unsigned integer", key)
+		}
+		m := transactionMetadatum{}
+		if err := json.Unmarshal(value, &m); err != nil {
+			return nil, fmt.Errorf("invalid metadata label %v: %w", label, err)
+		}
+		metadata[label] = m
+	}
+	return metadata, nil
+}
+
+// MarshalJSON implements json.Marshaler using the cardano-cli detailed schema.
+func (metadata transactionMetadata) MarshalJSON() ([]byte, error) {
+	raw := map[string]transactionMetadatum{}
+	for label, m := range metadata {
+		raw[strconv.FormatUint(label, 10)] = m
+	}
+	return json.Marshal(raw)
+}
+
+type metadatumJSON struct {
+	Int    *json.Number         `json:"int,omitempty"`
+	Bytes  *string              `json:"bytes,omitempty"`
+	String *string              `json:"string,omitempty"`
+	List   *[]json.RawMessage   `json:"list,omitempty"`
+	Map    *[]metadatumPairJSON `json:"map,omitempty"`
+}
+
+type metadatumPairJSON struct {
+	K json.RawMessage `json:"k"`
+	V json.RawMessage `json:"v"`
+}
+
+var (
+	minMetadatumInt = new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 64))
+	maxMetadatumInt = new(big.Int).SetUint64(maxUint64)
+)
+
+// MarshalJSON implements json.Marshaler using the cardano-cli detailed schema.
+func (m transactionMetadatum) MarshalJSON() ([]byte, error) {
+	switch m.kind {
+	case metadatumInt:
+		v := new(big.Int).SetUint64(m.n)
+		if m.negative {
+			v.Neg(v.Add(v, big.NewInt(1)))
+		}
+		number := json.Number(v.String())
+		return json.Marshal(metadatumJSON{Int: &number})
+	case metadatumBytes:
+		encoded := hex.EncodeToString(m.bytes)
+		return json.Marshal(metadatumJSON{Bytes: &encoded})
+	case metadatumText:
+		return json.Marshal(metadatumJSON{String: &m.text})
+	case metadatumList:
+		list := []transactionMetadatum{}
+		list = append(list, m.list...)
+		return json.Marshal(map[string][]transactionMetadatum{"list": list})
+	case metadatumMap:
+		type pair struct {
+			K transactionMetadatum `json:"k"`
+			V transactionMetadatum `json:"v"`
+		}
+		pairs := make([]pair, len(m.pairs))
+		for i, p := range m.pairs {
+			pairs[i] = pair{K: p.Key, V: p.Value}
+		}
+		return json.Marshal(map[string][]pair{"map": pairs})
+	}
+	return nil, fmt.Errorf("unknown metadatum kind %v", m.kind)
+}
+
+// UnmarshalJSON implements json.Unmarshaler using the cardano-cli detailed
+// schema.
+func (m *transactionMetadatum) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	decoder.DisallowUnknownFields()
+	raw := metadatumJSON{}
+	if err := decoder.Decode(&raw); err != nil {
+		return fmt.Errorf("invalid metadatum %s: %v", data, err)
+	}
+	if count := countSet(raw.Int != nil, raw.Bytes != nil, raw.String != nil, raw.List != nil, raw.Map != nil); count > 1 {
+		return fmt.Errorf("invalid metadatum %s, want a single kind", data)
+	}
+
+	switch {
+	case raw.Int != nil:
+		v, ok := new(big.Int).SetString(raw.Int.String(), 10)
+		if !ok || v.Cmp(minMetadatumInt) < 0 || v.Cmp(maxMetadatumInt) > 0 {
+			return fmt.Errorf("invalid metadata int %v", *raw.Int)
+		}
+		if v.Sign() < 0 {
+			*m = transactionMetadatum{kind: metadatumInt, negative: true, n: new(big.Int).Sub(new(big.Int).Neg(v), big.NewInt(1)).Uint64()}
+		} else {
+			*m = transactionMetadatum{kind: metadatumInt, n: v.Uint64()}
+		}
+	case raw.Bytes != nil:
+		b, err := hex.DecodeString(*raw.Bytes)
+		if err != nil {
+			return fmt.Errorf("invalid metadata bytes %q: %v", *raw.Bytes, err)
+		}
+		*m = MetadatumBytes(b)
+	case raw.String != nil:
+		*m = MetadatumText(*raw.String)
+	case raw.List != nil:
+		items := make([]transactionMetadatum, len(*raw.List))
+		for i, rawItem := range *raw.List {
+			if err := json.Unmarshal(rawItem, &items[i]); err != nil {
+				return err
+			}
+		}
+		*m = MetadatumList(items...)
+	case raw.Map != nil:
+		pairs := make([]MetadatumPair, len(*raw.Map))
+		for i, rawPair := range *raw.Map {
+			if err := json.Unmarshal(rawPair.K, &pairs[i].Key); err != nil {
+				return err
+			}
+			if err := json.Unmarshal(rawPair.V, &pairs[i].Value); err != nil {
+				return err
+			}
+		}
+		*m = MetadatumMap(pairs...)
+	default:
+		return fmt.Errorf("invalid metadatum %s, want one of int, bytes, string, list or map", data)
+	}
+
+	if _, err := m.encode(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func countSet(set ...bool) int {
+	count := 0
+	for _, ok := range set {
+		if ok {
+			count++
+		}
+	}
+	return count
+}
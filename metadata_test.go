@@ -0,0 +1,229 @@
+package cardano
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"golang.org/x/crypto/blake2b"
+)
+
+func TestTransactionMetadata_MarshalCBOR(t *testing.T) {
+	metadata := transactionMetadata{
+		674: MetadatumMap(MetadatumPair{
+			Key:   MetadatumText("msg"),
+			Value: MetadatumList(MetadatumText("hello"), MetadatumText("world")),
+		}),
+		2: MetadatumBytes([]byte{0x01, 0x02, 0x03}),
+		1: MetadatumInt(-5),
+	}
+	tx := &Transaction{
+		Body: TransactionBody{
+			Inputs:  []TransactionInput{{ID: make([]byte, 32), Index: 1}},
+			Outputs: []TransactionOutput{{Address: make([]byte, 29), Amount: 1000000}},
+			Fee:     170000,
+			Ttl:     100,
+		},
+		Metadata: &metadata,
+	}
+
+	got, err := cbor.Marshal(metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := hex.DecodeString("a3" + "0124" + "0243010203" + "1902a2" + "a1636d7367826568656c6c6f65776f726c64")
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x want %x", got, want)
+	}
+
+	decoded, err := DecodeTransactionBytes(tx.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Metadata == nil {
+		t.Fatalf("missing decoded metadata")
+	}
+	msg := (*decoded.Metadata)[674]
+	if msg.kind != metadatumMap || len(msg.pairs) != 1 || msg.pairs[0].Value.list[1].text != "world" {
+		t.Errorf("got %+v want the msg map", msg)
+	}
+	if got := (*decoded.Metadata)[1]; !got.negative || got.n != 4 {
+		t.Errorf("got %+v want -5", got)
+	}
+	if got, want := decoded.Bytes(), tx.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("got %x want %x", got, want)
+	}
+}
+
+func TestTransaction_DecodedMetadataOrder(t *testing.T) {
+	// Labels out of order, as produced by another serializer
+	rawMetadata := []byte{0xa2, 0x02, 0x01, 0x01, 0x02}
+	hash := blake2b.Sum256(rawMetadata)
+	hashBytes := hash[:]
+	tx := &Transaction{Body: TransactionBody{
+		Inputs:       []TransactionInput{{ID: make([]byte, 32), Index: 1}},
+		Outputs:      []TransactionOutput{{Address: make([]byte, 29), Amount: 1000000}},
+		Fee:          170000,
+		Ttl:          100,
+		MetadataHash: &hashBytes,
+	}}
+	txBytes := tx.Bytes()
+	txBytes = append(txBytes[:len(txBytes)-1], rawMetadata...)
+
+	decoded, err := DecodeTransactionBytes(txBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.Bytes(); !bytes.Equal(got, txBytes) {
+		t.Errorf("got %x want %x", got, txBytes)
+	}
+	if err := decoded.VerifyMetadataHash(); err != nil {
+		t.Error(err)
+	}
+
+	// Modified metadata is encoded in ascending order
+	(*decoded.Metadata)[3] = MetadatumInt(3)
+	want := []byte{0xa3, 0x01, 0x02, 0x02, 0x01, 0x03, 0x03}
+	if got := decoded.Bytes(); !bytes.HasSuffix(got, want) {
+		t.Errorf("got %x want suffix %x", got, want)
+	}
+}
+
+func TestTransactionMetadatum_UnmarshalCBOR(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "indefinite list", data: "9f0102ff"},
+		{name: "indefinite map", data: "bf0102ff"},
+		{name: "indefinite text", data: "7f61616162ff"},
+		{name: "non shortest int", data: "1800"},
+		{name: "min negative int", data: "3bffffffffffffffff"},
+		{name: "max uint", data: "1bffffffffffffffff"},
+		{name: "text too long", data: "7841" + strings.Repeat("61", 65), wantErr: true},
+		{name: "tag", data: "c249010000000000000000", wantErr: true},
+		{name: "float", data: "f93c00", wantErr: true},
+		{name: "missing map value", data: "bf01ff", wantErr: true},
+		{name: "invalid utf-8", data: "61ff", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := hex.DecodeString(tt.data)
+			m := transactionMetadatum{}
+			err := m.UnmarshalCBOR(data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalCBOR() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, err := m.MarshalCBOR()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("got %x want %x", got, data)
+			}
+		})
+	}
+
+	// The original bytes are dropped once modified
+	data, _ := hex.DecodeString("9f0102ff")
+	m := transactionMetadatum{}
+	if err := m.UnmarshalCBOR(data); err != nil {
+		t.Fatal(err)
+	}
+	m.list = append(m.list, MetadatumInt(3))
+	got, err := m.MarshalCBOR()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := hex.DecodeString("83010203"); !bytes.Equal(got, want) {
+		t.Errorf("got %x want %x", got, want)
+	}
+}
+
+func TestTransactionMetadatum_SizeLimit(t *testing.T) {
+	if _, err := MetadatumText(strings.Repeat("a", 64)).MarshalCBOR(); err != nil {
+		t.Errorf("MarshalCBOR() error = %v", err)
+	}
+	if _, err := MetadatumText(strings.Repeat("a", 65)).MarshalCBOR(); err == nil {
+		t.Errorf("expected text too long error")
+	}
+	if _, err := MetadatumList(MetadatumBytes(make([]byte, 65))).MarshalCBOR(); err == nil {
+		t.Errorf("expected nested bytes too long error")
+	}
+}
+
+func TestMetadataFromJSON(t *testing.T) {
+	data := []byte(`{
+		"1": {"int": -18446744073709551616},
+		"2": {"bytes": "010203"},
+		"674": {"map": [{"k": {"string": "msg"}, "v": {"list": [{"string": "hello"}, {"int": 18446744073709551615}]}}]},
+		"721": {"list": []}
+	}`)
+	metadata, err := MetadataFromJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := metadata[1]; !got.negative || got.n != maxUint64 {
+		t.Errorf("got %+v want -2^64", got)
+	}
+	if got := metadata[674].pairs[0].Value.list[1]; got.negative || got.n != maxUint64 {
+		t.Errorf("got %+v want 2^64-1", got)
+	}
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := MetadataFromJSON(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, encoded) {
+		t.Errorf("unstable round trip:\ngot: %s\nwant: %s", again, encoded)
+	}
+	want := `{"1":{"int":-18446744073709551616},"2":{"bytes":"010203"},` +
+		`"674":{"map":[{"k":{"string":"msg"},"v":{"list":[{"string":"hello"},{"int":18446744073709551615}]}}]},"721":{"list":[]}}`
+	if string(encoded) != want {
+		t.Errorf("got %s want %s", encoded, want)
+	}
+
+	cborBytes, err := cbor.Marshal(metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := cbor.Marshal(decoded); err != nil || !bytes.Equal(got, cborBytes) {
+		t.Errorf("got %x want %x", got, cborBytes)
+	}
+
+	invalid := []struct {
+		name string
+		data string
+	}{
+		{name: "label", data: `{"msg": {"int": 1}}`},
+		{name: "negative label", data: `{"-1": {"int": 1}}`},
+		{name: "bytes hex", data: `{"1": {"bytes": "zz"}}`},
+		{name: "int range", data: `{"1": {"int": 18446744073709551616}}`},
+		{name: "float", data: `{"1": {"int": 1.5}}`},
+		{name: "unknown kind", data: `{"1": {"float": 1}}`},
+		{name: "two kinds", data: `{"1": {"int": 1, "string": "a"}}`},
+		{name: "text too long", data: `{"1": {"string": "` + strings.Repeat("a", 65) + `"}}`},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := MetadataFromJSON([]byte(tt.data)); err == nil {
+				t.Errorf("expected error decoding %s", tt.data)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package cardano
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+	"golang.org/x/crypto/blake2b"
+)
+
+// NativeScriptType is the kind of a native script.
+type NativeScriptType uint64
+
+const (
+	ScriptPubKey           NativeScriptType = 0
+	ScriptAll              NativeScriptType = 1
+	ScriptAny              NativeScriptType = 2
+	ScriptNOfK             NativeScriptType = 3
+	ScriptInvalidBefore    NativeScriptType = 4
+	ScriptInvalidHereafter NativeScriptType = 5
+)
+
+// NativeScript is a multisig or timelock script, e.g. a minting policy.
+type NativeScript struct {
+	Type    NativeScriptType
+	KeyHash []byte         // ScriptPubKey
+	Scripts []NativeScript // ScriptAll, ScriptAny and ScriptNOfK
+	N       uint64         // ScriptNOfK
+	Slot    uint64         // ScriptInvalidBefore and ScriptInvalidHereafter
+}
+
+// NewScriptPubKey returns a script requiring a signature of the key hash.
+func NewScriptPubKey(keyHash []byte) NativeScript {
+	return NativeScript{Type: ScriptPubKey, KeyHash: keyHash}
+}
+
+// NewScriptAll returns a script requiring all the scripts.
+func NewScriptAll(scripts ...NativeScript) NativeScript {
+	return NativeScript{Type: ScriptAll, Scripts: scripts}
+}
+
+// NewScriptAny returns a script requiring any of the scripts.
+func NewScriptAny(scripts ...NativeScript) NativeScript {
+	return NativeScript{Type: ScriptAny, Scripts: scripts}
+}
+
+// NewScriptNOfK returns a script requiring n of the scripts, e.g. a n of k
+// multisig of pubkey scripts.
+func NewScriptNOfK(n uint64, scripts ...NativeScript) NativeScript {
+	return NativeScript{Type: ScriptNOfK, N: n, Scripts: scripts}
+}
+
+// NewScriptInvalidBefore returns a script requiring the transaction to be
+// invalid before the slot.
+func NewScriptInvalidBefore(slot uint64) NativeScript {
+	return NativeScript{Type: ScriptInvalidBefore, Slot: slot}
+}
+
+// NewScriptInvalidHereafter returns a script requiring the transaction to be
+// invalid after the slot.
+func NewScriptInvalidHereafter(slot uint64) NativeScript {
+	return NativeScript{Type: ScriptInvalidHereafter, Slot: slot}
+}
+
+// NewTimeLockedMintPolicy returns the minting policy requiring a signature of
+// the signer key hash and the transaction to be invalid after the deadline
+// slot, so that no token can be minted once it's reached.
+func NewTimeLockedMintPolicy(signerKeyHash []byte, deadlineSlot uint64) NativeScript {
+	return NewScriptAll(NewScriptPubKey(signerKeyHash), NewScriptInvalidHereafter(deadlineSlot))
+}
+
+// Validate checks that the script can be encoded: the key hashes are 28 bytes
+// and the script types are known.
+func (script NativeScript) Validate() error {
+	_, err := cbor.Marshal(script)
+	return err
+}
+
+// Hash returns the blake2b-224 hash of the script, the policy id of the
+// tokens it mints or the credential of its address. It panics if the script
+// is invalid, see Validate.
+func (script NativeScript) Hash() [28]byte {
+	encoded, err := cbor.Marshal(script)
+	if err != nil {
+		panic(fmt.Sprintf("invalid native script: %v", err))
+	}
+	hash, err := blake2b.New(224/8, nil)
+	if err != nil {
+		panic(err)
+	}
+	hash.Write([]byte{0x00}) // native script tag
+	hash.Write(encoded)
+	var sum [28]byte
+	copy(sum[:], hash.Sum(nil))
+	return sum
+}
+
+// PolicyID returns the hex encoded hash of the script, or an error if the
+// script is invalid.
+func (script NativeScript) PolicyID() (PolicyID, error) {
+	if err := script.Validate(); err != nil {
+		return "", err
+	}
+	hash := script.Hash()
+	return PolicyID(hex.EncodeToString(hash[:])), nil
+}
+
+// keyHashes returns the key hashes of the script, each requiring a witness.
+func (script NativeScript) keyHashes() [][]byte {
+	if script.Type == ScriptPubKey {
+		return [][]byte{script.KeyHash}
+	}
+	hashes := [][]byte{}
+	for _, sub := range script.Scripts {
+		hashes = append(hashes, sub.keyHashes()...)
+	}
+	return hashes
+}
+
+// IsSatisfiedBy reports whether the witnesses' keys and the validity interval
+// from validityStart to ttl satisfy the script. The signatures aren't checked,
+// a zero validityStart or ttl means the interval is unbounded on that side.
+func (script NativeScript) IsSatisfiedBy(witnesses []VKeyWitness, validityStart, ttl uint64) bool {
+	return script.satisfied(func(hash []byte) bool {
+		for _, witness := range witnesses {
+			if len(witness.VKey) == 32 && bytes.Equal(keyHash(witness.VKey), hash) {
+				return true
+			}
+		}
+		return false
+	}, validityStart, ttl)
+}
+
+// allowsInterval reports whether the script can be satisfied in the validity
+// interval from validityStart to ttl, assuming all its keys sign.
+func (script NativeScript) allowsInterval(validityStart, ttl uint64) bool {
+	return script.satisfied(func([]byte) bool { return true }, validityStart, ttl)
+}
+
+func (script NativeScript) satisfied(signed func(keyHash []byte) bool, validityStart, ttl uint64) bool {
+	switch script.Type {
+	case ScriptPubKey:
+		return signed(script.KeyHash)
+	case ScriptAll, ScriptAny, ScriptNOfK:
+		satisfied := uint64(0)
+		for _, sub := range script.Scripts {
+			if sub.satisfied(signed, validityStart, ttl) {
+				satisfied++
+			}
+		}
+		switch script.Type {
+		case ScriptAll:
+			return satisfied == uint64(len(script.Scripts))
+		case ScriptAny:
+			return satisfied > 0
+		}
+		return satisfied >= script.N
+	case ScriptInvalidBefore:
+		return validityStart != 0 && script.Slot <= validityStart
+	case ScriptInvalidHereafter:
+		return ttl != 0 && ttl <= script.Slot
+	}
+	return false
+}
+
+// MarshalCBOR implements cbor.Marshaler.
+func (script NativeScript) MarshalCBOR() ([]byte, error) {
+	switch script.Type {
+	case ScriptPubKey:
+		if len(script.KeyHash) != 28 {
+			return nil, fmt.Errorf("invalid native script key hash length %v", len(script.KeyHash))
+		}
+		return cbor.Marshal([]interface{}{script.Type, script.KeyHash})
+	case ScriptAll, ScriptAny:
+		return cbor.Marshal([]interface{}{script.Type, script.subScripts()})
+	case ScriptNOfK:
+		return cbor.Marshal([]interface{}{script.Type, script.N, script.subScripts()})
+	case ScriptInvalidBefore, ScriptInvalidHereafter:
+		return cbor.Marshal([]interface{}{script.Type, script.Slot})
+	}
+	return nil, fmt.Errorf("unknown native script type %v", uint64(script.Type))
+}
+
+// subScripts returns the scripts, encoded as an empty array if nil.
+func (script NativeScript) subScripts() []NativeScript {
+	if script.Scripts == nil {
+		return []NativeScript{}
+	}
+	return script.Scripts
+}
+
+// UnmarshalCBOR implements cbor.Unmarshaler.
+func (script *NativeScript) UnmarshalCBOR(data []byte) error {
+	fields := []cbor.RawMessage{}
+	if err := cbor.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if len(fields) < 2 {
+		return fmt.Errorf("invalid native script length %v", len(fields))
+	}
+	decoded := NativeScript{}
+	if err := cbor.Unmarshal(fields[0], &decoded.Type); err != nil {
+		return err
+	}
+
+	want := 2
+	var err error
+	switch decoded.Type {
+	case ScriptPubKey:
+		err = cbor.Unmarshal(fields[1], &decoded.KeyHash)
+		if err == nil && len(decoded.KeyHash) != 28 {
+			err = fmt.Errorf("invalid native script key hash length %v", len(decoded.KeyHash))
+		}
+	case ScriptAll, ScriptAny:
+		err = cbor.Unmarshal(fields[1], &decoded.Scripts)
+	case ScriptNOfK:
+		want = 3
+		if len(fields) == want {
+			err = cbor.Unmarshal(fields[1], &decoded.N)
+			if err == nil {
+				err = cbor.Unmarshal(fields[2], &decoded.Scripts)
+			}
+		}
+	case ScriptInvalidBefore, ScriptInvalidHereafter:
+		err = cbor.Unmarshal(fields[1], &decoded.Slot)
+	default:
+		return fmt.Errorf("unknown native script type %v", uint64(decoded.Type))
+	}
+	if err != nil {
+		return err
+	}
+	if len(fields) != want {
+		return fmt.Errorf("invalid native script length %v", len(fields))
+	}
+	*script = decoded
+	return nil
+}
@@ -0,0 +1,164 @@
+package cardano
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+func TestNativeScript_CBOR(t *testing.T) {
+	keyHash := bytes.Repeat([]byte{0x01}, 28)
+	testcases := []struct {
+		name    string
+		script  NativeScript
+		cborHex string
+	}{
+		{
+			name:    "pubkey",
+			script:  NewScriptPubKey(keyHash),
+			cborHex: "8200581c" + strings.Repeat("01", 28),
+		},
+		{
+			name:    "all",
+			script:  NewScriptAll(NewScriptPubKey(keyHash), NewScriptInvalidHereafter(1000)),
+			cborHex: "8201828200581c" + strings.Repeat("01", 28) + "82051903e8",
+		},
+		{
+			name:    "n of k",
+			script:  NativeScript{Type: ScriptNOfK, N: 1, Scripts: []NativeScript{NewScriptPubKey(keyHash)}},
+			cborHex: "830301818200581c" + strings.Repeat("01", 28),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			encoded, err := cbor.Marshal(tc.script)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := hex.EncodeToString(encoded), tc.cborHex; got != want {
+				t.Errorf("got %v want %v", got, want)
+			}
+
+			decoded := NativeScript{}
+			if err := cbor.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded, tc.script) {
+				t.Errorf("got script %+v want %+v", decoded, tc.script)
+			}
+		})
+	}
+
+	invalid := NewScriptPubKey([]byte{0x01})
+	if err := invalid.Validate(); err == nil {
+		t.Error("expected an invalid key hash error")
+	}
+	if _, err := invalid.PolicyID(); err == nil {
+		t.Error("expected an error hashing a script with an invalid key hash")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Hash to panic on an invalid script")
+		}
+	}()
+	invalid.Hash()
+}
+
+func TestNewTimeLockedMintPolicy(t *testing.T) {
+	keyHash := bytes.Repeat([]byte{0x01}, 28)
+	script := NewTimeLockedMintPolicy(keyHash, 50000000)
+
+	want := NewScriptAll(NewScriptPubKey(keyHash), NewScriptInvalidHereafter(50000000))
+	if !reflect.DeepEqual(script, want) {
+		t.Errorf("got script %+v want %+v", script, want)
+	}
+	encoded, err := cbor.Marshal(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := hex.EncodeToString(encoded), "8201828200581c"+strings.Repeat("01", 28)+"82051a02faf080"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	policy, err := script.PolicyID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := policy, PolicyID("fac1876f7c2155871984a525c167a9b1f46b78a6a39dfe8f658f11dd"); got != want {
+		t.Errorf("got policy %v want %v", got, want)
+	}
+}
+
+func TestNativeScript_IsSatisfiedBy(t *testing.T) {
+	keys := []crypto.ExtendedSigningKey{
+		crypto.NewExtendedSigningKey([]byte("alice"), "foo"),
+		crypto.NewExtendedSigningKey([]byte("bob"), "foo"),
+		crypto.NewExtendedSigningKey([]byte("carol"), "foo"),
+	}
+	witnesses := make([]VKeyWitness, len(keys))
+	scripts := make([]NativeScript, len(keys))
+	for i, key := range keys {
+		witnesses[i] = VKeyWitness{VKey: key.VerificationKey()}
+		scripts[i] = NewScriptPubKey(keyHash(key.ExtendedVerificationKey()))
+	}
+	twoOfThree := NativeScript{Type: ScriptNOfK, N: 2, Scripts: scripts}
+
+	testcases := []struct {
+		name          string
+		script        NativeScript
+		witnesses     []VKeyWitness
+		validityStart uint64
+		ttl           uint64
+		want          bool
+	}{
+		{name: "2 of 3", script: twoOfThree, witnesses: witnesses[1:], want: true},
+		{name: "1 of 3", script: twoOfThree, witnesses: witnesses[:1], want: false},
+		{name: "any", script: NativeScript{Type: ScriptAny, Scripts: scripts}, witnesses: witnesses[2:], want: true},
+		{name: "all missing key", script: NewScriptAll(scripts...), witnesses: witnesses[:2], want: false},
+		{
+			name:      "before deadline",
+			script:    NewScriptAll(scripts[0], NewScriptInvalidHereafter(1000)),
+			witnesses: witnesses[:1],
+			ttl:       900,
+			want:      true,
+		},
+		{
+			name:      "after deadline",
+			script:    NewScriptAll(scripts[0], NewScriptInvalidHereafter(1000)),
+			witnesses: witnesses[:1],
+			ttl:       1001,
+			want:      false,
+		},
+		{
+			name:      "unbounded ttl",
+			script:    NewScriptInvalidHereafter(1000),
+			witnesses: witnesses,
+			want:      false,
+		},
+		{
+			name:          "after start",
+			script:        NativeScript{Type: ScriptInvalidBefore, Slot: 500},
+			validityStart: 500,
+			want:          true,
+		},
+		{
+			name:          "before start",
+			script:        NativeScript{Type: ScriptInvalidBefore, Slot: 500},
+			validityStart: 499,
+			want:          false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.script.IsSatisfiedBy(tc.witnesses, tc.validityStart, tc.ttl); got != tc.want {
+				t.Errorf("got %v want %v", got, tc.want)
+			}
+		})
+	}
+}
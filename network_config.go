@@ -0,0 +1,175 @@
+package cardano
+
+import (
+	"fmt"
+	"time"
+)
+
+// NetworkConfig holds the genesis and protocol parameters of a network, so
+// transactions can be built for networks other than mainnet.
+type NetworkConfig struct {
+	Network Network
+
+	// StartTime is the time of StartSlot, the first slot of StartEpoch with
+	// SlotLength.
+	StartTime  time.Time
+	StartSlot  uint64
+	StartEpoch uint64
+	SlotLength time.Duration
+
+	// EpochLength is the number of slots in an epoch.
+	EpochLength uint64
+
+	// StabilityWindow is the number of slots (3k/f) after which the
+	// conversion between slots and time isn't guaranteed.
+	StabilityWindow uint64
+
+	// ShelleySlot is the first slot of the Shelley era, the slots before it
+	// last ByronSlotLength. Without ByronSlotLength the times before
+	// StartTime are clamped to StartSlot.
+	ShelleySlot     uint64
+	ByronSlotLength time.Duration
+
+	Protocol ProtocolParams
+}
+
+// MainnetConfig is the configuration of the mainnet Shelley era.
+var MainnetConfig = NetworkConfig{
+	Network:         Mainnet,
+	StartTime:       time.Unix(shelleyStartTimestamp, 0),
+	StartSlot:       shelleyStartSlot,
+	StartEpoch:      209,
+	SlotLength:      shelleySlotLength,
+	EpochLength:     432000,
+	StabilityWindow: uint64(maxTTLDuration / shelleySlotLength),
+	ShelleySlot:     4492800,
+	ByronSlotLength: 20 * time.Second,
+	Protocol:        ShelleyProtocol,
+}
+
+// PreprodConfig is the configuration of the preprod testnet Shelley era,
+// which started after 4 Byron epochs of 20 seconds slots.
+var PreprodConfig = NetworkConfig{
+	Network:         Testnet,
+	StartTime:       time.Date(2022, time.June, 21, 0, 0, 0, 0, time.UTC),
+	StartSlot:       86400,
+	StartEpoch:      4,
+	SlotLength:      time.Second,
+	EpochLength:     432000,
+	StabilityWindow: 129600,
+	ShelleySlot:     86400,
+	ByronSlotLength: 20 * time.Second,
+	Protocol:        ShelleyProtocol,
+}
+
+// PreviewConfig is the configuration of the preview testnet, Shelley from its
+// genesis.
+var PreviewConfig = NetworkConfig{
+	Network:         Testnet,
+	StartTime:       time.Date(2022, time.October, 25, 0, 0, 0, 0, time.UTC),
+	StartSlot:       0,
+	StartEpoch:      0,
+	SlotLength:      time.Second,
+	EpochLength:     86400,
+	StabilityWindow: 25920,
+	Protocol:        ShelleyProtocol,
+}
+
+// Validate checks that the time parameters are set, none of them defaults to
+// the mainnet ones.
+func (cfg NetworkConfig) Validate() error {
+	if cfg.StartTime.IsZero() {
+		return fmt.Errorf("missing network start time")
+	}
+	if cfg.SlotLength <= 0 {
+		return fmt.Errorf("invalid network slot length %v", cfg.SlotLength)
+	}
+	if cfg.EpochLength == 0 {
+		return fmt.Errorf("invalid network epoch length %v", cfg.EpochLength)
+	}
+	if cfg.StabilityWindow == 0 {
+		return fmt.Errorf("invalid network stability window %v", cfg.StabilityWindow)
+	}
+	return nil
+}
+
+// SlotAt returns the slot at time t. Times before StartTime are in the
+// Byron era if the config has one, otherwise they give the start slot.
+func (cfg NetworkConfig) SlotAt(t time.Time) uint64 {
+	elapsed := t.Sub(cfg.StartTime)
+	if elapsed >= 0 {
+		return cfg.StartSlot + uint64(elapsed/cfg.SlotLength)
+	}
+	if cfg.ByronSlotLength == 0 {
+		return cfg.StartSlot
+	}
+	if shelleyTime := cfg.shelleyTime(); !t.Before(shelleyTime) {
+		return cfg.ShelleySlot + uint64(t.Sub(shelleyTime)/cfg.SlotLength)
+	}
+	elapsed = t.Sub(cfg.byronTime())
+	if elapsed < 0 {
+		return 0
+	}
+	return uint64(elapsed / cfg.ByronSlotLength)
+}
+
+// TimeAt returns the start time of the slot. Slots before StartSlot are in
+// the Byron era if the config has one, otherwise they give the start time.
+func (cfg NetworkConfig) TimeAt(slot uint64) time.Time {
+	if slot >= cfg.StartSlot {
+		return cfg.StartTime.Add(time.Duration(slot-cfg.StartSlot) * cfg.SlotLength)
+	}
+	if cfg.ByronSlotLength == 0 {
+		return cfg.StartTime
+	}
+	if slot >= cfg.ShelleySlot {
+		return cfg.shelleyTime().Add(time.Duration(slot-cfg.ShelleySlot) * cfg.SlotLength)
+	}
+	return cfg.byronTime().Add(time.Duration(slot) * cfg.ByronSlotLength)
+}
+
+// shelleyTime returns the time of the first Shelley slot.
+func (cfg NetworkConfig) shelleyTime() time.Time {
+	return cfg.StartTime.Add(-time.Duration(cfg.StartSlot-cfg.ShelleySlot) * cfg.SlotLength)
+}
+
+// byronTime returns the time of the first Byron slot.
+func (cfg NetworkConfig) byronTime() time.Time {
+	return cfg.shelleyTime().Add(-time.Duration(cfg.ShelleySlot) * cfg.ByronSlotLength)
+}
+
+// TimeFromSlot returns the start time of the slot on the network of the
+// config, e.g. PreprodConfig or PreviewConfig for the testnets.
+func TimeFromSlot(slot uint64, cfg NetworkConfig) time.Time {
+	return cfg.TimeAt(slot)
+}
+
+// SlotFromTime returns the slot at time t on the network of the config.
+func SlotFromTime(t time.Time, cfg NetworkConfig) uint64 {
+	return cfg.SlotAt(t)
+}
+
+// TTLFromDuration returns the slot of the network reached after the duration d
+// from now, clamped to the stability window like TXBuilder.SetTTLIn.
+func TTLFromDuration(d time.Duration, cfg NetworkConfig) uint64 {
+	if d < 0 {
+		d = 0
+	}
+	if d > cfg.maxTTL() {
+		d = cfg.maxTTL()
+	}
+	return cfg.SlotAt(time.Now()) + uint64(d/cfg.SlotLength)
+}
+
+// Epoch returns the epoch of the slot, or the start epoch for slots before it.
+func (cfg NetworkConfig) Epoch(slot uint64) uint64 {
+	if slot < cfg.StartSlot {
+		return cfg.StartEpoch
+	}
+	return cfg.StartEpoch + (slot-cfg.StartSlot)/cfg.EpochLength
+}
+
+// maxTTL returns the stability window as a duration.
+func (cfg NetworkConfig) maxTTL() time.Duration {
+	return time.Duration(cfg.StabilityWindow) * cfg.SlotLength
+}
@@ -0,0 +1,185 @@
+package cardano
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+func TestNetworkConfig(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	cfg := NetworkConfig{
+		Network:         Testnet,
+		StartTime:       start,
+		StartSlot:       1000,
+		StartEpoch:      5,
+		SlotLength:      200 * time.Millisecond,
+		EpochLength:     3000,
+		StabilityWindow: 600,
+		Protocol: ProtocolParams{
+			MinimumUtxoValue: 500000,
+			KeyDeposit:       1000000,
+			MinFeeA:          10,
+			MinFeeB:          1000,
+			MaxTxSize:        8192,
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cfg.SlotAt(start.Add(time.Minute)), uint64(1300); got != want {
+		t.Errorf("got slot %v want %v", got, want)
+	}
+	if got, want := cfg.TimeAt(1300), start.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("got time %v want %v", got, want)
+	}
+	if got, want := cfg.Epoch(7000), uint64(7); got != want {
+		t.Errorf("got epoch %v want %v", got, want)
+	}
+
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(cfg.Network, NewKeyCredential(key.ExtendedVerificationKey()))
+	key = crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(cfg.Network, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	builder, err := NewTxBuilderWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder.AddUtxo(Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Amount:  2000000,
+	})
+	builder.AddOutput(receiver, 1000000)
+	builder.SetChangeAddress(payer)
+	builder.SetTip(NodeTip{Slot: 5000})
+	builder.SetTTLIn(time.Hour)
+	if err := builder.SignWith(mapResolver{payer: crypto.NewExtendedSigningKey([]byte("payer"), "foo")}); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The TTL is clamped to the stability window of 600 slots
+	if got, want := tx.Body.Ttl, uint64(5600); got != want {
+		t.Errorf("got ttl %v want %v", got, want)
+	}
+	if got, want := tx.Body.Fee, CalculateFee(&tx, cfg.Protocol); got < want || got > 2*want {
+		t.Errorf("got fee %v want about %v", got, want)
+	}
+	if got, want := len(tx.Body.Outputs), 2; got != want {
+		t.Fatalf("got %v outputs want %v", got, want)
+	}
+	if got, want := tx.Body.Outputs[0].Amount, 1000000-tx.Body.Fee; got != want {
+		t.Errorf("got change %v want %v", got, want)
+	}
+
+	body, err := TXBodyBuilder{TTL: 100, Network: &cfg}.Build(receiver, []Utxo{{TxId: TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"), Amount: 1200000}}, 1000000, payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The 200000 - fee change is below the network's min utxo value
+	if got, want := body.Fee, uint64(200000); got != want {
+		t.Errorf("got fee %v want %v", got, want)
+	}
+
+	if _, err := NewTxBuilderWithConfig(NetworkConfig{Protocol: cfg.Protocol}); err == nil {
+		t.Errorf("expected invalid config error")
+	}
+}
+
+func TestTestnetConfigs(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   NetworkConfig
+		time  time.Time
+		slot  uint64
+		epoch uint64
+	}{
+		{
+			name:  "preprod",
+			cfg:   PreprodConfig,
+			time:  time.Date(2022, time.June, 26, 0, 0, 0, 0, time.UTC),
+			slot:  518400,
+			epoch: 5,
+		},
+		{
+			name:  "preview",
+			cfg:   PreviewConfig,
+			time:  time.Date(2022, time.October, 26, 0, 0, 0, 0, time.UTC),
+			slot:  86400,
+			epoch: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.cfg.SlotAt(tt.time); got != tt.slot {
+				t.Errorf("got slot %v want %v", got, tt.slot)
+			}
+			if got := tt.cfg.TimeAt(tt.slot); !got.Equal(tt.time) {
+				t.Errorf("got time %v want %v", got, tt.time)
+			}
+			if got := tt.cfg.Epoch(tt.slot); got != tt.epoch {
+				t.Errorf("got epoch %v want %v", got, tt.epoch)
+			}
+		})
+	}
+}
+
+func TestTimeFromSlot(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  NetworkConfig
+		slot uint64
+		time time.Time
+	}{
+		{"mainnet byron genesis", MainnetConfig, 0, time.Unix(1506203091, 0)},
+		{"mainnet byron", MainnetConfig, 5, time.Unix(1506203191, 0)},
+		{"mainnet last byron slot", MainnetConfig, 4492799, time.Unix(1596059071, 0)},
+		{"mainnet first shelley slot", MainnetConfig, 4492800, time.Unix(1596059091, 0)},
+		{"mainnet shelley", MainnetConfig, 4924800, time.Unix(shelleyStartTimestamp, 0)},
+		{"preprod byron genesis", PreprodConfig, 0, time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{"preprod first shelley slot", PreprodConfig, 86400, time.Date(2022, time.June, 21, 0, 0, 0, 0, time.UTC)},
+		{"preview genesis", PreviewConfig, 0, time.Date(2022, time.October, 25, 0, 0, 0, 0, time.UTC)},
+		{"preview", PreviewConfig, 86400, time.Date(2022, time.October, 26, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TimeFromSlot(tt.slot, tt.cfg); !got.Equal(tt.time) {
+				t.Errorf("got time %v want %v", got, tt.time)
+			}
+			if got := SlotFromTime(tt.time, tt.cfg); got != tt.slot {
+				t.Errorf("got slot %v want %v", got, tt.slot)
+			}
+		})
+	}
+
+	// A time in the middle of a byron slot gives that slot
+	if got, want := SlotFromTime(time.Unix(1506203091+119, 0), MainnetConfig), uint64(5); got != want {
+		t.Errorf("got slot %v want %v", got, want)
+	}
+	if got, want := SlotFromTime(time.Unix(0, 0), MainnetConfig), uint64(0); got != want {
+		t.Errorf("got slot %v want %v", got, want)
+	}
+
+	// The testnets have their own genesis
+	date := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if SlotFromTime(date, PreprodConfig) == SlotFromTime(date, PreviewConfig) {
+		t.Errorf("got the same preprod and preview slot %v", SlotFromTime(date, PreviewConfig))
+	}
+
+	now := SlotFromTime(time.Now(), MainnetConfig)
+	if got := TTLFromDuration(time.Hour, MainnetConfig); got < now+3600 || got > now+3601 {
+		t.Errorf("got ttl %v want about %v", got, now+3600)
+	}
+	if got := TTLFromDuration(48*time.Hour, MainnetConfig); got > now+MainnetConfig.StabilityWindow+1 {
+		t.Errorf("got ttl %v want atmost %v", got, now+MainnetConfig.StabilityWindow)
+	}
+}
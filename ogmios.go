@@ -0,0 +1,259 @@
+package cardano
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+
+	"golang.org/x/net/websocket"
+)
+
+// ogmiosMinUtxoOutputSize is the size of the largest ada only output, a base
+// address and an 8 bytes amount, used to derive the min utxo value.
+const ogmiosMinUtxoOutputSize = 69
+
+// Ogmios queries and submits transactions to a node through an Ogmios server
+// using its JSON-RPC websocket API.
+type Ogmios struct {
+	url string
+}
+
+// NewOgmios returns an Ogmios connecting to url, e.g. ws://localhost:1337.
+func NewOgmios(url string) *Ogmios {
+	return &Ogmios{url: url}
+}
+
+type ogmiosRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type ogmiosResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *ogmiosError    `json:"error"`
+}
+
+type ogmiosError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func (err *ogmiosError) Error() string {
+	return fmt.Sprintf("ogmios error %v: %v", err.Code, err.Message)
+}
+
+type ogmiosLovelace struct {
+	Ada struct {
+		Lovelace uint64 `json:"lovelace"`
+	} `json:"ada"`
+}
+
+type ogmiosUtxo struct {
+	Transaction struct {
+		ID string `json:"id"`
+	} `json:"transaction"`
+	Index   uint64      `json:"index"`
+	Address Address     `json:"address"`
+	Value   ogmiosValue `json:"value"`
+}
+
+// ogmiosValue maps the policy ids, or ada, to the quantities by asset name, or
+// lovelace.
+type ogmiosValue map[string]map[string]uint64
+
+func (v ogmiosValue) value() (Value, error) {
+	value := Value{Coin: v["ada"]["lovelace"]}
+	for policy, assets := range v {
+		if policy == "ada" {
+			continue
+		}
+		if policyBytes, err := hex.DecodeString(policy); err != nil || len(policyBytes) != 28 {
+			return Value{}, fmt.Errorf("invalid policy id %v", policy)
+		}
+		for name, quantity := range assets {
+			nameBytes, err := hex.DecodeString(name)
+			if err != nil || len(nameBytes) > 32 {
+				return Value{}, fmt.Errorf("invalid asset name %v of policy %v", name, policy)
+			}
+			value.Assets.set(PolicyID(policy), AssetName(nameBytes), quantity)
+		}
+	}
+	value.Assets = value.normalizedAssets()
+	return value, nil
+}
+
+type ogmiosTip struct {
+	Slot uint64 `json:"slot"`
+	ID   string `json:"id"`
+}
+
+type ogmiosProtocolParams struct {
+	MinFeeCoefficient         uint64         `json:"minFeeCoefficient"`
+	MinFeeConstant            ogmiosLovelace `json:"minFeeConstant"`
+	MinUtxoDepositCoefficient uint64         `json:"minUtxoDepositCoefficient"`
+	MinUtxoDepositConstant    ogmiosLovelace `json:"minUtxoDepositConstant"`
+	StakeCredentialDeposit    ogmiosLovelace `json:"stakeCredentialDeposit"`
+	StakePoolDeposit          ogmiosLovelace `json:"stakePoolDeposit"`
+	MaxTransactionSize        struct {
+		Bytes uint64 `json:"bytes"`
+	} `json:"maxTransactionSize"`
+	MaxExecutionUnitsPerTransaction struct {
+		Memory uint64 `json:"memory"`
+		CPU    uint64 `json:"cpu"`
+	} `json:"maxExecutionUnitsPerTransaction"`
+	PlutusCostModels CostModels `json:"plutusCostModels"`
+}
+
+func (o *Ogmios) UTxOs(address Address) ([]Utxo, error) {
+	ogmiosUtxos := []ogmiosUtxo{}
+	params := map[string][]Address{"addresses": {address}}
+	if err := o.call("queryLedgerState/utxo", params, &ogmiosUtxos); err != nil {
+		return nil, err
+	}
+
+	utxos := make([]Utxo, len(ogmiosUtxos))
+	for i, utxo := range ogmiosUtxos {
+		value, err := utxo.Value.value()
+		if err != nil {
+			return nil, fmt.Errorf("invalid utxo %v#%v value: %w", utxo.Transaction.ID, utxo.Index, err)
+		}
+		utxos[i] = Utxo{
+			Address: utxo.Address,
+			TxId:    TransactionID(utxo.Transaction.ID),
+			Amount:  value.Coin,
+			Index:   utxo.Index,
+			Assets:  value.Assets,
+		}
+	}
+	return utxos, nil
+}
+
+func (o *Ogmios) QueryTip() (NodeTip, error) {
+	tip := ogmiosTip{}
+	if err := o.call("queryNetwork/tip", nil, &tip); err != nil {
+		return NodeTip{}, err
+	}
+	var block, epoch uint64
+	if err := o.call("queryNetwork/blockHeight", nil, &block); err != nil {
+		return NodeTip{}, err
+	}
+	if err := o.call("queryLedgerState/epoch", nil, &epoch); err != nil {
+		return NodeTip{}, err
+	}
+
+	return NodeTip{
+		Epoch: epoch,
+		Block: block,
+		Slot:  tip.Slot,
+	}, nil
+}
+
+// Tip returns the slot of the network tip.
+func (o *Ogmios) Tip() (uint64, error) {
+	return o.TipContext(context.Background())
+}
+
+// TipContext returns the slot of the network tip, the query stops when the
+// context is done.
+func (o *Ogmios) TipContext(ctx context.Context) (uint64, error) {
+	tip := ogmiosTip{}
+	if err := o.callContext(ctx, "queryNetwork/tip", nil, &tip); err != nil {
+		return 0, err
+	}
+	return tip.Slot, nil
+}
+
+// QueryProtocolParams returns the protocol parameters of the current epoch.
+//
+// Since Babbage the min utxo value depends on the size of the output, it is
+// set to the min value of the largest ada only output.
+func (o *Ogmios) QueryProtocolParams() (ProtocolParams, error) {
+	params := ogmiosProtocolParams{}
+	if err := o.call("queryLedgerState/protocolParameters", nil, &params); err != nil {
+		return ProtocolParams{}, err
+	}
+
+	minUtxoValue := params.MinUtxoDepositConstant.Ada.Lovelace +
+		params.MinUtxoDepositCoefficient*(160+ogmiosMinUtxoOutputSize)
+	return ProtocolParams{
+		MinimumUtxoValue: minUtxoValue,
+		PoolDeposit:      params.StakePoolDeposit.Ada.Lovelace,
+		KeyDeposit:       params.StakeCredentialDeposit.Ada.Lovelace,
+		MinFeeA:          params.MinFeeCoefficient,
+		MinFeeB:          params.MinFeeConstant.Ada.Lovelace,
+		MaxTxSize:        params.MaxTransactionSize.Bytes,
+		CoinsPerUTxOByte: params.MinUtxoDepositCoefficient,
+		CostModels:       params.PlutusCostModels,
+		MaxTxExUnits: ExUnits{
+			Mem:   params.MaxExecutionUnitsPerTransaction.Memory,
+			Steps: params.MaxExecutionUnitsPerTransaction.CPU,
+		},
+	}, nil
+}
+
+func (o *Ogmios) SubmitTx(tx *Transaction) (TransactionID, error) {
+	params := map[string]map[string]string{"transaction": {"cbor": tx.CborHex()}}
+	result := struct {
+		Transaction struct {
+			ID TransactionID `json:"id"`
+		} `json:"transaction"`
+	}{}
+	if err := o.call("submitTransaction", params, &result); err != nil {
+		return "", err
+	}
+	if got, want := result.Transaction.ID, tx.ID(); got != want {
+		return "", fmt.Errorf("submitted transaction id mismatch, got %v want %v", got, want)
+	}
+	return result.Transaction.ID, nil
+}
+
+// call sends a JSON-RPC request on a new connection and decodes its result.
+func (o *Ogmios) call(method string, params interface{}, result interface{}) error {
+	return o.callContext(context.Background(), method, params, result)
+}
+
+// callContext is call closing the connection when the context is done.
+func (o *Ogmios) callContext(ctx context.Context, method string, params interface{}, result interface{}) error {
+	config, err := websocket.NewConfig(o.url, "http://localhost/")
+	if err != nil {
+		return err
+	}
+	config.Dialer = &net.Dialer{Cancel: ctx.Done()}
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	request := ogmiosRequest{JSONRPC: "2.0", Method: method, Params: params}
+	if err := websocket.JSON.Send(conn, request); err != nil {
+		return err
+	}
+	response := ogmiosResponse{}
+	if err := websocket.JSON.Receive(conn, &response); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	return json.Unmarshal(response.Result, result)
+}
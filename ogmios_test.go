@@ -0,0 +1,105 @@
+package cardano
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/websocket"
+)
+
+func newOgmiosTestServer(t *testing.T) *httptest.Server {
+	data, err := ioutil.ReadFile("tests/ogmios-responses.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &responses); err != nil {
+		t.Fatal(err)
+	}
+
+	return httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		request := ogmiosRequest{}
+		if err := websocket.JSON.Receive(conn, &request); err != nil {
+			t.Error(err)
+			return
+		}
+		response, ok := responses[request.Method]
+		if !ok {
+			t.Errorf("unexpected method %v", request.Method)
+			return
+		}
+		if err := websocket.Message.Send(conn, string(response)); err != nil {
+			t.Error(err)
+		}
+	}))
+}
+
+func TestOgmios(t *testing.T) {
+	server := newOgmiosTestServer(t)
+	defer server.Close()
+	ogmios := NewOgmios("ws" + strings.TrimPrefix(server.URL, "http"))
+
+	tip, err := ogmios.QueryTip()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (NodeTip{Epoch: 93, Block: 1665927, Slot: 39916796}); tip != want {
+		t.Errorf("got %v want %v", tip, want)
+	}
+
+	address := Address("addr_test1vqgjd0t02q9yglcjwdc8dht9tz6gkfpqqm7evs5csrklakcqmwv40")
+	utxos, err := ogmios.UTxOs(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(utxos), 2; got != want {
+		t.Fatalf("got %v utxos want %v", got, want)
+	}
+	want := Utxo{
+		Address: address,
+		TxId:    "a3d2c16e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4",
+		Amount:  1500000,
+		Index:   1,
+		Assets:  MultiAsset{"919d4c2c9455016289341b1a14dedf697687af31751170d56a31466e": {"tBTC": 4}},
+	}
+	if !reflect.DeepEqual(utxos[1], want) {
+		t.Errorf("got %v want %v", utxos[1], want)
+	}
+
+	params, err := ogmios.QueryProtocolParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.MinFeeA != 44 || params.MinFeeB != 155381 || params.MaxTxSize != 16384 {
+		t.Errorf("got fee params (%v, %v, %v) want (44, 155381, 16384)", params.MinFeeA, params.MinFeeB, params.MaxTxSize)
+	}
+	if got, want := params.KeyDeposit, uint64(2000000); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := params.MinimumUtxoValue, uint64(4310*(160+69)); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := len(params.CostModels[PlutusV2]), 175; got != want {
+		t.Errorf("got %v PlutusV2 costs want %v", got, want)
+	}
+	if got, want := params.MaxTxExUnits, (ExUnits{Mem: 14000000, Steps: 10000000000}); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	tx := Transaction{Body: TransactionBody{
+		Inputs:  []TransactionInput{{ID: TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1").Bytes()}},
+		Outputs: []TransactionOutput{{Address: address.Bytes(), Amount: 9600000}},
+		Fee:     200000,
+		Ttl:     39920000,
+	}}
+	_, err = ogmios.SubmitTx(&tx)
+	var ogmiosErr *ogmiosError
+	if !errors.As(err, &ogmiosErr) || ogmiosErr.Code != 3117 {
+		t.Errorf("got error %v want unknown utxo error", err)
+	}
+}
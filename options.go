@@ -22,8 +22,54 @@ func WithSocket(socketPath string) Options {
 	})
 }
 
-func WithNode(node cardanoNode) Options {
+func WithNode(node Node) Options {
 	return optionFunc(func(client *Client) {
 		client.node = node
 	})
 }
+
+// WithNetworkGuard refuses to submit transactions with outputs to addresses
+// of another network than the given one.
+func WithNetworkGuard(network Network) Options {
+	return optionFunc(func(client *Client) {
+		client.network = &network
+	})
+}
+
+// BuildOption configures the transactions built by SimplePayment, BuildSplit,
+// BuildSweep and BuildExitStaking.
+type BuildOption interface {
+	apply(*TXBuilder)
+}
+
+type buildOptionFunc func(*TXBuilder)
+
+func (f buildOptionFunc) apply(builder *TXBuilder) {
+	f(builder)
+}
+
+// WithTTL sets the ttl slot of the transaction.
+func WithTTL(ttl uint64) BuildOption {
+	return buildOptionFunc(func(builder *TXBuilder) {
+		builder.SetTtl(ttl)
+	})
+}
+
+// WithNetworkConfig sets the network whose slots time the default ttl,
+// mainnet otherwise.
+func WithNetworkConfig(cfg NetworkConfig) BuildOption {
+	return buildOptionFunc(func(builder *TXBuilder) {
+		builder.config = &cfg
+	})
+}
+
+// applyBuildOptions applies the options to the builder, the ttl defaults to
+// slotMargin seconds from now with SetTTLIn.
+func applyBuildOptions(builder *TXBuilder, opts []BuildOption) {
+	for _, opt := range opts {
+		opt.apply(builder)
+	}
+	if builder.ttl == 0 {
+		builder.SetTTLIn(slotMargin * shelleySlotLength)
+	}
+}
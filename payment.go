@@ -0,0 +1,32 @@
+package cardano
+
+import (
+	"fmt"
+
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+// SimplePayment builds a transaction sending amount to the receiver from the
+// inputs, all owned by key, with the change sent to the change address. The
+// transaction is balanced and signed, ready to be submitted. The ttl defaults
+// to 20 minutes from now, see BuildOption.
+func SimplePayment(receiver Address, amount uint64, inputs []Utxo, change Address, key crypto.ExtendedSigningKey, protocol ProtocolParams, opts ...BuildOption) (*Transaction, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no inputs to pay from")
+	}
+
+	builder := NewTxBuilder(protocol)
+	for _, utxo := range inputs {
+		builder.AddInput(key.ExtendedVerificationKey(), utxo.TxId, utxo.Index, utxo.Amount)
+	}
+	builder.AddOutput(receiver, amount)
+	builder.SetChangeAddress(change)
+	applyBuildOptions(builder, opts)
+	builder.Sign(key)
+
+	tx, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
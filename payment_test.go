@@ -0,0 +1,76 @@
+package cardano
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+func TestSimplePayment(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	receiverKey := crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(receiverKey.ExtendedVerificationKey()))
+
+	inputs := testUtxos(3000000, 4000000)
+	for i := range inputs {
+		inputs[i].Address = payer
+	}
+	tx, err := SimplePayment(receiver, 5000000, inputs, payer, key, ShelleyProtocol, WithTTL(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeTransaction(tx.CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.VerifySignatures(); err != nil {
+		t.Errorf("VerifySignatures() error = %v", err)
+	}
+	if got, want := len(decoded.WitnessSet.VKeyWitnessSet), 1; got != want {
+		t.Errorf("got %v witnesses want %v", got, want)
+	}
+	if got, want := len(decoded.Body.Outputs), 2; got != want {
+		t.Fatalf("got %v outputs want %v", got, want)
+	}
+	change, err := decoded.ChangeUtxo(payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := change.Amount+decoded.Body.Fee+5000000, sumUtxos(inputs); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := decoded.Body.Outputs[1].Address, receiver.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("got receiver %x want %x", got, want)
+	}
+	if got, want := decoded.Body.Ttl, uint64(1000); got != want {
+		t.Errorf("got ttl %v want %v", got, want)
+	}
+
+	if _, err := SimplePayment(receiver, 8000000, inputs, payer, key, ShelleyProtocol); !errors.Is(err, ErrInsufficientInput) {
+		t.Errorf("got error %v want %v", err, ErrInsufficientInput)
+	}
+}
+
+func TestSimplePayment_DefaultTTL(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	inputs := testUtxos(3000000)
+	for i := range inputs {
+		inputs[i].Address = payer
+	}
+	before := PreviewConfig.SlotAt(time.Now())
+	tx, err := SimplePayment(payer, 1000000, inputs, payer, key, ShelleyProtocol, WithNetworkConfig(PreviewConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := PreviewConfig.SlotAt(time.Now())
+	if got := tx.Body.Ttl; got < before+slotMargin || got > after+slotMargin {
+		t.Errorf("got ttl %v want between %v and %v", got, before+slotMargin, after+slotMargin)
+	}
+}
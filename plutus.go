@@ -0,0 +1,332 @@
+package cardano
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/fxamacker/cbor/v2"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Language is the version of a Plutus script language.
+type Language uint64
+
+const (
+	PlutusV1 Language = 0
+	PlutusV2 Language = 1
+	PlutusV3 Language = 2
+)
+
+// MarshalText implements encoding.TextMarshaler.
+func (lang Language) MarshalText() ([]byte, error) {
+	switch lang {
+	case PlutusV1:
+		return []byte("PlutusV1"), nil
+	case PlutusV2:
+		return []byte("PlutusV2"), nil
+	case PlutusV3:
+		return []byte("PlutusV3"), nil
+	}
+	return nil, fmt.Errorf("unknown plutus language %v", uint64(lang))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (lang *Language) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "PlutusV1", "PlutusScriptV1", "plutus:v1":
+		*lang = PlutusV1
+	case "PlutusV2", "PlutusScriptV2", "plutus:v2":
+		*lang = PlutusV2
+	case "PlutusV3", "PlutusScriptV3", "plutus:v3":
+		*lang = PlutusV3
+	default:
+		return fmt.Errorf("unknown plutus language %v", string(text))
+	}
+	return nil
+}
+
+// PlutusScript is a Plutus script of a language, attached to the witness set
+// of the transactions spending its outputs.
+type PlutusScript struct {
+	Language Language
+	Script   []byte // serialized script, as found in the witness set
+}
+
+// Hash returns the script hash of the address locking funds to the script.
+func (script PlutusScript) Hash() ([]byte, error) {
+	if _, err := script.Language.MarshalText(); err != nil {
+		return nil, err
+	}
+	hash, err := blake2b.New(224/8, nil)
+	if err != nil {
+		return nil, err
+	}
+	hash.Write([]byte{byte(script.Language) + 1}) // plutus script tag
+	hash.Write(script.Script)
+	return hash.Sum(nil), nil
+}
+
+// CostModels maps each Plutus language to its cost model parameters.
+type CostModels map[Language][]int64
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// Each cost model can either be a list of costs or, as emitted by older nodes,
+// an object mapping parameter names to costs which are then ordered by name.
+func (cm *CostModels) UnmarshalJSON(data []byte) error {
+	raw := map[Language]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	costModels := CostModels{}
+	for lang, rawCosts := range raw {
+		costs := []int64{}
+		if err := json.Unmarshal(rawCosts, &costs); err == nil {
+			costModels[lang] = costs
+			continue
+		}
+		namedCosts := map[string]int64{}
+		if err := json.Unmarshal(rawCosts, &namedCosts); err != nil {
+			return fmt.Errorf("invalid cost model for %v: %v", lang, err)
+		}
+		names := make([]string, 0, len(namedCosts))
+		for name := range namedCosts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			costs = append(costs, namedCosts[name])
+		}
+		costModels[lang] = costs
+	}
+	*cm = costModels
+	return nil
+}
+
+// forLanguages returns the cost models of the languages, an error is returned
+// if one of them is missing.
+func (cm CostModels) forLanguages(languages []Language) (CostModels, error) {
+	selected := CostModels{}
+	for _, lang := range languages {
+		costs, ok := cm[lang]
+		if !ok {
+			return nil, fmt.Errorf("missing cost model for language %v", uint64(lang))
+		}
+		selected[lang] = costs
+	}
+	return selected, nil
+}
+
+// RedeemerTag indicates what a redeemer is used for.
+type RedeemerTag uint64
+
+const (
+	RedeemerTagSpend  RedeemerTag = 0
+	RedeemerTagMint   RedeemerTag = 1
+	RedeemerTagCert   RedeemerTag = 2
+	RedeemerTagReward RedeemerTag = 3
+)
+
+// ExUnits are the execution units (memory and cpu steps) of a script.
+type ExUnits struct {
+	_     struct{} `cbor:",toarray"`
+	Mem   uint64   `json:"memory"`
+	Steps uint64   `json:"steps"`
+}
+
+// Redeemer is the argument passed to a Plutus script, pointing to the item
+// being validated through its tag and index.
+type Redeemer struct {
+	_       struct{} `cbor:",toarray"`
+	Tag     RedeemerTag
+	Index   uint64
+	Data    cbor.RawMessage // plutus_data
+	ExUnits ExUnits
+}
+
+// TotalExUnits returns the sum of the execution units of all the redeemers.
+func (tx *Transaction) TotalExUnits() (mem, steps uint64) {
+	for _, redeemer := range tx.WitnessSet.Redeemers {
+		mem += redeemer.ExUnits.Mem
+		steps += redeemer.ExUnits.Steps
+	}
+	return mem, steps
+}
+
+// ValidateExUnits checks that the redeemers' execution units fit in the
+// protocol's per transaction budget. A zero MaxTxExUnits is not enforced.
+func (tx *Transaction) ValidateExUnits(protocol ProtocolParams) error {
+	if protocol.MaxTxExUnits == (ExUnits{}) {
+		return nil
+	}
+	mem, steps := tx.TotalExUnits()
+	if mem > protocol.MaxTxExUnits.Mem {
+		return fmt.Errorf("transaction memory units exceed the limit, got %v want atmost %v", mem, protocol.MaxTxExUnits.Mem)
+	}
+	if steps > protocol.MaxTxExUnits.Steps {
+		return fmt.Errorf("transaction step units exceed the limit, got %v want atmost %v", steps, protocol.MaxTxExUnits.Steps)
+	}
+	return nil
+}
+
+// ScriptDataHash computes the script integrity hash of a transaction from its
+// redeemers, datums and the cost models of the languages used by its scripts.
+// It returns nil if there are neither redeemers nor datums.
+func ScriptDataHash(redeemers []Redeemer, datums []cbor.RawMessage, costModels CostModels) ([]byte, error) {
+	if len(redeemers) == 0 && len(datums) == 0 {
+		return nil, nil
+	}
+
+	redeemersBytes, err := cbor.Marshal(redeemers)
+	if err != nil {
+		return nil, err
+	}
+	if len(redeemers) == 0 {
+		redeemersBytes = []byte{0x80}
+		// Without redeemers no script is run, the language views are empty
+		costModels = nil
+	}
+
+	var datumsBytes []byte
+	if len(datums) != 0 {
+		datumsBytes, err = cbor.Marshal(datums)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	languageViews, err := costModels.languageViews()
+	if err != nil {
+		return nil, err
+	}
+
+	data := append(append(redeemersBytes, datumsBytes...), languageViews...)
+	hash := blake2b.Sum256(data)
+	return hash[:], nil
+}
+
+// VerifyScriptDataHash recomputes the script data hash from the transaction's
+// redeemers and datums and checks that it matches the one committed in the body.
+// Only the cost models of the languages of the scripts attached to the witness
+// set are used, all of them are used if no script is attached.
+func (tx *Transaction) VerifyScriptDataHash(costModels CostModels) error {
+	if languages := tx.WitnessSet.plutusLanguages(); len(languages) != 0 {
+		var err error
+		if costModels, err = costModels.forLanguages(languages); err != nil {
+			return err
+		}
+	}
+	want, err := ScriptDataHash(tx.WitnessSet.Redeemers, tx.WitnessSet.PlutusData, costModels)
+	if err != nil {
+		return err
+	}
+	if got := tx.Body.ScriptDataHash; !bytes.Equal(got, want) {
+		return fmt.Errorf("script data hash mismatch, got %x want %x", got, want)
+	}
+	return nil
+}
+
+// languageViews encodes the cost models as the canonical map of language views
+// used to compute the script data hash.
+func (cm CostModels) languageViews() ([]byte, error) {
+	type view struct {
+		key   []byte
+		value []byte
+	}
+	views := []view{}
+	for lang, costs := range cm {
+		var key, value []byte
+		var err error
+		switch lang {
+		case PlutusV1:
+			// PlutusV1 keeps the encoding of the original Alonzo implementation:
+			// the language and the indefinite list of costs are double serialized.
+			key, err = cbor.Marshal([]byte{0x00})
+			if err != nil {
+				return nil, err
+			}
+			costsBytes := []byte{0x9f}
+			for _, cost := range costs {
+				costBytes, err := cbor.Marshal(cost)
+				if err != nil {
+					return nil, err
+				}
+				costsBytes = append(costsBytes, costBytes...)
+			}
+			costsBytes = append(costsBytes, 0xff)
+			value, err = cbor.Marshal(costsBytes)
+		default:
+			key, err = cbor.Marshal(uint64(lang))
+			if err != nil {
+				return nil, err
+			}
+			value, err = cbor.Marshal(costs)
+		}
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, view{key: key, value: value})
+	}
+
+	// Canonical CBOR map ordering: shorter keys first, then bytewise
+	sort.Slice(views, func(i, j int) bool {
+		if len(views[i].key) != len(views[j].key) {
+			return len(views[i].key) < len(views[j].key)
+		}
+		return bytes.Compare(views[i].key, views[j].key) < 0
+	})
+
+	if len(views) > 23 {
+		return nil, fmt.Errorf("too many cost models %v", len(views))
+	}
+	out := []byte{0xa0 | byte(len(views))}
+	for _, v := range views {
+		out = append(out, v.key...)
+		out = append(out, v.value...)
+	}
+	return out, nil
+}
+
+// plutusLanguages returns the languages of the Plutus scripts of the witness set.
+func (ws *TransactionWitnessSet) plutusLanguages() []Language {
+	var languages []Language
+	if len(ws.PlutusV1Scripts) != 0 {
+		languages = append(languages, PlutusV1)
+	}
+	if len(ws.PlutusV2Scripts) != 0 {
+		languages = append(languages, PlutusV2)
+	}
+	if len(ws.PlutusV3Scripts) != 0 {
+		languages = append(languages, PlutusV3)
+	}
+	return languages
+}
+
+// plutusLanguages returns the distinct languages of the scripts.
+func plutusLanguages(scripts []PlutusScript) []Language {
+	var languages []Language
+	seen := map[Language]bool{}
+	for _, script := range scripts {
+		if !seen[script.Language] {
+			seen[script.Language] = true
+			languages = append(languages, script.Language)
+		}
+	}
+	return languages
+}
+
+// addPlutusScripts attaches the scripts to the witness set by language.
+func (ws *TransactionWitnessSet) addPlutusScripts(scripts []PlutusScript) {
+	for _, script := range scripts {
+		switch script.Language {
+		case PlutusV1:
+			ws.PlutusV1Scripts = append(ws.PlutusV1Scripts, script.Script)
+		case PlutusV2:
+			ws.PlutusV2Scripts = append(ws.PlutusV2Scripts, script.Script)
+		case PlutusV3:
+			ws.PlutusV3Scripts = append(ws.PlutusV3Scripts, script.Script)
+		}
+	}
+}
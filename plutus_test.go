@@ -0,0 +1,167 @@
+package cardano
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func TestCostModels_languageViews(t *testing.T) {
+	costModels := CostModels{
+		PlutusV1: {1, 2},
+		PlutusV2: {3},
+	}
+	got, err := costModels.languageViews()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := hex.DecodeString("a2018103410044" + "9f0102ff")
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x want %x", got, want)
+	}
+}
+
+func TestTransaction_VerifyScriptDataHash(t *testing.T) {
+	costModels := CostModels{PlutusV2: {205665, 812, 1, 1, 1000, 571, 0, 1}}
+	datum := cbor.RawMessage{0xd8, 0x79, 0x9f, 0x01, 0xff} // Constr 0 [1]
+	tx := &Transaction{
+		Body: TransactionBody{
+			Inputs:  []TransactionInput{{ID: make([]byte, 32), Index: 0}},
+			Outputs: []TransactionOutput{{Address: make([]byte, 29), Amount: 1000000}},
+			Fee:     200000,
+			Ttl:     100,
+		},
+		WitnessSet: TransactionWitnessSet{
+			PlutusData: []cbor.RawMessage{datum},
+			Redeemers: []Redeemer{{
+				Tag:     RedeemerTagSpend,
+				Index:   0,
+				Data:    cbor.RawMessage{0x80},
+				ExUnits: ExUnits{Mem: 1000, Steps: 2000},
+			}},
+		},
+	}
+	hash, err := ScriptDataHash(tx.WitnessSet.Redeemers, tx.WitnessSet.PlutusData, costModels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Body.ScriptDataHash = hash
+
+	decoded, err := DecodeTransaction(tx.CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.VerifyScriptDataHash(costModels); err != nil {
+		t.Errorf("VerifyScriptDataHash() error = %v", err)
+	}
+
+	decoded.WitnessSet.Redeemers[0].ExUnits.Mem++
+	if err := decoded.VerifyScriptDataHash(costModels); err == nil {
+		t.Errorf("expected mismatch error after tampering with the redeemers")
+	}
+}
+
+func TestScriptDataHash_NoScriptData(t *testing.T) {
+	hash, err := ScriptDataHash(nil, nil, CostModels{PlutusV1: {1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != nil {
+		t.Errorf("got %x want nil", hash)
+	}
+}
+
+func TestProtocolParams_CostModelsJSON(t *testing.T) {
+	data, err := ioutil.ReadFile("tests/protocol-parameters.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := ProtocolParams{}
+	if err := json.Unmarshal(data, &params); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := params.MinFeeA, uint64(44); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := params.KeyDeposit, uint64(2000000); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := params.MaxTxSize, uint64(16384); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := params.MaxFee(), uint64(44*16384+155381); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := len(params.CostModels[PlutusV1]), 166; got != want {
+		t.Errorf("got %v PlutusV1 costs want %v", got, want)
+	}
+	if got, want := len(params.CostModels[PlutusV2]), 175; got != want {
+		t.Errorf("got %v PlutusV2 costs want %v", got, want)
+	}
+	if got, want := params.CostModels[PlutusV2][0], int64(205665); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := params.MaxTxExUnits, (ExUnits{Mem: 14000000, Steps: 10000000000}); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := ProtocolParams{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, params) {
+		t.Errorf("round trip mismatch:\ngot: %v\nwant: %v", decoded, params)
+	}
+}
+
+func TestCostModels_UnmarshalJSONNamed(t *testing.T) {
+	data := []byte(`{"PlutusScriptV1": {"b-cpu": 2, "a-cpu": 1, "c-mem": 3}}`)
+	costModels := CostModels{}
+	if err := json.Unmarshal(data, &costModels); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := costModels[PlutusV1], []int64{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	if err := json.Unmarshal([]byte(`{"PlutusV9": [1]}`), &costModels); err == nil {
+		t.Errorf("expected unknown language error")
+	}
+}
+
+func TestTransaction_TotalExUnits(t *testing.T) {
+	tx := &Transaction{
+		WitnessSet: TransactionWitnessSet{
+			Redeemers: []Redeemer{
+				{Tag: RedeemerTagSpend, Index: 0, Data: cbor.RawMessage{0x80}, ExUnits: ExUnits{Mem: 4000000, Steps: 3000000000}},
+				{Tag: RedeemerTagMint, Index: 0, Data: cbor.RawMessage{0x80}, ExUnits: ExUnits{Mem: 6000000, Steps: 2000000000}},
+			},
+		},
+	}
+	mem, steps := tx.TotalExUnits()
+	if mem != 10000000 || steps != 5000000000 {
+		t.Errorf("got (%v, %v) want (10000000, 5000000000)", mem, steps)
+	}
+
+	protocol := ProtocolParams{MaxTxExUnits: ExUnits{Mem: 14000000, Steps: 10000000000}}
+	if err := tx.ValidateExUnits(protocol); err != nil {
+		t.Errorf("ValidateExUnits() error = %v", err)
+	}
+	protocol.MaxTxExUnits.Mem = 8000000
+	if err := tx.ValidateExUnits(protocol); err == nil {
+		t.Errorf("expected memory units limit error")
+	}
+	protocol.MaxTxExUnits = ExUnits{Mem: 14000000, Steps: 4000000000}
+	if err := tx.ValidateExUnits(protocol); err == nil {
+		t.Errorf("expected step units limit error")
+	}
+}
@@ -0,0 +1,36 @@
+package cardano
+
+// OutputAddresses returns the address of each transaction output.
+func (tx *Transaction) OutputAddresses() []Address {
+	utxos := tx.Produces()
+	addresses := make([]Address, len(utxos))
+	for i, utxo := range utxos {
+		addresses[i] = utxo.Address
+	}
+	return addresses
+}
+
+// ReusedAddresses returns how many times each of the addresses received funds
+// in the transactions, only for the addresses that received funds more than
+// once.
+func ReusedAddresses(addresses []Address, txs []*Transaction) map[Address]int {
+	received := map[Address]int{}
+	for _, addr := range addresses {
+		received[addr] = 0
+	}
+	for _, tx := range txs {
+		for _, addr := range tx.OutputAddresses() {
+			if count, ok := received[addr]; ok {
+				received[addr] = count + 1
+			}
+		}
+	}
+
+	reused := map[Address]int{}
+	for addr, count := range received {
+		if count > 1 {
+			reused[addr] = count
+		}
+	}
+	return reused
+}
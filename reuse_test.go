@@ -0,0 +1,45 @@
+package cardano
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+func TestReusedAddresses(t *testing.T) {
+	addresses := make([]Address, 3)
+	for i := range addresses {
+		key := crypto.NewExtendedSigningKey([]byte{byte(i)}, "foo")
+		addresses[i] = NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	}
+	key := crypto.NewExtendedSigningKey([]byte("external"), "foo")
+	external := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	newTx := func(receivers ...Address) *Transaction {
+		tx := &Transaction{Body: TransactionBody{
+			Inputs: []TransactionInput{{ID: make([]byte, 32), Index: 0}},
+			Fee:    170000,
+			Ttl:    100,
+		}}
+		for _, receiver := range receivers {
+			tx.Body.Outputs = append(tx.Body.Outputs, TransactionOutput{Address: receiver.Bytes(), Amount: 1000000})
+		}
+		return tx
+	}
+	txs := []*Transaction{
+		newTx(addresses[0], external),
+		newTx(addresses[1], external),
+		newTx(addresses[0], external),
+		newTx(addresses[2], addresses[0]),
+	}
+
+	if got := txs[3].OutputAddresses(); !reflect.DeepEqual(got, []Address{addresses[2], addresses[0]}) {
+		t.Errorf("got %v want %v", got, []Address{addresses[2], addresses[0]})
+	}
+
+	want := map[Address]int{addresses[0]: 3}
+	if got := ReusedAddresses(addresses, txs); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
@@ -0,0 +1,39 @@
+package cardano
+
+import (
+	"fmt"
+
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+// BuildSplit builds a transaction splitting the input, owned by key, into n
+// outputs of amountEach to the receiver, with the rest minus the fee sent to
+// the change address. It's the inverse of BuildSweep, e.g. to fund a faucet.
+// The ttl defaults to 20 minutes from now, see BuildOption.
+func BuildSplit(input Utxo, n int, amountEach uint64, to Address, change Address, key crypto.ExtendedSigningKey, protocol ProtocolParams, opts ...BuildOption) (*Transaction, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid number of outputs %v", n)
+	}
+	output := TransactionOutput{Address: to.Bytes(), Amount: amountEach}
+	if minUTXO := MinUTXO(output, protocol); amountEach < minUTXO {
+		return nil, fmt.Errorf("output amount %v is below the minimum utxo value %v", amountEach, minUTXO)
+	}
+	if amountEach > maxUint64/uint64(n) || amountEach*uint64(n) > input.Amount {
+		return nil, fmt.Errorf("input amount %v doesn't cover %v outputs of %v", input.Amount, n, amountEach)
+	}
+
+	builder := NewTxBuilder(protocol)
+	builder.AddInput(key.ExtendedVerificationKey(), input.TxId, input.Index, input.Amount)
+	for i := 0; i < n; i++ {
+		builder.AddOutput(to, amountEach)
+	}
+	builder.SetChangeAddress(change)
+	applyBuildOptions(builder, opts)
+	builder.Sign(key)
+
+	tx, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
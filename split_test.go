@@ -0,0 +1,62 @@
+package cardano
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+func TestBuildSplit(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("faucet"), "foo")
+	faucet := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	receiverKey := crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(receiverKey.ExtendedVerificationKey()))
+
+	input := testUtxos(20000000)[0]
+	input.Address = faucet
+	tx, err := BuildSplit(input, 5, 2000000, receiver, faucet, key, ShelleyProtocol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(tx.Body.Outputs), 6; got != want {
+		t.Fatalf("got %v outputs want %v", got, want)
+	}
+	for _, txOut := range tx.Body.Outputs[1:] {
+		if !bytes.Equal(txOut.Address, receiver.Bytes()) || txOut.Amount != 2000000 {
+			t.Errorf("got output %x %v want %x %v", txOut.Address, txOut.Amount, receiver.Bytes(), 2000000)
+		}
+	}
+	change, err := tx.ChangeUtxo(faucet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := change.Amount+tx.Body.Fee+5*2000000, input.Amount; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		t.Errorf("VerifySignatures() error = %v", err)
+	}
+
+	invalid := []struct {
+		n          int
+		amountEach uint64
+	}{
+		{0, 2000000},
+		{5, 100000},   // below the minimum utxo value
+		{10, 2000000}, // no amount left for the fee
+		{11, 2000000},
+	}
+	for _, tt := range invalid {
+		if _, err := BuildSplit(input, tt.n, tt.amountEach, receiver, faucet, key, ShelleyProtocol); err == nil {
+			t.Errorf("expected an error splitting into %v outputs of %v", tt.n, tt.amountEach)
+		}
+	}
+
+	// The minimum utxo value is per byte since Babbage
+	babbage := ShelleyProtocol
+	babbage.MinimumUtxoValue, babbage.CoinsPerUTxOByte = 0, 4310
+	if _, err := BuildSplit(input, 5, 800000, receiver, faucet, key, babbage); err == nil {
+		t.Errorf("expected an error below the minimum utxo value %v", MinUTXO(TransactionOutput{Address: receiver.Bytes()}, babbage))
+	}
+}
@@ -0,0 +1,42 @@
+package cardano
+
+import (
+	"fmt"
+
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+// BuildExitStaking builds a transaction leaving staking: it withdraws the
+// rewards of the stake key and deregisters it, the key deposit refund and the
+// rewards are sent with the inputs, all owned by paymentKey, to the change
+// address minus the fee. The rewards must be the whole reward balance, which
+// the ledger requires to be withdrawn before deregistering. The ttl defaults
+// to 20 minutes from now, see BuildOption.
+func BuildExitStaking(stakeKey, paymentKey crypto.ExtendedSigningKey, inputs []Utxo, rewards uint64, change Address, protocol ProtocolParams, opts ...BuildOption) (*Transaction, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no inputs to pay the fee from")
+	}
+	network := change.Network()
+	credential := NewKeyCredential(stakeKey.ExtendedVerificationKey())
+
+	builder := NewTxBuilder(protocol)
+	for _, utxo := range inputs {
+		builder.AddInput(paymentKey.ExtendedVerificationKey(), utxo.TxId, utxo.Index, utxo.Amount)
+	}
+	if rewards != 0 {
+		if err := builder.AddWithdrawal(NewRewardAddress(network, credential), rewards); err != nil {
+			return nil, err
+		}
+	}
+	builder.AddCertificate(DeregisterStake(credential))
+	builder.SetChangeAddress(change)
+	applyBuildOptions(builder, opts)
+	builder.Sign(paymentKey)
+	builder.Sign(stakeKey)
+
+	tx, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
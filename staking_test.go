@@ -0,0 +1,55 @@
+package cardano
+
+import (
+	"testing"
+
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+func TestBuildExitStaking(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	stakeKey := crypto.NewExtendedSigningKey([]byte("stake"), "foo")
+	rewardAddress := NewRewardAddress(Testnet, NewKeyCredential(stakeKey.ExtendedVerificationKey()))
+
+	inputs := testUtxos(3000000)
+	for i := range inputs {
+		inputs[i].Address = payer
+	}
+	rewards := uint64(1234567)
+	tx, err := BuildExitStaking(stakeKey, key, inputs, rewards, payer, ShelleyProtocol)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeTransaction(tx.CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.VerifySignatures(); err != nil {
+		t.Errorf("VerifySignatures() error = %v", err)
+	}
+	if got, want := len(decoded.WitnessSet.VKeyWitnessSet), 2; got != want {
+		t.Errorf("got %v witnesses want %v", got, want)
+	}
+	if got, want := len(decoded.Body.Certificates), 1; got != want {
+		t.Fatalf("got %v certificates want %v", got, want)
+	}
+	if decoded.Body.Certificates[0].StakeDeregistration == nil {
+		t.Errorf("got certificate %+v want a deregistration", decoded.Body.Certificates[0])
+	}
+	if got, want := decoded.Body.Withdrawals[rewardAddress], rewards; got != want {
+		t.Errorf("got withdrawal %v want %v", got, want)
+	}
+	// The inputs, the refund and the rewards pay the fee and the change
+	if got, want := decoded.Body.Outputs[0].Amount+decoded.Body.Fee, sumUtxos(inputs)+ShelleyProtocol.KeyDeposit+rewards; got != want {
+		t.Errorf("got change plus fee %v want %v", got, want)
+	}
+	if got, want := tx.Body.Fee, CalculateFee(tx, ShelleyProtocol); got < want {
+		t.Errorf("got fee %v want atleast %v", got, want)
+	}
+
+	if _, err := BuildExitStaking(stakeKey, key, nil, rewards, payer, ShelleyProtocol); err == nil {
+		t.Errorf("expected error without inputs")
+	}
+}
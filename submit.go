@@ -0,0 +1,53 @@
+package cardano
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// SubmitError is returned when the submit endpoint rejects a transaction, its
+// body is the node's rejection reason as returned.
+type SubmitError struct {
+	StatusCode int
+	Body       string
+}
+
+func (err *SubmitError) Error() string {
+	return fmt.Sprintf("transaction rejected with status %v: %v", err.StatusCode, err.Body)
+}
+
+// SubmitToURL submits the transaction cbor to a cardano-submit-api endpoint,
+// e.g. http://localhost:8090/api/submit/tx, and returns the id it answers.
+func SubmitToURL(ctx context.Context, url string, tx *Transaction) (TransactionID, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(tx.Bytes()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/cbor")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusAccepted {
+		return "", &SubmitError{StatusCode: res.StatusCode, Body: string(data)}
+	}
+
+	var id TransactionID
+	if err := json.Unmarshal(data, &id); err != nil {
+		return "", fmt.Errorf("invalid submit response %q: %v", data, err)
+	}
+	if want := tx.ID(); id != want {
+		return "", fmt.Errorf("submitted transaction id mismatch, got %v want %v", id, want)
+	}
+	return id, nil
+}
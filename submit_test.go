@@ -0,0 +1,73 @@
+package cardano
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSubmitToURL(t *testing.T) {
+	rejection := `{"tag":"TxSubmitFail","contents":{"tag":"TxCmdTxSubmitValidationError","contents":"FeeTooSmallUTxO"}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("Content-Type"), "application/cbor"; got != want {
+			t.Errorf("got content type %v want %v", got, want)
+		}
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		tx, err := DecodeTransactionBytes(data)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/submit/tx":
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(tx.ID())
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(rejection))
+		}
+	}))
+	defer server.Close()
+
+	address := Address("addr_test1vqgjd0t02q9yglcjwdc8dht9tz6gkfpqqm7evs5csrklakcqmwv40")
+	tx := &Transaction{Body: TransactionBody{
+		Inputs:  []TransactionInput{{ID: make([]byte, 32), Index: 0}},
+		Outputs: []TransactionOutput{{Address: address.Bytes(), Amount: 1000000}},
+		Fee:     170000,
+		Ttl:     100,
+	}}
+
+	id, err := SubmitToURL(context.Background(), server.URL+"/api/submit/tx", tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := id, tx.ID(); got != want {
+		t.Errorf("got id %v want %v", got, want)
+	}
+
+	_, err = SubmitToURL(context.Background(), server.URL+"/reject", tx)
+	submitErr := &SubmitError{}
+	if !errors.As(err, &submitErr) {
+		t.Fatalf("got error %v want a SubmitError", err)
+	}
+	if submitErr.StatusCode != http.StatusBadRequest || submitErr.Body != rejection {
+		t.Errorf("got %v %v want %v %v", submitErr.StatusCode, submitErr.Body, http.StatusBadRequest, rejection)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := SubmitToURL(ctx, server.URL+"/slow", tx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v want %v", err, context.DeadlineExceeded)
+	}
+}
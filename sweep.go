@@ -0,0 +1,39 @@
+package cardano
+
+import (
+	"fmt"
+
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+// FundedInput is a utxo along with the key able to spend it.
+type FundedInput struct {
+	Utxo Utxo
+	Key  crypto.ExtendedSigningKey
+}
+
+// BuildSweep builds a transaction sending the whole amount of the sources,
+// minus the fee, to the destination address. Each source is signed with its
+// own key. The ttl defaults to 20 minutes from now, see BuildOption.
+func BuildSweep(sources []FundedInput, dest Address, protocol ProtocolParams, opts ...BuildOption) (*Transaction, error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no sources to sweep")
+	}
+
+	builder := NewTxBuilder(protocol)
+	for _, source := range sources {
+		builder.AddInput(source.Key.ExtendedVerificationKey(), source.Utxo.TxId, source.Utxo.Index, source.Utxo.Amount)
+		builder.Sign(source.Key)
+	}
+	builder.SetChangeAddress(dest)
+	applyBuildOptions(builder, opts)
+
+	tx, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	if len(tx.Body.Outputs) == 0 {
+		return nil, fmt.Errorf("swept amount is below the minimum utxo value, got %v", tx.Body.Fee)
+	}
+	return &tx, nil
+}
@@ -0,0 +1,55 @@
+package cardano
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+func TestBuildSweep(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("treasury"), "foo")
+	dest := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	utxos := testUtxos(3000000, 2000000, 5000000)
+	sources := make([]FundedInput, len(utxos))
+	resolved := map[string]Address{}
+	for i, utxo := range utxos {
+		key := crypto.NewExtendedSigningKey([]byte{byte(i)}, "foo")
+		utxo.Address = NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+		sources[i] = FundedInput{Utxo: utxo, Key: key}
+		resolved[TransactionInput{ID: utxo.TxId.Bytes(), Index: utxo.Index}.String()] = utxo.Address
+	}
+
+	tx, err := BuildSweep(sources, dest, ShelleyProtocol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(tx.Body.Outputs), 1; got != want {
+		t.Fatalf("got %v outputs want %v", got, want)
+	}
+	if got, want := tx.Body.Outputs[0].Address, dest.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("got output address %x want %x", got, want)
+	}
+	if got, want := tx.Body.Outputs[0].Amount+tx.Body.Fee, sumUtxos(utxos); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := tx.Body.Fee, CalculateFee(tx, ShelleyProtocol); got < want {
+		t.Errorf("got fee %v want atleast %v", got, want)
+	}
+
+	if err := tx.VerifySignatures(); err != nil {
+		t.Errorf("VerifySignatures() error = %v", err)
+	}
+	signed, missing, err := tx.IsFullySigned(resolved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signed {
+		t.Errorf("missing signatures for %x", missing)
+	}
+
+	if _, err := BuildSweep(sources[:0], dest, ShelleyProtocol); err == nil {
+		t.Errorf("expected no sources error")
+	}
+}
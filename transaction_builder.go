@@ -0,0 +1,122 @@
+package cardano
+
+import (
+	"bytes"
+
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+// TransactionBuilder is a chainable TXBuilder, balancing the transaction with
+// its change address in Build and signing the inputs with the keys given to
+// Sign in BuildAndSign.
+type TransactionBuilder struct {
+	builder *TXBuilder
+	keys    signingKeys
+}
+
+// NewTransactionBuilder returns a TransactionBuilder using the protocol
+// parameters to calculate the fee.
+func NewTransactionBuilder(protocol ProtocolParams) *TransactionBuilder {
+	return &TransactionBuilder{builder: NewTxBuilder(protocol)}
+}
+
+// AddInput adds the utxo as an input, it's signed by the key of its address
+// payment key hash.
+func (tb *TransactionBuilder) AddInput(utxo Utxo) *TransactionBuilder {
+	tb.builder.AddUtxo(utxo)
+	return tb
+}
+
+// AddOutput adds an output of the lovelace and native tokens value.
+func (tb *TransactionBuilder) AddOutput(address Address, value Value) *TransactionBuilder {
+	tb.builder.AddOutputValue(address, value)
+	return tb
+}
+
+// SetTTL sets the slot after which the transaction is invalid.
+func (tb *TransactionBuilder) SetTTL(ttl uint64) *TransactionBuilder {
+	tb.builder.SetTtl(ttl)
+	return tb
+}
+
+// SetFee sets the fee, recalculated by Build if a change address is given.
+func (tb *TransactionBuilder) SetFee(fee uint64) *TransactionBuilder {
+	tb.builder.SetFee(fee)
+	return tb
+}
+
+// AddCertificate adds the certificate, its deposit or refund is accounted for
+// in the change.
+func (tb *TransactionBuilder) AddCertificate(cert Certificate) *TransactionBuilder {
+	tb.builder.AddCertificate(cert)
+	return tb
+}
+
+// SetMetadata sets the transaction metadata and its hash in the body.
+func (tb *TransactionBuilder) SetMetadata(metadata transactionMetadata) *TransactionBuilder {
+	tb.builder.SetMetadata(metadata)
+	return tb
+}
+
+// SetProtocolParams sets the protocol parameters used to calculate the fee
+// and the deposits.
+func (tb *TransactionBuilder) SetProtocolParams(protocol ProtocolParams) *TransactionBuilder {
+	tb.builder.protocol = protocol
+	return tb
+}
+
+// Sign adds a signing key. BuildAndSign signs with every key, the inputs
+// keys and the extra signers like the stake keys of the certificates.
+func (tb *TransactionBuilder) Sign(key crypto.ExtendedSigningKey) *TransactionBuilder {
+	tb.keys = append(tb.keys, key)
+	return tb
+}
+
+// Build calculates the fee and adds the change output if a change address is
+// given, returning the unsigned body.
+func (tb *TransactionBuilder) Build(change Address) (*TransactionBody, error) {
+	if change != "" {
+		if err := tb.builder.AddFee(change); err != nil {
+			return nil, err
+		}
+	}
+	body, err := tb.builder.buildBody()
+	if err != nil {
+		return nil, err
+	}
+	return &body, nil
+}
+
+// BuildAndSign balances the transaction with the change address and signs
+// it, returning an error if an input has no signing key.
+func (tb *TransactionBuilder) BuildAndSign(change Address) (*Transaction, error) {
+	tb.builder.SetChangeAddress(change)
+	if err := tb.builder.SignWith(tb.keys); err != nil {
+		return nil, err
+	}
+	for _, key := range tb.keys {
+		tb.builder.Sign(key)
+	}
+	tx, err := tb.builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// signingKeys resolves addresses to the key of their payment key hash.
+type signingKeys []crypto.ExtendedSigningKey
+
+// KeyFor implements KeyResolver.
+func (keys signingKeys) KeyFor(addr Address) (Signer, bool) {
+	paymentKeyHash, err := addr.PaymentKeyHash()
+	if err != nil {
+		return nil, false
+	}
+	for i := range keys {
+		if bytes.Equal(keyHash(keys[i].ExtendedVerificationKey()), paymentKeyHash) {
+			return &keys[i], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,71 @@
+package cardano
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+func TestTransactionBuilder(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	receiverKey := crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(receiverKey.ExtendedVerificationKey()))
+	stakeKey := crypto.NewExtendedSigningKey([]byte("stake"), "foo")
+	credential := NewKeyCredential(stakeKey.ExtendedVerificationKey())
+
+	utxos := testUtxos(6000000, 4000000)
+	for i := range utxos {
+		utxos[i].Address = payer
+	}
+	newBuilder := func() *TransactionBuilder {
+		return NewTransactionBuilder(ShelleyProtocol).
+			AddInput(utxos[0]).
+			AddInput(utxos[1]).
+			AddOutput(receiver, NewValue(2000000)).
+			AddOutput(receiver, NewValue(1500000)).
+			AddCertificate(RegisterStake(credential)).
+			AddCertificate(DelegateStake(credential, bytes.Repeat([]byte{0x03}, 28))).
+			SetMetadata(transactionMetadata{674: MetadatumText("invoice 42")}).
+			SetTTL(100)
+	}
+
+	body, err := newBuilder().Build(payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(body.Outputs), 3; got != want {
+		t.Fatalf("got %v outputs want %v", got, want)
+	}
+	if body.MetadataHash == nil {
+		t.Errorf("missing metadata hash")
+	}
+
+	tx, err := newBuilder().Sign(key).Sign(stakeKey).BuildAndSign(payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tx.Body.Fee, body.Fee; got != want {
+		t.Errorf("got fee %v want %v", got, want)
+	}
+	if got, want := tx.Body.Fee, CalculateFee(tx, ShelleyProtocol); got < want {
+		t.Errorf("got fee %v want atleast %v", got, want)
+	}
+	if got, want := tx.Body.Outputs[0].Amount+tx.Body.Fee+3500000, sumUtxos(utxos)-ShelleyProtocol.KeyDeposit; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := len(tx.WitnessSet.VKeyWitnessSet), 2; got != want {
+		t.Errorf("got %v vkey witnesses want %v", got, want)
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		t.Errorf("VerifySignatures() error = %v", err)
+	}
+	if err := tx.VerifyMetadataHash(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := newBuilder().Sign(stakeKey).BuildAndSign(payer); err == nil {
+		t.Errorf("expected missing input key error")
+	}
+}
@@ -1,20 +1,41 @@
 package cardano
 
 import (
+	"bytes"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/echovl/ed25519"
 	"github.com/fxamacker/cbor/v2"
 	"github.com/tclairet/cardano-go/crypto"
 	"golang.org/x/crypto/blake2b"
+	"sort"
 )
 
 type ProtocolParams struct {
-	MinimumUtxoValue uint64
-	PoolDeposit      uint64
-	KeyDeposit       uint64
-	MinFeeA          uint64
-	MinFeeB          uint64
+	MinimumUtxoValue uint64     `json:"minUTxOValue"`
+	PoolDeposit      uint64     `json:"stakePoolDeposit"`
+	KeyDeposit       uint64     `json:"stakeAddressDeposit"`
+	MinFeeA          uint64     `json:"txFeePerByte"`
+	MinFeeB          uint64     `json:"txFeeFixed"`
+	MaxTxSize        uint64     `json:"maxTxSize"`
+	CostModels       CostModels `json:"costModels,omitempty"`
+	MaxTxExUnits     ExUnits    `json:"maxTxExecutionUnits"`
+
+	// CoinsPerUTxOByte is the Babbage min utxo rule, when set the min utxo
+	// value increases with the size of the output, see MinUTXO.
+	CoinsPerUTxOByte uint64 `json:"utxoCostPerByte,omitempty"`
+
+	// CoinsPerUTxOWord is the Alonzo min utxo rule, used by MinUTXO when
+	// CoinsPerUTxOByte isn't set, see MinUTXOWord.
+	CoinsPerUTxOWord uint64 `json:"utxoCostPerWord,omitempty"`
+}
+
+// MaxFee returns the min fee of a transaction of the maximum size, which is
+// the highest fee a transaction may need.
+func (protocol ProtocolParams) MaxFee() uint64 {
+	return protocol.MinFeeA*protocol.MaxTxSize + protocol.MinFeeB
 }
 
 type TransactionID string
@@ -28,11 +49,125 @@ func (id TransactionID) Bytes() []byte {
 	return bytes
 }
 
+// Transaction is encoded as [body, witnesses, metadata], or since Alonzo as
+// [body, witnesses, is_valid, metadata] when IsValid is set.
 type Transaction struct {
-	_          struct{} `cbor:",toarray"`
 	Body       TransactionBody
 	WitnessSet TransactionWitnessSet
+	IsValid    *bool                // nil before Alonzo, false if a script fails
 	Metadata   *transactionMetadata // or null
+
+	// rawMetadata are the original bytes of decoded metadata, encoded
+	// instead of Metadata as long as its encoding is encodedMetadata.
+	rawMetadata     []byte
+	encodedMetadata []byte
+
+	description string // off-chain, only in the text envelope
+}
+
+type shelleyTransaction struct {
+	_          struct{} `cbor:",toarray"`
+	Body       TransactionBody
+	WitnessSet TransactionWitnessSet
+	Metadata   cbor.RawMessage
+}
+
+type alonzoTransaction struct {
+	_          struct{} `cbor:",toarray"`
+	Body       TransactionBody
+	WitnessSet TransactionWitnessSet
+	IsValid    bool
+	Metadata   cbor.RawMessage
+}
+
+// MarshalCBOR implements cbor.Marshaler.
+func (tx Transaction) MarshalCBOR() ([]byte, error) {
+	metadata, err := tx.metadataBytes()
+	if err != nil {
+		return nil, err
+	}
+	if tx.IsValid == nil {
+		return cbor.Marshal(shelleyTransaction{Body: tx.Body, WitnessSet: tx.WitnessSet, Metadata: metadata})
+	}
+	return cbor.Marshal(alonzoTransaction{Body: tx.Body, WitnessSet: tx.WitnessSet, IsValid: *tx.IsValid, Metadata: metadata})
+}
+
+// metadataBytes returns the encoding of the metadata, the original bytes of
+// decoded metadata which wasn't modified or null without metadata.
+func (tx *Transaction) metadataBytes() ([]byte, error) {
+	if tx.Metadata == nil {
+		return []byte{0xf6}, nil
+	}
+	encoded, err := tx.Metadata.MarshalCBOR()
+	if err != nil {
+		return nil, err
+	}
+	if tx.rawMetadata != nil && bytes.Equal(encoded, tx.encodedMetadata) {
+		return tx.rawMetadata, nil
+	}
+	return encoded, nil
+}
+
+// UnmarshalCBOR implements cbor.Unmarshaler, it accepts both the 3 elements
+// and the Alonzo 4 elements arrays.
+func (tx *Transaction) UnmarshalCBOR(data []byte) error {
+	fields := []cbor.RawMessage{}
+	if err := txDecMode.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	decoded := Transaction{}
+	switch len(fields) {
+	case 3:
+	case 4:
+		isValid := false
+		if err := txDecMode.Unmarshal(fields[2], &isValid); err != nil {
+			return fmt.Errorf("invalid transaction is_valid: %v", err)
+		}
+		decoded.IsValid = &isValid
+	default:
+		return fmt.Errorf("invalid transaction array length %v", len(fields))
+	}
+	if err := txDecMode.Unmarshal(fields[0], &decoded.Body); err != nil {
+		return err
+	}
+	if err := txDecMode.Unmarshal(fields[1], &decoded.WitnessSet); err != nil {
+		return err
+	}
+	rawMetadata := fields[len(fields)-1]
+	if err := txDecMode.Unmarshal(rawMetadata, &decoded.Metadata); err != nil {
+		return err
+	}
+	if decoded.Metadata != nil {
+		encoded, err := decoded.Metadata.MarshalCBOR()
+		if err != nil {
+			return err
+		}
+		decoded.rawMetadata = []byte(rawMetadata)
+		decoded.encodedMetadata = encoded
+	}
+	*tx = decoded
+	return nil
+}
+
+// Description returns the off-chain description of the transaction.
+func (tx *Transaction) Description() string {
+	return tx.description
+}
+
+// SetDescription sets an off-chain description, e.g. an internal reference,
+// exported in the text envelope but not submitted on-chain.
+func (tx *Transaction) SetDescription(description string) {
+	tx.description = description
+}
+
+// TextEnvelope returns the transaction in the cardano-cli text envelope
+// format, with its description.
+func (tx *Transaction) TextEnvelope() ([]byte, error) {
+	return json.Marshal(cardanoCliTx{
+		Type:        "Tx MaryEra",
+		Description: tx.description,
+		CborHex:     tx.CborHex(),
+	})
 }
 
 func (tx *Transaction) Bytes() []byte {
@@ -51,18 +186,382 @@ func (tx *Transaction) ID() TransactionID {
 	return tx.Body.ID()
 }
 
+// Spends returns the inputs consumed by the transaction.
+func (tx *Transaction) Spends() []TransactionInput {
+	inputs := make([]TransactionInput, len(tx.Body.Inputs))
+	copy(inputs, tx.Body.Inputs)
+	return inputs
+}
+
+// Produces returns the utxos created by the transaction outputs. Byron
+// addresses are base58 encoded, an output address which can't be encoded is
+// left empty.
+func (tx *Transaction) Produces() []Utxo {
+	txId := tx.ID()
+	utxos := make([]Utxo, len(tx.Body.Outputs))
+	for i, txOut := range tx.Body.Outputs {
+		var address Address
+		if len(txOut.Address) > 0 {
+			encoded, err := txOut.AddressBech32(Network(txOut.Address[0] & 0x0F))
+			if err == nil {
+				address = Address(encoded)
+			}
+		}
+		utxos[i] = Utxo{
+			Address: address,
+			TxId:    txId,
+			Amount:  txOut.Amount,
+			Index:   uint64(i),
+			Assets:  txOut.Assets,
+		}
+	}
+	return utxos
+}
+
+// CheckNetwork returns ErrNetworkMismatch if an output pays to an address of
+// another network. Byron addresses are not checked.
+func (tx *Transaction) CheckNetwork(network Network) error {
+	for i, txOut := range tx.Body.Outputs {
+		if len(txOut.Address) == 0 || txOut.Address[0]>>4 == 0x08 {
+			continue
+		}
+		if got := Network(txOut.Address[0] & 0x0F); got != network {
+			addr, _ := txOut.AddressBech32(got)
+			return fmt.Errorf("%w, output %v %v is on network %v want %v", ErrNetworkMismatch, i, addr, got, network)
+		}
+	}
+	return nil
+}
+
+// ChangeUtxo returns the utxo created by the transaction for the change
+// address, so it can be spent by a chained transaction before this one is
+// confirmed.
+func (tx *Transaction) ChangeUtxo(change Address) (Utxo, error) {
+	changeBytes := change.Bytes()
+	for _, utxo := range tx.Produces() {
+		if bytes.Equal(tx.Body.Outputs[utxo.Index].Address, changeBytes) {
+			return utxo, nil
+		}
+	}
+	return Utxo{}, fmt.Errorf("no change output for address %v", change)
+}
+
+// DecodeTransaction decodes a hex encoded cbor transaction.
 func DecodeTransaction(cborHex string) (*Transaction, error) {
 	bytes, err := hex.DecodeString(cborHex)
 	if err != nil {
 		return nil, err
 	}
+	return DecodeTransactionBytes(bytes)
+}
+
+// txDecMode decodes untrusted transactions, rejecting duplicated map keys.
+var txDecMode = func() cbor.DecMode {
+	decMode, err := cbor.DecOptions{DupMapKey: cbor.DupMapKeyEnforcedAPF}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return decMode
+}()
+
+// DecodeTransactionBytes decodes a raw cbor transaction.
+//
+// The body keeps its original bytes, even if they aren't canonical, e.g.
+// indefinite length arrays, so the id and the signatures are computed on what
+// was actually signed. They are dropped once the decoded body is modified.
+func DecodeTransactionBytes(b []byte) (*Transaction, error) {
 	tx := Transaction{}
-	if err := cbor.Unmarshal(bytes, &tx); err != nil {
+	if err := txDecMode.Unmarshal(b, &tx); err != nil {
+		return nil, err
+	}
+	if err := tx.validate(); err != nil {
 		return nil, err
 	}
 	return &tx, nil
 }
 
+// validate checks the length of the hashes, keys and signatures of a decoded
+// transaction so they can be safely used afterwards.
+func (tx *Transaction) validate() error {
+	for i, txIn := range tx.Body.Inputs {
+		if len(txIn.ID) != blake2b.Size256 {
+			return fmt.Errorf("invalid input %v id length %v", i, len(txIn.ID))
+		}
+	}
+	for i, txOut := range tx.Body.Outputs {
+		if len(txOut.Address) == 0 {
+			return fmt.Errorf("empty output %v address", i)
+		}
+	}
+	if hash := tx.Body.MetadataHash; hash != nil && len(*hash) != blake2b.Size256 {
+		return fmt.Errorf("invalid metadata hash length %v", len(*hash))
+	}
+	if hash := tx.Body.ScriptDataHash; hash != nil && len(hash) != blake2b.Size256 {
+		return fmt.Errorf("invalid script data hash length %v", len(hash))
+	}
+	for i, hash := range tx.Body.RequiredSigners {
+		if len(hash) != 28 {
+			return fmt.Errorf("invalid required signer %v length %v", i, len(hash))
+		}
+	}
+	for i, witness := range tx.WitnessSet.VKeyWitnessSet {
+		if len(witness.VKey) != ed25519.PublicKeySize {
+			return fmt.Errorf("invalid witness %v vkey length %v", i, len(witness.VKey))
+		}
+		if len(witness.Signature) != ed25519.SignatureSize {
+			return fmt.Errorf("%w, witness %v got %v", ErrInvalidSignatureLength, i, len(witness.Signature))
+		}
+	}
+	for i, witness := range tx.WitnessSet.Bootstrap {
+		if len(witness.VKey) != ed25519.PublicKeySize {
+			return fmt.Errorf("invalid bootstrap witness %v vkey length %v", i, len(witness.VKey))
+		}
+		if len(witness.Signature) != ed25519.SignatureSize {
+			return fmt.Errorf("%w, bootstrap witness %v got %v", ErrInvalidSignatureLength, i, len(witness.Signature))
+		}
+		if len(witness.ChainCode) != 32 {
+			return fmt.Errorf("invalid bootstrap witness %v chain code length %v", i, len(witness.ChainCode))
+		}
+	}
+	for i, redeemer := range tx.WitnessSet.Redeemers {
+		if len(redeemer.Data) == 0 {
+			return fmt.Errorf("missing redeemer %v data", i)
+		}
+	}
+	return nil
+}
+
+// VerifySignatures checks that every vkey and bootstrap witness is a valid
+// signature of the transaction body.
+func (tx *Transaction) VerifySignatures() error {
+	txHash := blake2b.Sum256(tx.Body.Bytes())
+	for i, witness := range tx.WitnessSet.VKeyWitnessSet {
+		vkey := crypto.ExtendedVerificationKey(witness.VKey)
+		if !vkey.Verify(txHash[:], witness.Signature) {
+			return fmt.Errorf("invalid witness %v signature", i)
+		}
+	}
+	for i, witness := range tx.WitnessSet.Bootstrap {
+		vkey := crypto.ExtendedVerificationKey(witness.VKey)
+		if !vkey.Verify(txHash[:], witness.Signature) {
+			return fmt.Errorf("invalid bootstrap witness %v signature", i)
+		}
+	}
+	return nil
+}
+
+var (
+	// ErrInsufficientInput is returned when the inputs don't cover the
+	// outputs, the deposits and the fee, or leave a too small change.
+	ErrInsufficientInput = errors.New("insufficient input")
+
+	// ErrWitnessCountMismatch is returned when the signatures don't match
+	// the public keys or the witnesses the body needs.
+	ErrWitnessCountMismatch = errors.New("witness count mismatch")
+
+	// ErrInvalidSignatureLength is returned for signatures which aren't
+	// ed25519 signatures.
+	ErrInvalidSignatureLength = errors.New("invalid signature length")
+)
+
+// ErrTxTooLarge is returned when a transaction is larger than the protocol
+// MaxTxSize.
+var ErrTxTooLarge = errors.New("transaction too large")
+
+// ValidateSize returns ErrTxTooLarge with the number of bytes over the limit
+// if the transaction is larger than the protocol MaxTxSize. A zero MaxTxSize
+// means no limit.
+func (tx *Transaction) ValidateSize(protocol ProtocolParams) error {
+	size := uint64(len(tx.Bytes()))
+	if protocol.MaxTxSize != 0 && size > protocol.MaxTxSize {
+		return fmt.Errorf("%w, got %v bytes want atmost %v, %v bytes over", ErrTxTooLarge, size, protocol.MaxTxSize, size-protocol.MaxTxSize)
+	}
+	return nil
+}
+
+// Verify checks the witnesses of the transaction: their lengths and
+// signatures of the body hash, a witness for every required signer, and
+// atleast one key or script witness spending the inputs. The input addresses
+// aren't known here, VerifyInputs checks that every input key signed.
+func (tx *Transaction) Verify() error {
+	if err := tx.validate(); err != nil {
+		return err
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		return err
+	}
+
+	witnessed := tx.witnessedKeys()
+	for i, hash := range tx.Body.RequiredSigners {
+		if !witnessed[string(hash)] {
+			return fmt.Errorf("missing witness of required signer %v %x", i, hash)
+		}
+	}
+
+	witnesses := len(tx.WitnessSet.VKeyWitnessSet) + len(tx.WitnessSet.Bootstrap) +
+		len(tx.WitnessSet.NativeScripts) + len(tx.WitnessSet.Redeemers)
+	if len(tx.Body.Inputs) > 0 && witnesses == 0 {
+		return fmt.Errorf("missing witnesses of the %v inputs", len(tx.Body.Inputs))
+	}
+	return nil
+}
+
+// VerifyInputs checks that there is a vkey witness for the payment key of
+// every input not locked by a script, the error reports the first input
+// without one. The inputs' addresses are looked up in resolvedInputs by their
+// txid#index, like IsFullySigned. The signatures are checked by Verify.
+func (tx *Transaction) VerifyInputs(resolvedInputs map[string]Address) error {
+	witnessed := tx.witnessedKeys()
+	for _, txIn := range tx.Body.Inputs {
+		addr, ok := resolvedInputs[txIn.String()]
+		if !ok {
+			return fmt.Errorf("unresolved input %v", txIn)
+		}
+		if addr.IsScript() {
+			continue
+		}
+		hash, err := addr.PaymentKeyHash()
+		if err != nil {
+			return fmt.Errorf("input %v: %w", txIn, err)
+		}
+		if !witnessed[string(hash)] {
+			return fmt.Errorf("missing witness of input %v key %x", txIn, hash)
+		}
+	}
+	return nil
+}
+
+// witnessedKeys returns the key hashes of the vkey witnesses.
+func (tx *Transaction) witnessedKeys() map[string]bool {
+	witnessed := map[string]bool{}
+	for _, witness := range tx.WitnessSet.VKeyWitnessSet {
+		witnessed[string(keyHash(witness.VKey))] = true
+	}
+	return witnessed
+}
+
+// ErrMetadataHashMismatch is returned when the metadata isn't the one whose
+// hash is committed in the body.
+var ErrMetadataHashMismatch = errors.New("metadata hash mismatch")
+
+// AttachMetadata attaches the metadata whose hash was reserved in the body,
+// e.g. with TXBuilder.ReserveMetadata when the metadata is produced after the
+// body is signed. ErrMetadataHashMismatch is returned if it isn't the
+// metadata of the body hash.
+func (tx *Transaction) AttachMetadata(metadata transactionMetadata) error {
+	if tx.Body.MetadataHash == nil {
+		return fmt.Errorf("%w, the body has no metadata hash", ErrMetadataHashMismatch)
+	}
+	hash, err := metadata.Hash()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(hash, *tx.Body.MetadataHash) {
+		return fmt.Errorf("%w, got %x want %x", ErrMetadataHashMismatch, hash, *tx.Body.MetadataHash)
+	}
+	tx.Metadata = &metadata
+	tx.rawMetadata, tx.encodedMetadata = nil, nil
+	return nil
+}
+
+// VerifyMetadataHash checks that the body metadata hash is the hash of the
+// transaction metadata, both are either present or absent. The original bytes
+// of decoded metadata are hashed.
+func (tx *Transaction) VerifyMetadataHash() error {
+	if tx.Metadata == nil || tx.Body.MetadataHash == nil {
+		if tx.Metadata != nil || tx.Body.MetadataHash != nil {
+			return fmt.Errorf("metadata and metadata hash must be both present or absent")
+		}
+		return nil
+	}
+	encoded, err := tx.metadataBytes()
+	if err != nil {
+		return err
+	}
+	hash := blake2b.Sum256(encoded)
+	if !bytes.Equal(hash[:], *tx.Body.MetadataHash) {
+		return fmt.Errorf("%w, got %x want %x", ErrMetadataHashMismatch, *tx.Body.MetadataHash, hash)
+	}
+	return nil
+}
+
+// IsFullySigned reports whether there is a vkey witness for the payment key of
+// every input and for every required signer. The inputs' addresses are looked
+// up in resolvedInputs by their txid#index. The key hashes without a witness
+// are returned when the transaction isn't fully signed.
+func (tx *Transaction) IsFullySigned(resolvedInputs map[string]Address) (bool, [][]byte, error) {
+	required := [][]byte{}
+	for _, txIn := range tx.Body.Inputs {
+		addr, ok := resolvedInputs[txIn.String()]
+		if !ok {
+			return false, nil, fmt.Errorf("unresolved input %v", txIn)
+		}
+		credType, err := addr.CredentialType()
+		if err != nil {
+			return false, nil, err
+		}
+		if credType == ScriptCredential {
+			continue
+		}
+		addrBytes := addr.Bytes()
+		if len(addrBytes) < 29 {
+			return false, nil, fmt.Errorf("invalid input %v address length %v", txIn, len(addrBytes))
+		}
+		required = append(required, addrBytes[1:29])
+	}
+	required = append(required, tx.Body.RequiredSigners...)
+
+	witnessed := tx.witnessedKeys()
+	missing := [][]byte{}
+	for _, hash := range required {
+		if !witnessed[string(hash)] {
+			missing = append(missing, hash)
+			witnessed[string(hash)] = true // report each key once
+		}
+	}
+	return len(missing) == 0, missing, nil
+}
+
+// TotalFee returns the sum of the fees of the transactions.
+func TotalFee(txs []*Transaction) uint64 {
+	var total uint64
+	for _, tx := range txs {
+		total += tx.Body.Fee
+	}
+	return total
+}
+
+// SizeBreakdown returns the serialized size in bytes of the transaction
+// components: "body", "witness_set", "is_valid" for Alonzo transactions and
+// "metadata", which is the null value without metadata, and "header" for the
+// enclosing array, summing to "total". The size of each output, included in
+// the body, is keyed "output/<index>".
+func (tx *Transaction) SizeBreakdown() (map[string]int, error) {
+	txBytes := tx.Bytes()
+	fields := []cbor.RawMessage{}
+	if err := cbor.Unmarshal(txBytes, &fields); err != nil {
+		return nil, fmt.Errorf("invalid transaction encoding: %w", err)
+	}
+	keys := []string{"body", "witness_set", "metadata"}
+	if len(fields) == 4 {
+		keys = []string{"body", "witness_set", "is_valid", "metadata"}
+	} else if len(fields) != 3 {
+		return nil, fmt.Errorf("invalid transaction encoding, got %v elements want 3 or 4", len(fields))
+	}
+	sizes := map[string]int{"total": len(txBytes), "header": len(txBytes)}
+	for i, key := range keys {
+		sizes[key] = len(fields[i])
+		sizes["header"] -= len(fields[i])
+	}
+	outputs := []cbor.RawMessage{}
+	if err := cbor.Unmarshal(tx.Body.RawOutputs(), &outputs); err == nil {
+		for i, output := range outputs {
+			sizes[fmt.Sprintf("output/%v", i)] = len(output)
+		}
+	}
+	return sizes, nil
+}
+
 func CalculateFee(tx *Transaction, protocol ProtocolParams) uint64 {
 	txBytes := tx.Bytes()
 	txLength := uint64(len(txBytes))
@@ -70,8 +569,104 @@ func CalculateFee(tx *Transaction, protocol ProtocolParams) uint64 {
 }
 
 type TransactionWitnessSet struct {
-	VKeyWitnessSet []VKeyWitness `cbor:"0,keyasint,omitempty"`
-	// TODO: add optional fields 1-4
+	VKeyWitnessSet  []VKeyWitness      `cbor:"0,keyasint,omitempty"`
+	NativeScripts   []NativeScript     `cbor:"1,keyasint,omitempty"`
+	Bootstrap       []BootstrapWitness `cbor:"2,keyasint,omitempty"` // byron inputs
+	PlutusV1Scripts [][]byte           `cbor:"3,keyasint,omitempty"`
+	PlutusData      []cbor.RawMessage  `cbor:"4,keyasint,omitempty"`
+	Redeemers       []Redeemer         `cbor:"5,keyasint,omitempty"`
+	PlutusV2Scripts [][]byte           `cbor:"6,keyasint,omitempty"`
+	PlutusV3Scripts [][]byte           `cbor:"7,keyasint,omitempty"`
+
+	fields map[uint64]cbor.RawMessage // fields not modelled or set by key
+}
+
+// transactionWitnessSet is TransactionWitnessSet without its cbor methods.
+type transactionWitnessSet TransactionWitnessSet
+
+// canonicalEncMode sorts map keys as required by the ledger.
+var canonicalEncMode = func() cbor.EncMode {
+	encMode, err := cbor.EncOptions{Sort: cbor.SortCanonical}.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return encMode
+}()
+
+// MarshalCBOR implements cbor.Marshaler.
+func (ws TransactionWitnessSet) MarshalCBOR() ([]byte, error) {
+	if len(ws.fields) == 0 {
+		return cbor.Marshal(transactionWitnessSet(ws))
+	}
+	fields, err := ws.rawFields()
+	if err != nil {
+		return nil, err
+	}
+	for key, raw := range ws.fields {
+		fields[key] = raw
+	}
+	return canonicalEncMode.Marshal(fields)
+}
+
+// UnmarshalCBOR implements cbor.Unmarshaler.
+func (ws *TransactionWitnessSet) UnmarshalCBOR(data []byte) error {
+	decoded := transactionWitnessSet{}
+	if err := txDecMode.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	fields := map[uint64]cbor.RawMessage{}
+	if err := txDecMode.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*ws = TransactionWitnessSet(decoded)
+	for key, raw := range fields {
+		switch key {
+		case 0, 1, 2, 3, 4, 5, 6, 7:
+		default:
+			if ws.fields == nil {
+				ws.fields = map[uint64]cbor.RawMessage{}
+			}
+			ws.fields[key] = raw
+		}
+	}
+	return nil
+}
+
+// rawFields returns the encoding of each modelled field by key.
+func (ws *TransactionWitnessSet) rawFields() (map[uint64]cbor.RawMessage, error) {
+	encoded, err := cbor.Marshal(transactionWitnessSet(*ws))
+	if err != nil {
+		return nil, err
+	}
+	fields := map[uint64]cbor.RawMessage{}
+	if err := cbor.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// SetWitnessField sets the raw cbor value of the witness set field key. This
+// allows attaching fields which aren't modelled yet, the value takes precedence
+// over the modelled field of the same key when encoding.
+func (tx *Transaction) SetWitnessField(key uint64, raw cbor.RawMessage) {
+	if tx.WitnessSet.fields == nil {
+		tx.WitnessSet.fields = map[uint64]cbor.RawMessage{}
+	}
+	tx.WitnessSet.fields[key] = raw
+}
+
+// WitnessField returns the raw cbor value of the witness set field key,
+// including unknown fields of a decoded transaction.
+func (tx *Transaction) WitnessField(key uint64) (cbor.RawMessage, bool) {
+	if raw, ok := tx.WitnessSet.fields[key]; ok {
+		return raw, true
+	}
+	fields, err := tx.WitnessSet.rawFields()
+	if err != nil {
+		return nil, false
+	}
+	raw, ok := fields[key]
+	return raw, ok
 }
 
 type VKeyWitness struct {
@@ -80,21 +675,103 @@ type VKeyWitness struct {
 	Signature []byte   // ed25519 signature
 }
 
-// Cbor map
-type transactionMetadata map[uint64]transactionMetadatum
-
-// This could be cbor map, array, int, bytes or a text
-type transactionMetadatum struct{}
+// BootstrapWitness is the witness of a byron address input, the chain code and
+// the attributes are needed to rebuild the address.
+type BootstrapWitness struct {
+	_          struct{} `cbor:",toarray"`
+	VKey       []byte   // ed25519 public key
+	Signature  []byte   // ed25519 signature
+	ChainCode  []byte   // 32 bytes
+	Attributes []byte   // cbor of the byron address attributes
+}
 
 type TransactionBody struct {
-	Inputs       []TransactionInput  `cbor:"0,keyasint"`
-	Outputs      []TransactionOutput `cbor:"1,keyasint"`
-	Fee          uint64              `cbor:"2,keyasint"`
-	Ttl          uint64              `cbor:"3,keyasint"`
-	Certificates []Certificate       `cbor:"4,keyasint,omitempty"` // Omit for now
-	Withdrawals  *uint               `cbor:"5,keyasint,omitempty"` // Omit for now
-	Update       *uint               `cbor:"6,keyasint,omitempty"` // Omit for now
-	MetadataHash *uint               `cbor:"7,keyasint,omitempty"` // Omit for now
+	Inputs          []TransactionInput  `cbor:"0,keyasint"`
+	Outputs         []TransactionOutput `cbor:"1,keyasint"`
+	Fee             uint64              `cbor:"2,keyasint"`
+	Ttl             uint64              `cbor:"3,keyasint"`
+	Certificates    []Certificate       `cbor:"4,keyasint,omitempty"`
+	Withdrawals     Withdrawals         `cbor:"5,keyasint,omitempty"`
+	Update          *uint               `cbor:"6,keyasint,omitempty"` // Omit for now
+	MetadataHash    *[]byte             `cbor:"7,keyasint,omitempty"` // nil without metadata
+	ValidityStart   *uint64             `cbor:"8,keyasint,omitempty"` // invalid before this slot
+	Mint            MintAssets          `cbor:"9,keyasint,omitempty"`
+	ScriptDataHash  []byte              `cbor:"11,keyasint,omitempty"`
+	Collateral      []TransactionInput  `cbor:"13,keyasint,omitempty"` // spent if a script fails
+	RequiredSigners [][]byte            `cbor:"14,keyasint,omitempty"` // key hashes
+
+	raw      []byte               // original bytes of a decoded body
+	encoded  []byte               // canonical encoding of the body when it was decoded
+	metadata *transactionMetadata // attached to the transaction by AddSignatures
+
+	// metadataSize is the size reserved in the fee for the metadata of the
+	// hash, attached later with AttachMetadata.
+	metadataSize int
+
+	// nativeScripts are the minting policies and the scripts of the
+	// scriptInputs, attached to the witness set by AddSignatures.
+	nativeScripts []NativeScript
+	scriptInputs  int
+
+	// byronAttributes are the address attributes of the bootstrap witnesses,
+	// accounted for in the fee.
+	byronAttributes [][]byte
+
+	// redeemers spend the script inputs, attached to the witness set by
+	// AddSignatures with the plutusScripts they run.
+	redeemers     []Redeemer
+	plutusScripts []PlutusScript
+}
+
+// transactionBody is TransactionBody without its cbor methods.
+type transactionBody TransactionBody
+
+// MarshalCBOR implements cbor.Marshaler. These are the bytes hashed to get the
+// transaction id and signed by the witnesses.
+func (body TransactionBody) MarshalCBOR() ([]byte, error) {
+	encoded, err := transactionBody(body).encode()
+	if err != nil {
+		return nil, err
+	}
+	if body.raw != nil && bytes.Equal(encoded, body.encoded) {
+		return body.raw, nil
+	}
+	return encoded, nil
+}
+
+// UnmarshalCBOR implements cbor.Unmarshaler.
+func (body *TransactionBody) UnmarshalCBOR(data []byte) error {
+	decoded := transactionBody{}
+	if err := txDecMode.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	encoded, err := decoded.encode()
+	if err != nil {
+		return err
+	}
+	*body = TransactionBody(decoded)
+	body.raw = append([]byte(nil), data...)
+	body.encoded = encoded
+	return nil
+}
+
+// encode returns the canonical encoding of the body, the inputs are a set
+// serialized sorted by id and index so that the same inputs always give the
+// same transaction id.
+func (body transactionBody) encode() ([]byte, error) {
+	inputs := make([]TransactionInput, len(body.Inputs))
+	copy(inputs, body.Inputs)
+	sort.SliceStable(inputs, func(i, j int) bool {
+		return lessInput(inputs[i], inputs[j])
+	})
+	if body.Inputs != nil {
+		body.Inputs = inputs
+	}
+	// An empty mint map is invalid, the field is omitted
+	if body.Mint.IsEmpty() {
+		body.Mint = nil
+	}
+	return canonicalEncMode.Marshal(body)
 }
 
 func (body *TransactionBody) Bytes() []byte {
@@ -105,6 +782,60 @@ func (body *TransactionBody) Bytes() []byte {
 	return bytes
 }
 
+// RawField returns the cbor of the body field key as it's serialized in the
+// body, or nil if the field is absent.
+func (body *TransactionBody) RawField(key uint64) cbor.RawMessage {
+	fields := map[uint64]cbor.RawMessage{}
+	if err := cbor.Unmarshal(body.Bytes(), &fields); err != nil {
+		return nil
+	}
+	return fields[key]
+}
+
+// RawInputs returns the cbor of the inputs.
+func (body *TransactionBody) RawInputs() cbor.RawMessage {
+	return body.RawField(0)
+}
+
+// RawOutputs returns the cbor of the outputs.
+func (body *TransactionBody) RawOutputs() cbor.RawMessage {
+	return body.RawField(1)
+}
+
+// RawCertificates returns the cbor of the certificates, or nil without
+// certificates.
+func (body *TransactionBody) RawCertificates() cbor.RawMessage {
+	return body.RawField(4)
+}
+
+// CborHex returns the hex encoded cbor of the body.
+func (body *TransactionBody) CborHex() string {
+	return hex.EncodeToString(body.Bytes())
+}
+
+// SetMetadata sets the metadata hash of the body, the metadata is then
+// accounted for in the fee and attached to the transaction by AddSignatures.
+// Empty metadata removes the hash.
+func (body *TransactionBody) SetMetadata(metadata transactionMetadata) error {
+	if len(metadata) == 0 {
+		body.MetadataHash, body.metadata = nil, nil
+		return nil
+	}
+	hash, err := metadata.Hash()
+	if err != nil {
+		return err
+	}
+	body.MetadataHash, body.metadata = &hash, &metadata
+	return nil
+}
+
+// ReserveMetadata sets the metadata hash of the body without the metadata,
+// which is attached to the signed transaction with AttachMetadata. The size of
+// the serialized metadata is accounted for in the fee.
+func (body *TransactionBody) ReserveMetadata(hash []byte, size int) {
+	body.MetadataHash, body.metadata, body.metadataSize = &hash, nil, size
+}
+
 func (body *TransactionBody) ID() TransactionID {
 	hash := blake2b.Sum256(body.Bytes())
 	return TransactionID(hex.EncodeToString(hash[:]))
@@ -112,17 +843,17 @@ func (body *TransactionBody) ID() TransactionID {
 
 func (body *TransactionBody) AddSignatures(publicKeys [][]byte, signatures [][]byte) (*Transaction, error) {
 	if len(publicKeys) != len(signatures) {
-		return nil, fmt.Errorf("missmatch length of publicKeys and signatures")
+		return nil, fmt.Errorf("%w, got %v public keys and %v signatures", ErrWitnessCountMismatch, len(publicKeys), len(signatures))
 	}
-	if len(signatures) != len(body.Inputs) {
-		return nil, fmt.Errorf("missmatch length of signatures and inputs")
+	if len(signatures) != body.witnessCount() {
+		return nil, fmt.Errorf("%w, got %v signatures want %v", ErrWitnessCountMismatch, len(signatures), body.witnessCount())
 	}
 
-	witnessSet := TransactionWitnessSet{}
+	witnessSet := body.witnessSet()
 
 	for i := 0; i < len(publicKeys); i++ {
 		if len(signatures[i]) != ed25519.SignatureSize {
-			return nil, fmt.Errorf("invalid signature length %v", len(signatures[i]))
+			return nil, fmt.Errorf("%w, got %v want %v", ErrInvalidSignatureLength, len(signatures[i]), ed25519.SignatureSize)
 		}
 		witness := VKeyWitness{VKey: publicKeys[i], Signature: signatures[i]}
 		witnessSet.VKeyWitnessSet = append(witnessSet.VKeyWitnessSet, witness)
@@ -131,7 +862,132 @@ func (body *TransactionBody) AddSignatures(publicKeys [][]byte, signatures [][]b
 	return &Transaction{
 		Body:       *body,
 		WitnessSet: witnessSet,
-		Metadata:   nil,
+		Metadata:   body.metadata,
+	}, nil
+}
+
+// AssetBalance returns the quantity of each native token of the inputs, looked
+// up in utxos, plus the mint minus the outputs. Only the unbalanced tokens are
+// returned, a positive quantity isn't sent by any output and a negative one
+// is sent without being spent or minted.
+func (body *TransactionBody) AssetBalance(utxos []Utxo) (map[AssetID]int64, error) {
+	resolved := map[string]Utxo{}
+	for _, utxo := range utxos {
+		resolved[TransactionInput{ID: utxo.TxId.Bytes(), Index: utxo.Index}.String()] = utxo
+	}
+	balance := map[AssetID]int64{}
+	add := func(assets MultiAsset, sign int64) error {
+		for policy, names := range assets {
+			for name, quantity := range names {
+				if quantity > maxInt64 {
+					return fmt.Errorf("asset %v.%x quantity %v overflows", policy, name, quantity)
+				}
+				balance[AssetID{Policy: policy, Name: name}] += sign * int64(quantity)
+			}
+		}
+		return nil
+	}
+	for _, txIn := range body.Inputs {
+		utxo, ok := resolved[txIn.String()]
+		if !ok {
+			return nil, fmt.Errorf("unresolved input %v", txIn)
+		}
+		if err := add(utxo.Assets, 1); err != nil {
+			return nil, err
+		}
+	}
+	for policy, names := range body.Mint {
+		for name, quantity := range names {
+			balance[AssetID{Policy: policy, Name: name}] += quantity
+		}
+	}
+	for _, txOut := range body.Outputs {
+		if err := add(txOut.Assets, -1); err != nil {
+			return nil, err
+		}
+	}
+	for asset, quantity := range balance {
+		if quantity == 0 {
+			delete(balance, asset)
+		}
+	}
+	return balance, nil
+}
+
+// Sign returns the transaction signed by the keys needed by the body: the
+// payment keys of the inputs, looked up in utxos, the required signers and
+// the stake keys of the certificates and withdrawals. The collateral inputs
+// are resolved like the inputs. Keys which aren't needed are ignored, the
+// native script keys sign if they are given.
+func (body *TransactionBody) Sign(utxos []Utxo, keys []crypto.ExtendedSigningKey) (*Transaction, error) {
+	resolved := map[string]Address{}
+	for _, utxo := range utxos {
+		resolved[TransactionInput{ID: utxo.TxId.Bytes(), Index: utxo.Index}.String()] = utxo.Address
+	}
+	required := [][]byte{}
+	inputs := append(append([]TransactionInput{}, body.Inputs...), body.Collateral...)
+	for _, txIn := range inputs {
+		addr, ok := resolved[txIn.String()]
+		if !ok {
+			return nil, fmt.Errorf("unresolved input %v", txIn)
+		}
+		if addr.IsScript() {
+			continue
+		}
+		hash, err := addr.PaymentKeyHash()
+		if err != nil {
+			return nil, fmt.Errorf("input %v: %v", txIn, err)
+		}
+		required = append(required, hash)
+	}
+	required = append(required, body.RequiredSigners...)
+	for _, cert := range body.Certificates {
+		if cert.StakeRegistration == nil && cert.credential() != nil && cert.credential().Type == KeyCredential {
+			required = append(required, cert.credential().Hash)
+		}
+		if cert.PoolRegistration != nil {
+			required = append(required, cert.PoolRegistration.signers()...)
+		}
+	}
+	for rewardAddress := range body.Withdrawals {
+		if hash, err := rewardAddress.StakeKeyHash(); err == nil {
+			required = append(required, hash)
+		}
+	}
+	optional := [][]byte{}
+	for _, script := range body.nativeScripts {
+		optional = append(optional, script.keyHashes()...)
+	}
+
+	keysByHash := map[string]*crypto.ExtendedSigningKey{}
+	for i := range keys {
+		keysByHash[string(keyHash(keys[i].ExtendedVerificationKey()))] = &keys[i]
+	}
+	txHash := blake2b.Sum256(body.Bytes())
+	witnessSet := body.witnessSet()
+	signed := map[string]bool{}
+	for i, hash := range append(required, optional...) {
+		if signed[string(hash)] {
+			continue
+		}
+		key, ok := keysByHash[string(hash)]
+		if !ok {
+			if i < len(required) {
+				return nil, fmt.Errorf("missing signing key of key hash %x", hash)
+			}
+			continue
+		}
+		signed[string(hash)] = true
+		witnessSet.VKeyWitnessSet = append(witnessSet.VKeyWitnessSet, VKeyWitness{
+			VKey:      key.VerificationKey(),
+			Signature: key.Sign(txHash[:]),
+		})
+	}
+
+	return &Transaction{
+		Body:       *body,
+		WitnessSet: witnessSet,
+		Metadata:   body.metadata,
 	}, nil
 }
 
@@ -140,56 +996,136 @@ func (body *TransactionBody) calculateMinFee(protocol ProtocolParams) uint64 {
 		0x0c, 0xcb, 0x74, 0xf3, 0x6b, 0x7d, 0xa1, 0x64, 0x9a, 0x81, 0x44, 0x67, 0x55, 0x22, 0xd4, 0xd8, 0x09, 0x7c, 0x64, 0x12,
 	}, "")
 
-	witnessSet := TransactionWitnessSet{}
-	for range body.Inputs {
-		witness := VKeyWitness{VKey: fakeXSigningKey.ExtendedVerificationKey()[:32], Signature: fakeXSigningKey.Sign(fakeXSigningKey.ExtendedVerificationKey())}
+	witnessSet := body.witnessSet()
+	for i := 0; i < body.witnessCount()-len(body.byronAttributes); i++ {
+		witness := VKeyWitness{VKey: fakeXSigningKey.VerificationKey(), Signature: fakeXSigningKey.Sign(fakeXSigningKey.ExtendedVerificationKey())}
 		witnessSet.VKeyWitnessSet = append(witnessSet.VKeyWitnessSet, witness)
 	}
+	for _, attributes := range body.byronAttributes {
+		witness := BootstrapWitness{
+			VKey:       fakeXSigningKey.VerificationKey(),
+			Signature:  fakeXSigningKey.Sign(fakeXSigningKey.ExtendedVerificationKey()),
+			ChainCode:  fakeXSigningKey.ExtendedVerificationKey()[32:],
+			Attributes: attributes,
+		}
+		witnessSet.Bootstrap = append(witnessSet.Bootstrap, witness)
+	}
 
 	return CalculateFee(&Transaction{
 		Body:       *body,
 		WitnessSet: witnessSet,
-		Metadata:   nil,
-	}, protocol)
+		Metadata:   body.metadata,
+	}, protocol) + protocol.MinFeeA*body.reservedMetadataSize()
+}
+
+// witnessSet returns the witness set of the body without the vkey witnesses.
+func (body *TransactionBody) witnessSet() TransactionWitnessSet {
+	witnessSet := TransactionWitnessSet{NativeScripts: body.nativeScripts, Redeemers: body.redeemers}
+	witnessSet.addPlutusScripts(body.plutusScripts)
+	return witnessSet
 }
 
-func (body *TransactionBody) addFee(inputAmount uint64, changeAddress Address, protocol ProtocolParams) error {
+// witnessCount returns the number of vkey witnesses, one per input not spent
+// by a script or collateral input, per key of the minting policies, per stake
+// key of the deregistrations and delegations, per pool operator and owner and
+// per withdrawal stake key.
+func (body *TransactionBody) witnessCount() int {
+	count := len(body.Inputs) - body.scriptInputs + len(body.Collateral)
+	for _, redeemer := range body.redeemers {
+		if redeemer.Tag == RedeemerTagSpend {
+			count--
+		}
+	}
+	for _, script := range body.nativeScripts {
+		count += len(script.keyHashes())
+	}
+	for _, cert := range body.Certificates {
+		if cert.StakeRegistration == nil && cert.credential() != nil && cert.credential().Type == KeyCredential {
+			count++
+		}
+		if cert.PoolRegistration != nil {
+			count += len(cert.PoolRegistration.signers())
+		}
+	}
+	for rewardAddress := range body.Withdrawals {
+		if credType, err := rewardAddress.CredentialType(); err == nil && credType == KeyCredential {
+			count++
+		}
+	}
+	return count
+}
+
+// calculateMinFeeWithWitnessSize calculates the min fee assuming a serialized
+// witness set of witnessSize bytes. A zero witnessSize uses fake vkey witnesses.
+func (body *TransactionBody) calculateMinFeeWithWitnessSize(protocol ProtocolParams, witnessSize int) uint64 {
+	if witnessSize <= 0 {
+		return body.calculateMinFee(protocol)
+	}
+	tx := &Transaction{Body: *body, WitnessSet: TransactionWitnessSet{}, Metadata: body.metadata}
+	// The empty witness set is serialized as a single byte
+	txLength := uint64(len(tx.Bytes()) - 1 + witnessSize)
+	return protocol.MinFeeA*(txLength+body.reservedMetadataSize()) + protocol.MinFeeB
+}
+
+// reservedMetadataSize returns the size of the reserved metadata replacing the
+// null metadata byte.
+func (body *TransactionBody) reservedMetadataSize() uint64 {
+	if body.metadata != nil || body.metadataSize <= 1 {
+		return 0
+	}
+	return uint64(body.metadataSize - 1)
+}
+
+// addFee sets the min fee and adds the change output, changeAssets are the
+// tokens which must be sent to the change output, e.g. minted tokens.
+func (body *TransactionBody) addFee(inputAmount uint64, changeAddress Address, changeAssets MultiAsset, protocol ProtocolParams, witnessSize int) error {
 	// Set a temporary realistic fee in order to serialize a valid transaction
 	body.Fee = 200000
 
-	minFee := body.calculateMinFee(protocol)
+	minFee := body.calculateMinFeeWithWitnessSize(protocol, witnessSize)
 
-	outputAmount := uint64(0)
+	// Registrations take the key deposit and deregistrations refund it,
+	// withdrawals add the rewards to the inputs
+	deposit, refund := body.deposits(protocol)
+	inputAmount += refund + body.Withdrawals.total()
+
+	outputAmount := deposit
 	for _, txOut := range body.Outputs {
 		outputAmount += txOut.Amount
 	}
 	outputWithFeeAmount := outputAmount + minFee
 
 	if inputAmount < outputWithFeeAmount {
-		return fmt.Errorf("insuficient input in transaction, got %v want atleast %v", inputAmount, outputWithFeeAmount)
+		return fmt.Errorf("%w, got %v want atleast %v", ErrInsufficientInput, inputAmount, outputWithFeeAmount)
 	}
 
-	if inputAmount == outputWithFeeAmount {
+	if inputAmount == outputWithFeeAmount && len(changeAssets) == 0 {
 		body.Fee = minFee
 		return nil
 	}
 
 	change := inputAmount - outputWithFeeAmount
-	if change < protocol.MinimumUtxoValue {
+	changeOutput := TransactionOutput{
+		Address: changeAddress.Bytes(),
+		Amount:  change, // set a temporary value
+		Assets:  changeAssets,
+	}
+	minChange := MinUTXO(changeOutput, protocol)
+	if change < minChange {
+		if len(changeAssets) != 0 {
+			return fmt.Errorf("%w for the change tokens, got change %v want atleast %v", ErrInsufficientInput, change, minChange)
+		}
 		body.Fee = minFee + change // burn change
 		return nil
 	}
 
-	newBody := TransactionBody{
-		Inputs: body.Inputs,
-		Outputs: append([]TransactionOutput{{
-			Address: changeAddress.Bytes(),
-			Amount:  change, // set a temporary value
-		}}, body.Outputs...), // change will always be outputs[0] if present
-		Ttl: body.Ttl,
-	}
-	newMinFee := newBody.calculateMinFee(protocol)
-	if change+minFee-newMinFee < protocol.MinimumUtxoValue {
+	newBody := *body
+	newBody.Outputs = append([]TransactionOutput{changeOutput}, body.Outputs...) // change will always be outputs[0] if present
+	newMinFee := newBody.calculateMinFeeWithWitnessSize(protocol, witnessSize)
+	if change+minFee-newMinFee < minChange {
+		if len(changeAssets) != 0 {
+			return fmt.Errorf("%w for the change tokens, got change %v want atleast %v", ErrInsufficientInput, change+minFee-newMinFee, minChange)
+		}
 		body.Fee = minFee + change // burn change
 		return nil
 	}
@@ -199,24 +1135,140 @@ func (body *TransactionBody) addFee(inputAmount uint64, changeAddress Address, p
 	return nil
 }
 
+// addFixedFee adds the change output of the inputs minus the outputs and the
+// body fee, which must be atleast the min fee.
+func (body *TransactionBody) addFixedFee(inputAmount uint64, changeAddress Address, changeAssets MultiAsset, protocol ProtocolParams, witnessSize int) error {
+	deposit, refund := body.deposits(protocol)
+	inputAmount += refund + body.Withdrawals.total()
+
+	outputWithFeeAmount := deposit + body.Fee
+	for _, txOut := range body.Outputs {
+		outputWithFeeAmount += txOut.Amount
+	}
+	if inputAmount < outputWithFeeAmount {
+		return fmt.Errorf("%w, got %v want atleast %v", ErrInsufficientInput, inputAmount, outputWithFeeAmount)
+	}
+
+	newBody := *body
+	if change := inputAmount - outputWithFeeAmount; change != 0 || len(changeAssets) != 0 {
+		changeOutput := TransactionOutput{
+			Address: changeAddress.Bytes(),
+			Amount:  change,
+			Assets:  changeAssets,
+		}
+		if minChange := MinUTXO(changeOutput, protocol); change < minChange {
+			return fmt.Errorf("%w for a change output, got change %v want atleast %v", ErrInsufficientInput, change, minChange)
+		}
+		newBody.Outputs = append([]TransactionOutput{changeOutput}, body.Outputs...)
+	}
+	if minFee := newBody.calculateMinFeeWithWitnessSize(protocol, witnessSize); body.Fee < minFee {
+		return fmt.Errorf("%w, got %v want atleast %v", ErrFeeTooLow, body.Fee, minFee)
+	}
+	body.Outputs = newBody.Outputs
+	return nil
+}
+
 type TransactionInput struct {
 	_     struct{} `cbor:",toarray"`
 	ID    []byte   // HashKey 32 bytes
 	Index uint64
 }
 
+// lessInput orders the inputs by id bytes then index, as the ledger does.
+func lessInput(a, b TransactionInput) bool {
+	if c := bytes.Compare(a.ID, b.ID); c != 0 {
+		return c < 0
+	}
+	return a.Index < b.Index
+}
+
+// String returns the input as txid#index.
+func (input TransactionInput) String() string {
+	return fmt.Sprintf("%x#%v", input.ID, input.Index)
+}
+
 type TransactionOutput struct {
 	_       struct{} `cbor:",toarray"`
 	Address []byte
-	Amount  uint64
-}
-
-// TODO: This should a cbor array with one element:
-//  stake_registration
-//	stake_deregistration
-//	stake_delegation
-//	pool_registration
-//	pool_retirement
-//	genesis_key_delegation
-//	move_instantaneous_rewards_cert
-type Certificate struct{}
+	Amount  uint64     // lovelace
+	Assets  MultiAsset // native tokens, encoded with the amount as a Value
+}
+
+// NewTransactionOutput returns an output of the value to the address.
+func NewTransactionOutput(address Address, value Value) TransactionOutput {
+	return TransactionOutput{Address: address.Bytes(), Amount: value.Coin, Assets: value.Assets}
+}
+
+// Value returns the lovelace and native tokens of the output.
+func (txOut TransactionOutput) Value() Value {
+	return Value{Coin: txOut.Amount, Assets: txOut.Assets}
+}
+
+// transactionOutput is the cbor representation of a TransactionOutput.
+type transactionOutput struct {
+	_       struct{} `cbor:",toarray"`
+	Address []byte
+	Value   Value
+}
+
+// MarshalCBOR implements cbor.Marshaler, the value is a bare coin for a
+// lovelace only output and [coin, multiasset] with native tokens.
+func (txOut TransactionOutput) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(transactionOutput{Address: txOut.Address, Value: txOut.Value()})
+}
+
+// UnmarshalCBOR implements cbor.Unmarshaler.
+func (txOut *TransactionOutput) UnmarshalCBOR(data []byte) error {
+	decoded := transactionOutput{}
+	if err := txDecMode.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*txOut = TransactionOutput{Address: decoded.Address, Amount: decoded.Value.Coin, Assets: decoded.Value.Assets}
+	return nil
+}
+
+// MinUTXO returns the min lovelace of the output. With CoinsPerUTxOByte it's
+// the size of the serialized output plus the 160 bytes of utxo overhead times
+// CoinsPerUTxOByte, with CoinsPerUTxOWord it's MinUTXOWord, otherwise the
+// fixed MinimumUtxoValue.
+func MinUTXO(output TransactionOutput, params ProtocolParams) uint64 {
+	if params.CoinsPerUTxOByte == 0 {
+		if params.CoinsPerUTxOWord != 0 {
+			return MinUTXOWord(output, params.CoinsPerUTxOWord)
+		}
+		return params.MinimumUtxoValue
+	}
+	// The amount is counted with its largest encoding so that the result
+	// doesn't depend on the amount
+	output.Amount = maxUint64
+	encoded, err := cbor.Marshal(output)
+	if err != nil {
+		panic(err)
+	}
+	return (160 + uint64(len(encoded))) * params.CoinsPerUTxOByte
+}
+
+// MinUTXOWord returns the Alonzo min lovelace of the output, its utxo entry
+// size in 8 bytes words times coinsPerWord. The entry is 27 words plus the
+// size of the value: 2 words for lovelace only, otherwise 6 words plus the
+// policy ids, the asset names and 12 bytes per asset rounded up to words.
+func MinUTXOWord(output TransactionOutput, coinsPerWord uint64) uint64 {
+	const entrySizeWithoutValue = 27
+	valueSize := uint64(2)
+	if len(output.Assets) != 0 {
+		var assets, namesLength uint64
+		names := map[AssetName]bool{}
+		for _, policyAssets := range output.Assets {
+			for name := range policyAssets {
+				assets++
+				if !names[name] {
+					names[name] = true
+					namesLength += uint64(len(name))
+				}
+			}
+		}
+		bundleSize := assets*12 + namesLength + uint64(len(output.Assets))*28
+		valueSize = 6 + (bundleSize+7)/8
+	}
+	return (entrySizeWithoutValue + valueSize) * coinsPerWord
+}
@@ -1,16 +1,61 @@
 package cardano
 
 import (
+	"bytes"
+	"context"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/echovl/bech32"
+	"github.com/fxamacker/cbor/v2"
 	"github.com/tclairet/cardano-go/crypto"
 	"golang.org/x/crypto/blake2b"
 )
 
-const maxUint64 uint64 = 1<<64 - 1
+const (
+	maxUint64 uint64 = 1<<64 - 1
+	maxInt64  uint64 = 1<<63 - 1
+)
+
+// ErrFeeTooLow is returned when building a transaction whose exact fee is
+// lower than the protocol minimum.
+var ErrFeeTooLow = errors.New("fee too low")
+
+// ErrFeeExceedsCap is returned when building a transaction whose minimum fee
+// is higher than the cap set with MaxFee.
+var ErrFeeExceedsCap = errors.New("fee exceeds cap")
+
+// ErrTimeLocked is returned when building a transaction whose validity
+// interval doesn't satisfy the time lock of a native script, e.g. minting
+// after the deadline of a NewTimeLockedMintPolicy.
+var ErrTimeLocked = errors.New("validity interval outside the script time lock")
+
+// ErrMissingPolicyScript is returned when building a transaction minting
+// tokens of a policy whose script isn't attached.
+var ErrMissingPolicyScript = errors.New("missing policy script")
+
+// Signer signs transaction bodies on behalf of a verification key.
+type Signer interface {
+	ExtendedVerificationKey() crypto.ExtendedVerificationKey
+	Sign(message []byte) []byte
+}
+
+// KeyResolver maps an input's address to the key able to sign for it.
+type KeyResolver interface {
+	KeyFor(addr Address) (Signer, bool)
+}
 
 type TXBuilderInput struct {
-	input  TransactionInput
-	amount uint64
+	input    TransactionInput
+	amount   uint64
+	assets   MultiAsset
+	address  Address
+	redeemer *Redeemer // plutus script inputs
+	native   bool      // native script inputs
 }
 
 type TXBuilderOutput struct {
@@ -19,24 +64,58 @@ type TXBuilderOutput struct {
 }
 
 type TXBuilder struct {
-	tx       Transaction
-	protocol ProtocolParams
-	inputs   []TXBuilderInput
-	outputs  []TransactionOutput
-	ttl      uint64
-	fee      uint64
-	vkeys    map[string]crypto.ExtendedVerificationKey
-	pkeys    map[string]crypto.ExtendedSigningKey
+	tx          Transaction
+	protocol    ProtocolParams
+	inputs      []TXBuilderInput
+	outputs     []TransactionOutput
+	ttl         uint64
+	start       *uint64
+	fee         uint64
+	exactFee    bool
+	fixedFee    bool
+	maxFee      uint64
+	checkSize   bool
+	sortOutputs bool
+	change      Address
+	changeIndex int
+	tip         *NodeTip
+	config      *NetworkConfig
+	witnessSize int
+	description string
+	metadata    transactionMetadata
+	reserved    *reservedMetadata
+	mint        MintAssets
+	scripts     []NativeScript
+	plutus      []PlutusScript
+	collateral  []TXBuilderInput
+	certs       []Certificate
+	withdrawals Withdrawals
+	vkeys       map[string]crypto.ExtendedVerificationKey
+	pkeys       map[string]Signer
+	byronKeys   map[string][]byte // byron address attributes by vkey hash
 }
 
 func NewTxBuilder(protocol ProtocolParams) *TXBuilder {
 	return &TXBuilder{
-		protocol: protocol,
-		vkeys:    map[string]crypto.ExtendedVerificationKey{},
-		pkeys:    map[string]crypto.ExtendedSigningKey{},
+		protocol:    protocol,
+		changeIndex: -1,
+		vkeys:       map[string]crypto.ExtendedVerificationKey{},
+		pkeys:       map[string]Signer{},
+		byronKeys:   map[string][]byte{},
 	}
 }
 
+// NewTxBuilderWithConfig returns a TXBuilder using the network's protocol
+// parameters and slot timing.
+func NewTxBuilderWithConfig(cfg NetworkConfig) (*TXBuilder, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	builder := NewTxBuilder(cfg.Protocol)
+	builder.config = &cfg
+	return builder, nil
+}
+
 func (builder *TXBuilder) AddInput(xvk crypto.ExtendedVerificationKey, txId TransactionID, index, amount uint64) {
 	input := TXBuilderInput{input: TransactionInput{ID: txId.Bytes(), Index: index}, amount: amount}
 	builder.inputs = append(builder.inputs, input)
@@ -46,77 +125,745 @@ func (builder *TXBuilder) AddInput(xvk crypto.ExtendedVerificationKey, txId Tran
 	builder.vkeys[vkeyHashString] = xvk
 }
 
+// AddByronInput adds the utxo of a Byron address as an input, signed with a
+// bootstrap witness of the key of the address.
+func (builder *TXBuilder) AddByronInput(xvk crypto.ExtendedVerificationKey, utxo Utxo) error {
+	root, attributes, err := parseByronAddress(utxo.Address)
+	if err != nil {
+		return err
+	}
+	keyRoot, err := byronAddressRoot(xvk, attributes)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(keyRoot, root) {
+		return fmt.Errorf("key doesn't match byron address %v", utxo.Address)
+	}
+	builder.AddInput(xvk, utxo.TxId, utxo.Index, utxo.Amount)
+
+	vkeyHashBytes := blake2b.Sum256(xvk)
+	builder.byronKeys[hex.EncodeToString(vkeyHashBytes[:])] = attributes
+	return nil
+}
+
 func (builder *TXBuilder) AddInputWithoutSig(txId TransactionID, index, amount uint64) {
 	input := TXBuilderInput{input: TransactionInput{ID: txId.Bytes(), Index: index}, amount: amount}
 	builder.inputs = append(builder.inputs, input)
 }
 
+// AddUtxo adds the utxo as an input, its signing key is resolved from its
+// address when calling SignWith. Its native tokens not sent by the outputs are
+// sent to the change output.
+func (builder *TXBuilder) AddUtxo(utxo Utxo) {
+	input := TXBuilderInput{
+		input:   TransactionInput{ID: utxo.TxId.Bytes(), Index: utxo.Index},
+		amount:  utxo.Amount,
+		assets:  utxo.Assets,
+		address: utxo.Address,
+	}
+	builder.inputs = append(builder.inputs, input)
+}
+
+// AddScriptInput adds the utxo of the Plutus script address as an input spent
+// by the redeemer data and execution units. The redeemer index is set by Build
+// to the position of the input in the body, where the inputs are sorted by id
+// and index. The script is attached to the witness set and the script data
+// hash is computed with the protocol cost models of the attached scripts'
+// languages.
+//
+// The collateral utxo, of a key address signed with SignWith or Sign, is
+// spent instead of the inputs if the script fails.
+func (builder *TXBuilder) AddScriptInput(utxo Utxo, script PlutusScript, data cbor.RawMessage, exUnits ExUnits, collateral Utxo) error {
+	hash, err := script.Hash()
+	if err != nil {
+		return err
+	}
+	_, addressBytes, err := bech32.DecodeToBase256(string(utxo.Address))
+	if err != nil {
+		return err
+	}
+	if !utxo.Address.IsScript() || len(addressBytes) < 29 || !bytes.Equal(addressBytes[1:29], hash) {
+		return fmt.Errorf("address %v isn't the address of script %x", utxo.Address, hash)
+	}
+	if collateral.Address.IsScript() {
+		return fmt.Errorf("collateral address %v is a script address", collateral.Address)
+	}
+
+	input := TXBuilderInput{
+		input:    TransactionInput{ID: utxo.TxId.Bytes(), Index: utxo.Index},
+		amount:   utxo.Amount,
+		assets:   utxo.Assets,
+		address:  utxo.Address,
+		redeemer: &Redeemer{Tag: RedeemerTagSpend, Data: data, ExUnits: exUnits},
+	}
+	builder.inputs = append(builder.inputs, input)
+	builder.addCollateral(collateral)
+	for _, added := range builder.plutus {
+		if addedHash, err := added.Hash(); err == nil && bytes.Equal(addedHash, hash) {
+			return nil
+		}
+	}
+	builder.plutus = append(builder.plutus, script)
+	return nil
+}
+
+// addCollateral adds the utxo as collateral unless it was already added.
+func (builder *TXBuilder) addCollateral(utxo Utxo) {
+	input := TransactionInput{ID: utxo.TxId.Bytes(), Index: utxo.Index}
+	for _, added := range builder.collateral {
+		if added.input.String() == input.String() {
+			return
+		}
+	}
+	builder.collateral = append(builder.collateral, TXBuilderInput{input: input, amount: utxo.Amount, address: utxo.Address})
+}
+
+// AddNativeScriptInput adds the utxo of the native script address as an
+// input, e.g. of a multisig. The script is attached to the witness set and
+// its keys must sign with Sign.
+func (builder *TXBuilder) AddNativeScriptInput(utxo Utxo, script NativeScript) error {
+	if err := script.Validate(); err != nil {
+		return err
+	}
+	hash := script.Hash()
+	_, addressBytes, err := bech32.DecodeToBase256(string(utxo.Address))
+	if err != nil {
+		return err
+	}
+	if !utxo.Address.IsScript() || len(addressBytes) < 29 || !bytes.Equal(addressBytes[1:29], hash[:]) {
+		return fmt.Errorf("address %v isn't the address of script %x", utxo.Address, hash)
+	}
+
+	input := TXBuilderInput{
+		input:   TransactionInput{ID: utxo.TxId.Bytes(), Index: utxo.Index},
+		amount:  utxo.Amount,
+		assets:  utxo.Assets,
+		address: utxo.Address,
+		native:  true,
+	}
+	builder.inputs = append(builder.inputs, input)
+	for _, added := range builder.scripts {
+		if added.Hash() == hash {
+			return nil
+		}
+	}
+	builder.scripts = append(builder.scripts, script)
+	return nil
+}
+
 func (builder *TXBuilder) AddOutput(address Address, amount uint64) {
 	output := TransactionOutput{Address: address.Bytes(), Amount: amount}
 	builder.outputs = append(builder.outputs, output)
 }
 
+// AddOutputValue adds an output of the lovelace and native tokens value.
+func (builder *TXBuilder) AddOutputValue(address Address, value Value) {
+	builder.outputs = append(builder.outputs, NewTransactionOutput(address, value))
+}
+
+// AddAssetOutput adds an output sending the quantity of the asset with the
+// minimum utxo value, the tokens and the lovelace are taken from the inputs.
+func (builder *TXBuilder) AddAssetOutput(addr Address, policy PolicyID, name AssetName, quantity uint64) {
+	output := NewTransactionOutput(addr, Value{Assets: MultiAsset{policy: {name: quantity}}})
+	builder.AddOutputValue(addr, Value{
+		Coin:   MinUTXO(output, builder.protocol),
+		Assets: output.Assets,
+	})
+}
+
 func (builder *TXBuilder) SetTtl(ttl uint64) {
 	builder.ttl = ttl
 }
 
+// SetValidityStart sets the slot before which the transaction is invalid, it
+// must be lower than the TTL.
+func (builder *TXBuilder) SetValidityStart(slot uint64) {
+	builder.start = &slot
+}
+
+// SetTip sets the node tip used as the current slot when computing relative TTLs.
+func (builder *TXBuilder) SetTip(tip NodeTip) {
+	builder.tip = &tip
+}
+
+// SetTTLFromTip queries the node tip and sets the TTL to slotsAhead slots
+// after it. The tip is also used as the current slot by SetTTLIn. The query
+// stops when the context is done if the node implements TipContexter,
+// otherwise the Tip call keeps running in the background until it returns.
+func (builder *TXBuilder) SetTTLFromTip(ctx context.Context, node Node, slotsAhead uint64) error {
+	slot, err := tipContext(ctx, node)
+	if err != nil {
+		return err
+	}
+	tip := NodeTip{Slot: slot}
+	if tip.Slot > maxUint64-slotsAhead {
+		return fmt.Errorf("ttl overflows, tip %v plus %v slots", tip.Slot, slotsAhead)
+	}
+	builder.SetTip(tip)
+	builder.ttl = tip.Slot + slotsAhead
+	return nil
+}
+
+// SetTTLIn sets the TTL to the slot reached after the duration d from the
+// current slot. The current slot is the node tip if set, otherwise it's
+// computed from the wall clock. The duration is clamped to the stability
+// window, 36 hours on mainnet.
+func (builder *TXBuilder) SetTTLIn(d time.Duration) {
+	cfg := MainnetConfig
+	if builder.config != nil {
+		cfg = *builder.config
+	}
+	if d < 0 {
+		d = 0
+	}
+	if d > cfg.maxTTL() {
+		d = cfg.maxTTL()
+	}
+	currentSlot := cfg.SlotAt(time.Now())
+	if builder.tip != nil {
+		currentSlot = builder.tip.Slot
+	}
+	builder.ttl = currentSlot + uint64(d/cfg.SlotLength)
+}
+
+// WitnessSizeHint sets the size in bytes of the serialized witness set used to
+// calculate the fee, instead of assuming one vkey witness per input. This is
+// useful when the witnesses are known to differ, e.g. script witnesses.
+func (builder *TXBuilder) WitnessSizeHint(size int) {
+	builder.witnessSize = size
+}
+
 func (builder *TXBuilder) SetFee(fee uint64) {
 	builder.fee = fee
 }
 
+// SetExactFee sets the fee as is, Build won't recalculate it nor add a change
+// output but returns ErrFeeTooLow if it's lower than the protocol minimum.
+// This allows reproducing the exact bytes of an existing transaction.
+func (builder *TXBuilder) SetExactFee(fee uint64) {
+	builder.fee = fee
+	builder.exactFee = true
+}
+
+// MaxFee caps the fee, Build returns ErrFeeExceedsCap if the minimum fee of
+// the transaction is higher, e.g. because it spends too many small inputs.
+// A zero cap means no cap.
+func (builder *TXBuilder) MaxFee(fee uint64) {
+	builder.maxFee = fee
+}
+
+// CheckSize makes Build return ErrTxTooLarge if the transaction is larger
+// than the protocol MaxTxSize, so it can be split before submitting it.
+func (builder *TXBuilder) CheckSize() {
+	builder.checkSize = true
+}
+
+// SortOutputs makes Build encode the outputs in canonical order, sorted by
+// their serialized bytes, instead of the order they were added.
+func (builder *TXBuilder) SortOutputs() {
+	builder.sortOutputs = true
+}
+
+// SetFixedFee sets the fee as is, e.g. to overpay it, Build sends the rest of
+// the inputs to the change address but returns ErrFeeTooLow if the fee is
+// lower than the protocol minimum.
+func (builder *TXBuilder) SetFixedFee(fee uint64) {
+	builder.fee = fee
+	builder.fixedFee = true
+}
+
+// SetDescription sets the off-chain description of the built transaction.
+func (builder *TXBuilder) SetDescription(description string) {
+	builder.description = description
+}
+
+// SetMetadata sets the transaction metadata, its hash is set in the body
+// before calculating the fee and signing.
+func (builder *TXBuilder) SetMetadata(metadata transactionMetadata) {
+	builder.metadata = metadata
+}
+
+// SetMetadatum sets the metadatum of the label, e.g. a memo edited after the
+// transaction was built. The change output and the fee of a previous Build
+// are removed, the next Build recomputes the metadata hash and the fee and
+// signs the new body, the witnesses of previously built transactions are
+// invalid.
+func (builder *TXBuilder) SetMetadatum(label uint64, m transactionMetadatum) {
+	metadata := transactionMetadata{label: m}
+	for l, datum := range builder.metadata {
+		if l != label {
+			metadata[l] = datum
+		}
+	}
+	builder.metadata = metadata
+	builder.resetChange()
+}
+
+// resetChange removes the change output and the fee added by AddFee, the fee
+// set with SetFixedFee or SetExactFee is kept.
+func (builder *TXBuilder) resetChange() {
+	if builder.change == "" {
+		return
+	}
+	if builder.changeIndex >= 0 {
+		outputs := append([]TransactionOutput{}, builder.outputs[:builder.changeIndex]...)
+		builder.outputs = append(outputs, builder.outputs[builder.changeIndex+1:]...)
+	}
+	builder.changeIndex = -1
+	if !builder.fixedFee && !builder.exactFee {
+		builder.fee = 0
+	}
+}
+
+// reservedMetadata is the hash and serialized size of metadata attached after
+// signing.
+type reservedMetadata struct {
+	hash []byte
+	size int
+}
+
+// ReserveMetadata commits the body to the metadata of the hash and size in
+// bytes, attached to the signed transaction with Transaction.AttachMetadata.
+// Building fails with ErrMetadataHashMismatch if metadata of another hash is
+// also set with SetMetadata.
+func (builder *TXBuilder) ReserveMetadata(hash []byte, size int) {
+	builder.reserved = &reservedMetadata{hash: hash, size: size}
+}
+
+// AddMint mints the quantity of the asset of the script policy, or burns it if
+// negative. The script is added to the witness set, its keys must sign with
+// Sign. The minted tokens not sent by an output are sent to the change output.
+func (builder *TXBuilder) AddMint(script NativeScript, name AssetName, quantity int64) error {
+	if len(name) > 32 {
+		return fmt.Errorf("invalid asset name %x length %v", name, len(name))
+	}
+	policy, err := builder.AddPolicy(script)
+	if err != nil {
+		return err
+	}
+	builder.mint[policy][name] += quantity
+	return nil
+}
+
+// AddPolicy adds the minting policy script to the witness set, so its tokens
+// can be minted with MintTo.
+func (builder *TXBuilder) AddPolicy(script NativeScript) (PolicyID, error) {
+	policy, err := script.PolicyID()
+	if err != nil {
+		return "", err
+	}
+	if builder.mint == nil {
+		builder.mint = MintAssets{}
+	}
+	if builder.mint[policy] == nil {
+		builder.mint[policy] = map[AssetName]int64{}
+		builder.scripts = append(builder.scripts, script)
+	}
+	return policy, nil
+}
+
+// MintTo mints the quantity of the asset of a policy added with AddPolicy or
+// AddMint, and adds an output sending it to the address with the minimum utxo
+// value. Burns are added with AddMint.
+func (builder *TXBuilder) MintTo(addr Address, policy PolicyID, name AssetName, quantity uint64) error {
+	if _, ok := builder.mint[policy]; !ok {
+		return fmt.Errorf("unknown policy %v", policy)
+	}
+	if quantity > maxInt64 {
+		return fmt.Errorf("invalid mint quantity %v", quantity)
+	}
+	if len(name) > 32 {
+		return fmt.Errorf("invalid asset name %x length %v", name, len(name))
+	}
+	builder.mint[policy][name] += int64(quantity)
+	builder.AddAssetOutput(addr, policy, name, quantity)
+	return nil
+}
+
+// AddCertificate adds the certificate to the body, the key deposit of the
+// registrations is taken from the change and the deregistrations refund it.
+// The stake keys of the deregistrations and delegations must sign with Sign.
+func (builder *TXBuilder) AddCertificate(cert Certificate) {
+	builder.certs = append(builder.certs, cert)
+}
+
+// AddWithdrawal withdraws the rewards of the reward address, they are added
+// to the change. The stake key must sign with Sign.
+func (builder *TXBuilder) AddWithdrawal(rewardAddress Address, amount uint64) error {
+	if _, err := rewardAccount(rewardAddress); err != nil {
+		return err
+	}
+	if builder.withdrawals == nil {
+		builder.withdrawals = Withdrawals{}
+	}
+	builder.withdrawals[rewardAddress] = amount
+	return nil
+}
+
+// SetChangeAddress sets the address receiving the change, Build will then
+// calculate the fee and add the change output.
+func (builder *TXBuilder) SetChangeAddress(address Address) {
+	builder.change = address
+}
+
 // This assumes that the builder inputs and outputs are defined
 func (builder *TXBuilder) AddFee(address Address) error {
 	inputAmount := uint64(0)
 	for _, txIn := range builder.inputs {
 		inputAmount += txIn.amount
 	}
-	body := builder.buildBody()
+	body, err := builder.buildBody()
+	if err != nil {
+		return err
+	}
 
-	if err := body.addFee(inputAmount, address, builder.protocol); err != nil {
+	changeAssets, err := builder.changeAssets()
+	if err != nil {
+		return err
+	}
+	if builder.fixedFee {
+		err = body.addFixedFee(inputAmount, address, changeAssets, builder.protocol, builder.witnessSize)
+	} else {
+		err = body.addFee(inputAmount, address, changeAssets, builder.protocol, builder.witnessSize)
+	}
+	if err != nil {
 		return err
 	}
+	builder.changeIndex = -1
+	if len(body.Outputs) > len(builder.outputs) {
+		builder.changeIndex = 0
+	}
 	builder.outputs = body.Outputs
 	builder.fee = body.Fee
 	return nil
 }
 
+// ChangeIndex returns the index of the change output added by AddFee in the
+// built transaction outputs, or -1 if the change was too small and burned as
+// fee. The outputs are encoded in the order they were added, after the change,
+// or in canonical order with SortOutputs.
+func (builder *TXBuilder) ChangeIndex() int {
+	if builder.changeIndex < 0 || !builder.sortOutputs {
+		return builder.changeIndex
+	}
+	for i, index := range builder.outputOrder() {
+		if index == builder.changeIndex {
+			return i
+		}
+	}
+	return -1
+}
+
+// outputOrder returns the indexes of the builder outputs in encoding order.
+func (builder *TXBuilder) outputOrder() []int {
+	order := make([]int, len(builder.outputs))
+	for i := range order {
+		order[i] = i
+	}
+	if !builder.sortOutputs {
+		return order
+	}
+	encoded := make([][]byte, len(builder.outputs))
+	for i, output := range builder.outputs {
+		encoded[i], _ = canonicalEncMode.Marshal(output)
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return bytes.Compare(encoded[order[i]], encoded[order[j]]) < 0
+	})
+	return order
+}
+
+// changeAssets returns the tokens of the inputs and the mint which aren't sent
+// by the outputs or burned.
+func (builder *TXBuilder) changeAssets() (MultiAsset, error) {
+	available := Value{Assets: builder.mint.Minted()}
+	for _, txIn := range builder.inputs {
+		var err error
+		if available, err = available.Add(Value{Assets: txIn.assets}); err != nil {
+			return nil, err
+		}
+	}
+	spent := Value{}
+	for policy, assets := range builder.mint {
+		for name, quantity := range assets {
+			if quantity < 0 {
+				spent.Assets.set(policy, name, uint64(-quantity))
+			}
+		}
+	}
+	for _, txOut := range builder.outputs {
+		var err error
+		if spent, err = spent.Add(Value{Assets: txOut.Assets}); err != nil {
+			return nil, err
+		}
+	}
+	change, err := available.Sub(spent)
+	if err != nil {
+		return nil, fmt.Errorf("%w, %v", ErrInsufficientInput, err)
+	}
+	return change.Assets, nil
+}
+
 func (builder *TXBuilder) Sign(xsk crypto.ExtendedSigningKey) {
-	pkeyHashBytes := blake2b.Sum256(xsk)
-	pkeyHashString := hex.EncodeToString(pkeyHashBytes[:])
-	builder.pkeys[pkeyHashString] = xsk
+	builder.addSigner(&xsk)
 }
 
-func (builder *TXBuilder) Build() Transaction {
-	if len(builder.pkeys) != len(builder.vkeys) {
-		panic("missing signatures")
+// SignWith resolves the signing key of every input added with AddUtxo and of
+// the collateral inputs.
+func (builder *TXBuilder) SignWith(resolver KeyResolver) error {
+	for _, txIn := range append(append([]TXBuilderInput{}, builder.inputs...), builder.collateral...) {
+		if txIn.address == "" || txIn.redeemer != nil || txIn.native {
+			continue
+		}
+		signer, ok := resolver.KeyFor(txIn.address)
+		if !ok {
+			return fmt.Errorf("missing signing key for address %v", txIn.address)
+		}
+		xvk := signer.ExtendedVerificationKey()
+		vkeyHashBytes := blake2b.Sum256(xvk)
+		builder.vkeys[hex.EncodeToString(vkeyHashBytes[:])] = xvk
+		builder.addSigner(signer)
 	}
+	return nil
+}
 
-	body := builder.buildBody()
-	witnessSet := TransactionWitnessSet{}
+func (builder *TXBuilder) addSigner(signer Signer) {
+	vkeyHashBytes := blake2b.Sum256(signer.ExtendedVerificationKey())
+	vkeyHashString := hex.EncodeToString(vkeyHashBytes[:])
+	builder.pkeys[vkeyHashString] = signer
+}
+
+// Build balances the transaction if a change address is set and signs it.
+//
+// The change is the inputs minus the outputs and the fee, an error is returned
+// if the inputs don't cover them.
+func (builder *TXBuilder) Build() (Transaction, error) {
+	if builder.exactFee || builder.fixedFee && builder.change == "" {
+		if err := builder.validateFee(); err != nil {
+			return Transaction{}, err
+		}
+	} else if builder.change != "" {
+		if err := builder.AddFee(builder.change); err != nil {
+			return Transaction{}, err
+		}
+	}
+	for vkeyHash := range builder.vkeys {
+		if _, ok := builder.pkeys[vkeyHash]; !ok {
+			return Transaction{}, fmt.Errorf("missing signatures, got %v want %v", len(builder.pkeys), len(builder.vkeys))
+		}
+	}
+
+	body, err := builder.buildBody()
+	if err != nil {
+		return Transaction{}, err
+	}
+	if builder.maxFee != 0 {
+		minFee := body.calculateMinFeeWithWitnessSize(builder.protocol, builder.witnessSize)
+		if minFee > builder.maxFee {
+			return Transaction{}, fmt.Errorf("%w, got %v want atmost %v", ErrFeeExceedsCap, minFee, builder.maxFee)
+		}
+	}
+	witnessSet := body.witnessSet()
 	txHash := blake2b.Sum256(body.Bytes())
-	for _, pkey := range builder.pkeys {
-		publicKey := pkey.ExtendedVerificationKey()[:32]
+	for vkeyHash, pkey := range builder.pkeys {
+		xvk := pkey.ExtendedVerificationKey()
+		publicKey := xvk.VerificationKey()
 		signature := pkey.Sign(txHash[:])
+		if attributes, ok := builder.byronKeys[vkeyHash]; ok {
+			witness := BootstrapWitness{VKey: publicKey, Signature: signature, ChainCode: xvk[32:], Attributes: attributes}
+			witnessSet.Bootstrap = append(witnessSet.Bootstrap, witness)
+			continue
+		}
 		witness := VKeyWitness{VKey: publicKey, Signature: signature}
 		witnessSet.VKeyWitnessSet = append(witnessSet.VKeyWitnessSet, witness)
 	}
 
-	return Transaction{Body: body, WitnessSet: witnessSet, Metadata: nil}
+	tx := Transaction{Body: body, WitnessSet: witnessSet, Metadata: body.metadata}
+	tx.SetDescription(builder.description)
+	if builder.checkSize {
+		if err := tx.ValidateSize(builder.protocol); err != nil {
+			return Transaction{}, err
+		}
+	}
+	return tx, nil
 }
 
-func (builder *TXBuilder) buildBody() TransactionBody {
-	inputs := make([]TransactionInput, len(builder.inputs))
-	for i, txInput := range builder.inputs {
+func (builder *TXBuilder) validateFee() error {
+	body, err := builder.buildBody()
+	if err != nil {
+		return err
+	}
+	minFee := body.calculateMinFeeWithWitnessSize(builder.protocol, builder.witnessSize)
+	if builder.fee < minFee {
+		return fmt.Errorf("%w, got %v want atleast %v", ErrFeeTooLow, builder.fee, minFee)
+	}
+	return nil
+}
+
+// mintedPolicies returns the mint without the policies which mint nothing,
+// e.g. added with AddPolicy only or whose mints and burns cancel out, and the
+// native scripts without these policies' scripts.
+func (builder *TXBuilder) mintedPolicies() (MintAssets, []NativeScript) {
+	var mint MintAssets
+	dropped := map[PolicyID]bool{}
+	for policy, assets := range builder.mint {
+		if (MintAssets{policy: assets}).IsEmpty() {
+			dropped[policy] = true
+			continue
+		}
+		if mint == nil {
+			mint = MintAssets{}
+		}
+		mint[policy] = assets
+	}
+	if len(dropped) == 0 {
+		return mint, builder.scripts
+	}
+
+	// The scripts of the native script inputs are kept
+	spent := map[PolicyID]bool{}
+	for _, txIn := range builder.inputs {
+		if _, addressBytes, err := bech32.DecodeToBase256(string(txIn.address)); txIn.native && err == nil && len(addressBytes) >= 29 {
+			spent[PolicyID(hex.EncodeToString(addressBytes[1:29]))] = true
+		}
+	}
+	var scripts []NativeScript
+	for _, script := range builder.scripts {
+		if policy, err := script.PolicyID(); err == nil && dropped[policy] && !spent[policy] {
+			continue
+		}
+		scripts = append(scripts, script)
+	}
+	return mint, scripts
+}
+
+// checkPolicyScripts returns ErrMissingPolicyScript listing the policies of
+// the mint without a script.
+func checkPolicyScripts(mint MintAssets, scripts []NativeScript) error {
+	attached := map[PolicyID]bool{}
+	for _, script := range scripts {
+		if policy, err := script.PolicyID(); err == nil {
+			attached[policy] = true
+		}
+	}
+	missing := []string{}
+	for policy := range mint {
+		if !attached[policy] {
+			missing = append(missing, string(policy))
+		}
+	}
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("%w, got none for %v", ErrMissingPolicyScript, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// checkTimeLock returns ErrTimeLocked if the script can't be satisfied in the
+// validity interval of the transaction.
+func (builder *TXBuilder) checkTimeLock(script NativeScript) error {
+	var start uint64
+	if builder.start != nil {
+		start = *builder.start
+	}
+	if !script.allowsInterval(start, builder.ttl) {
+		return fmt.Errorf("%w, got validity start %v and ttl %v", ErrTimeLocked, start, builder.ttl)
+	}
+	return nil
+}
+
+func (builder *TXBuilder) buildBody() (TransactionBody, error) {
+	if builder.start != nil && builder.ttl != 0 && *builder.start >= builder.ttl {
+		return TransactionBody{}, fmt.Errorf("invalid validity interval, start %v isn't before ttl %v", *builder.start, builder.ttl)
+	}
+
+	// The ledger sorts the inputs, the spend redeemers point to this order
+	sorted := make([]TXBuilderInput, len(builder.inputs))
+	copy(sorted, builder.inputs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return lessInput(sorted[i].input, sorted[j].input)
+	})
+	inputs := make([]TransactionInput, len(sorted))
+	var redeemers []Redeemer
+	scriptInputs := 0
+	for i, txInput := range sorted {
+		if txInput.native {
+			scriptInputs++
+		}
 		inputs[i] = TransactionInput{
 			ID:    txInput.input.ID,
 			Index: txInput.input.Index,
 		}
+		if txInput.redeemer != nil {
+			redeemer := *txInput.redeemer
+			redeemer.Index = uint64(i)
+			redeemers = append(redeemers, redeemer)
+		}
 	}
 
-	return TransactionBody{
-		Inputs:  inputs,
-		Outputs: builder.outputs,
-		Fee:     builder.fee,
-		Ttl:     builder.ttl,
+	mint, scripts := builder.mintedPolicies()
+	if err := checkPolicyScripts(mint, scripts); err != nil {
+		return TransactionBody{}, err
+	}
+	// The time locks of the policies and the native script inputs must all
+	// allow the validity interval
+	for _, script := range scripts {
+		if err := builder.checkTimeLock(script); err != nil {
+			hash := script.Hash()
+			return TransactionBody{}, fmt.Errorf("native script %x: %w", hash, err)
+		}
+	}
+	outputs := builder.outputs
+	if builder.sortOutputs {
+		outputs = make([]TransactionOutput, len(builder.outputs))
+		for i, index := range builder.outputOrder() {
+			outputs[i] = builder.outputs[index]
+		}
+	}
+	body := TransactionBody{
+		Inputs:        inputs,
+		Outputs:       outputs,
+		Fee:           builder.fee,
+		Ttl:           builder.ttl,
+		ValidityStart: builder.start,
+		Certificates:  builder.certs,
+		Withdrawals:   builder.withdrawals,
+		Mint:          mint,
+
+		nativeScripts: scripts,
+		scriptInputs:  scriptInputs,
+		redeemers:     redeemers,
+		plutusScripts: builder.plutus,
+	}
+	for _, collateral := range builder.collateral {
+		body.Collateral = append(body.Collateral, collateral.input)
+	}
+	sort.SliceStable(body.Collateral, func(i, j int) bool {
+		return lessInput(body.Collateral[i], body.Collateral[j])
+	})
+	for _, attributes := range builder.byronKeys {
+		body.byronAttributes = append(body.byronAttributes, attributes)
+	}
+	if len(redeemers) != 0 {
+		costModels, err := builder.protocol.CostModels.forLanguages(plutusLanguages(builder.plutus))
+		if err != nil {
+			return TransactionBody{}, err
+		}
+		hash, err := ScriptDataHash(redeemers, nil, costModels)
+		if err != nil {
+			return TransactionBody{}, err
+		}
+		body.ScriptDataHash = hash
+	}
+	if err := body.SetMetadata(builder.metadata); err != nil {
+		return TransactionBody{}, err
+	}
+	if reserved := builder.reserved; reserved != nil {
+		if body.MetadataHash == nil {
+			body.ReserveMetadata(reserved.hash, reserved.size)
+		} else if !bytes.Equal(*body.MetadataHash, reserved.hash) {
+			return TransactionBody{}, fmt.Errorf("%w, got %x want %x", ErrMetadataHashMismatch, *body.MetadataHash, reserved.hash)
+		}
 	}
+	return body, nil
 }
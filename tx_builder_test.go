@@ -1,13 +1,23 @@
 package cardano
 
 import (
-	"github.com/tclairet/cardano-go/crypto"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/tclairet/cardano-go/crypto"
+	"golang.org/x/crypto/blake2b"
 )
 
 func TestTXBuilder_AddFee(t *testing.T) {
 	key := crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
-	receiver := NewEnterpriseAddress(key.ExtendedVerificationKey(), Testnet)
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
 	type fields struct {
 		tx       Transaction
 		protocol ProtocolParams
@@ -163,7 +173,7 @@ func TestTXBuilder_AddFee(t *testing.T) {
 				ttl:      tt.fields.ttl,
 			}
 			key := crypto.NewExtendedSigningKey([]byte("change address"), "foo")
-			change := NewEnterpriseAddress(key.ExtendedVerificationKey(), Testnet)
+			change := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
 			if err := builder.AddFee(change); err != nil {
 				if tt.wantErr {
 					return
@@ -195,3 +205,1551 @@ func TestTXBuilder_AddFee(t *testing.T) {
 		})
 	}
 }
+
+type mapResolver map[Address]crypto.ExtendedSigningKey
+
+func (r mapResolver) KeyFor(addr Address) (Signer, bool) {
+	key, ok := r[addr]
+	if !ok {
+		return nil, false
+	}
+	return &key, true
+}
+
+func TestTXBuilder_SignWith(t *testing.T) {
+	resolver := mapResolver{}
+	var addresses []Address
+	for _, seed := range []string{"input 0", "input 1"} {
+		key := crypto.NewExtendedSigningKey([]byte(seed), "foo")
+		addr := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+		resolver[addr] = key
+		addresses = append(addresses, addr)
+	}
+	key := crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	builder := NewTxBuilder(ShelleyProtocol)
+	txId := TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1")
+	builder.AddUtxo(Utxo{Address: addresses[0], TxId: txId, Index: 0, Amount: 2000000})
+	builder.AddUtxo(Utxo{Address: addresses[1], TxId: txId, Index: 1, Amount: 2000000})
+	builder.AddOutput(receiver, 1000000)
+	builder.SetTtl(100)
+	if err := builder.AddFee(addresses[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := builder.SignWith(resolver); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(tx.WitnessSet.VKeyWitnessSet), 2; got != want {
+		t.Fatalf("got %v witnesses want %v", got, want)
+	}
+	txHash := blake2b.Sum256(tx.Body.Bytes())
+	for _, witness := range tx.WitnessSet.VKeyWitnessSet {
+		vkey := crypto.ExtendedVerificationKey(witness.VKey)
+		if !vkey.Verify(txHash[:], witness.Signature) {
+			t.Errorf("invalid signature for vkey %x", witness.VKey)
+		}
+	}
+
+	builder.AddUtxo(Utxo{Address: receiver, TxId: txId, Index: 2, Amount: 2000000})
+	if err := builder.SignWith(resolver); err == nil {
+		t.Errorf("expected missing signing key error")
+	}
+}
+
+func TestTXBuilder_BuildWithChange(t *testing.T) {
+	txId := TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1")
+	key := crypto.NewExtendedSigningKey([]byte("change address"), "foo")
+	change := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	var receivers []Address
+	for _, seed := range []string{"receiver 0", "receiver 1"} {
+		key := crypto.NewExtendedSigningKey([]byte(seed), "foo")
+		receivers = append(receivers, NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey())))
+	}
+
+	builder := NewTxBuilder(ShelleyProtocol)
+	builder.AddInputWithoutSig(txId, 0, 3000000)
+	builder.AddInputWithoutSig(txId, 1, 2000000)
+	builder.AddOutput(receivers[0], 1500000)
+	builder.AddOutput(receivers[1], 1500000)
+	builder.SetChangeAddress(change)
+	builder.SetTtl(100)
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(tx.Body.Outputs), 3; got != want {
+		t.Fatalf("got %v outputs want %v", got, want)
+	}
+	_, gotChange, _ := DecodeAddress(tx.Body.Outputs[0].Address)
+	if gotChange != change {
+		t.Errorf("got %v want %v", gotChange, change)
+	}
+	if got, want := tx.Body.Outputs[0].Amount, 5000000-3000000-tx.Body.Fee; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := tx.Body.Fee, tx.Body.calculateMinFee(ShelleyProtocol); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	builder = NewTxBuilder(ShelleyProtocol)
+	builder.AddInputWithoutSig(txId, 0, 3000000)
+	builder.AddOutput(receivers[0], 3000000)
+	builder.SetChangeAddress(change)
+	if _, err := builder.Build(); !errors.Is(err, ErrInsufficientInput) {
+		t.Errorf("got error %v want %v", err, ErrInsufficientInput)
+	}
+}
+
+func TestTXBuilder_SetTTLIn(t *testing.T) {
+	builder := NewTxBuilder(ShelleyProtocol)
+	builder.SetTip(NodeTip{Slot: 50000000})
+
+	builder.SetTTLIn(time.Hour)
+	if got, want := builder.ttl, uint64(50003600); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	builder.SetTTLIn(100 * time.Hour)
+	if got, want := builder.ttl, uint64(50129600); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	builder = NewTxBuilder(ShelleyProtocol)
+	before := LiveTTL()
+	builder.SetTTLIn(time.Hour)
+	if got := builder.ttl; got < before+3600 || got > LiveTTL()+3600 {
+		t.Errorf("got %v want around %v", got, before+3600)
+	}
+}
+
+func TestTXBuilder_WitnessSizeHint(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	key = crypto.NewExtendedSigningKey([]byte("change address"), "foo")
+	change := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	txId := TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1")
+
+	fee := func(hint int) uint64 {
+		builder := NewTxBuilder(ShelleyProtocol)
+		builder.AddInputWithoutSig(txId, 0, 5000000)
+		builder.AddOutput(receiver, 1000000)
+		builder.SetTtl(100)
+		builder.WitnessSizeHint(hint)
+		if err := builder.AddFee(change); err != nil {
+			t.Fatal(err)
+		}
+		return builder.fee
+	}
+
+	defaultFee := fee(0)
+	// A witness set with a single vkey witness is serialized in 104 bytes
+	if got := fee(104); got != defaultFee {
+		t.Errorf("got %v want %v", got, defaultFee)
+	}
+	if got, want := fee(200), defaultFee+96*ShelleyProtocol.MinFeeA; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestTransaction_ChangeUtxoChaining(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	key = crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	utxo := Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  10000000,
+	}
+	txs := []*Transaction{}
+	for i := 0; i < 3; i++ {
+		builder := NewTxBuilder(ShelleyProtocol)
+		builder.AddUtxo(utxo)
+		builder.AddOutput(receiver, 1000000)
+		builder.SetChangeAddress(payer)
+		builder.SetTtl(100)
+		if err := builder.SignWith(resolver); err != nil {
+			t.Fatal(err)
+		}
+		tx, err := builder.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := tx.Body.Inputs[0].ID, utxo.TxId.Bytes(); !bytes.Equal(got, want) {
+			t.Errorf("tx %v: got input %x want %x", i, got, want)
+		}
+		if got, want := tx.Body.Inputs[0].Index, utxo.Index; got != want {
+			t.Errorf("tx %v: got input index %v want %v", i, got, want)
+		}
+
+		change, err := tx.ChangeUtxo(payer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := change.TxId, tx.ID(); got != want {
+			t.Errorf("tx %v: got change txid %v want %v", i, got, want)
+		}
+		if got, want := change.Amount, utxo.Amount-1000000-tx.Body.Fee; got != want {
+			t.Errorf("tx %v: got change amount %v want %v", i, got, want)
+		}
+		utxo = change
+		txs = append(txs, &tx)
+	}
+
+	// The chain of payments is only paid by its fees
+	if got, want := TotalFee(txs), 10000000-3*1000000-utxo.Amount; got != want {
+		t.Errorf("got total fee %v want %v", got, want)
+	}
+
+	tx := &Transaction{Body: TransactionBody{Outputs: []TransactionOutput{{Address: receiver.Bytes(), Amount: 1}}}}
+	if _, err := tx.ChangeUtxo(payer); err == nil {
+		t.Errorf("expected missing change output error")
+	}
+}
+
+func TestTXBuilder_SetExactFee(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	key = crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	utxo := Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  10000000,
+	}
+	newBuilder := func(fee uint64) *TXBuilder {
+		builder := NewTxBuilder(ShelleyProtocol)
+		builder.AddUtxo(utxo)
+		builder.AddOutput(receiver, utxo.Amount-fee)
+		builder.SetChangeAddress(payer)
+		builder.SetTtl(100)
+		builder.SetExactFee(fee)
+		if err := builder.SignWith(resolver); err != nil {
+			t.Fatal(err)
+		}
+		return builder
+	}
+
+	tx, err := newBuilder(200000).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tx.Body.Fee, uint64(200000); got != want {
+		t.Errorf("got fee %v want %v", got, want)
+	}
+	if got, want := len(tx.Body.Outputs), 1; got != want {
+		t.Errorf("got %v outputs want %v", got, want)
+	}
+
+	if _, err := newBuilder(1000).Build(); !errors.Is(err, ErrFeeTooLow) {
+		t.Errorf("got error %v want %v", err, ErrFeeTooLow)
+	}
+}
+
+func TestTXBuilder_SetDescription(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	builder := NewTxBuilder(ShelleyProtocol)
+	builder.AddUtxo(Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Amount:  5000000,
+	})
+	builder.AddOutput(payer, 2000000)
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(100)
+	builder.SetDescription("order 42")
+	if err := builder.SignWith(mapResolver{payer: key}); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tx.Description(), "order 42"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	envelope, err := tx.TextEnvelope()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := cardanoCliTx{}
+	if err := json.Unmarshal(envelope, &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := (cardanoCliTx{Type: "Tx MaryEra", Description: "order 42", CborHex: tx.CborHex()}); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	// The description doesn't go on-chain
+	without := tx
+	without.SetDescription("")
+	if !bytes.Equal(tx.Bytes(), without.Bytes()) {
+		t.Errorf("description changed the transaction bytes")
+	}
+}
+
+func TestTXBuilder_SetMetadata(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	key = crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	metadata := transactionMetadata{674: MetadatumMap(MetadatumPair{
+		Key:   MetadatumText("msg"),
+		Value: MetadatumList(MetadatumText("invoice 42")),
+	})}
+
+	builder := NewTxBuilder(ShelleyProtocol)
+	builder.AddUtxo(Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  10000000,
+	})
+	builder.AddOutput(receiver, 2000000)
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(100)
+	builder.SetMetadata(metadata)
+	if err := builder.SignWith(resolver); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tx.Body.MetadataHash == nil {
+		t.Fatal("missing metadata hash")
+	}
+	if err := tx.VerifyMetadataHash(); err != nil {
+		t.Error(err)
+	}
+	if got, want := tx.Body.Fee, CalculateFee(&tx, ShelleyProtocol); got < want {
+		t.Errorf("got fee %v want atleast %v", got, want)
+	}
+
+	decoded, err := DecodeTransaction(tx.CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.VerifyMetadataHash(); err != nil {
+		t.Error(err)
+	}
+	if err := decoded.VerifySignatures(); err != nil {
+		t.Error(err)
+	}
+
+	decoded.Body.MetadataHash = nil
+	if err := decoded.VerifyMetadataHash(); err == nil {
+		t.Error("expected an error without metadata hash")
+	}
+}
+
+func TestTXBuilder_SetMetadatum(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	key = crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	builder := NewTxBuilder(ShelleyProtocol)
+	builder.AddUtxo(Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  10000000,
+	})
+	builder.AddOutput(receiver, 2000000)
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(100)
+	builder.SetMetadatum(674, MetadatumText("memo"))
+	if err := builder.SignWith(resolver); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memo := []transactionMetadatum{}
+	for i := 0; i < 10; i++ {
+		memo = append(memo, MetadatumText(strings.Repeat("x", 64)))
+	}
+	builder.SetMetadatum(674, MetadatumList(memo...))
+	edited, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if edited.Body.Fee <= tx.Body.Fee {
+		t.Errorf("got fee %v want more than %v", edited.Body.Fee, tx.Body.Fee)
+	}
+	if got, want := edited.Body.Fee, CalculateFee(&edited, ShelleyProtocol); got < want {
+		t.Errorf("got fee %v want atleast %v", got, want)
+	}
+	if got, want := len(edited.Body.Outputs), len(tx.Body.Outputs); got != want {
+		t.Errorf("got %v outputs want %v", got, want)
+	}
+	outputAmount := edited.Body.Fee
+	for _, txOut := range edited.Body.Outputs {
+		outputAmount += txOut.Amount
+	}
+	if got, want := outputAmount, uint64(10000000); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if err := edited.VerifyMetadataHash(); err != nil {
+		t.Error(err)
+	}
+	if err := edited.VerifySignatures(); err != nil {
+		t.Error(err)
+	}
+	if bytes.Equal(*edited.Body.MetadataHash, *tx.Body.MetadataHash) {
+		t.Error("metadata hash not recomputed")
+	}
+}
+
+func TestTXBuilder_ReserveMetadata(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	key = crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	metadata := transactionMetadata{674: MetadatumText("invoice 42")}
+	hash, err := metadata.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadataBytes, err := metadata.MarshalCBOR()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newBuilder := func() *TXBuilder {
+		builder := NewTxBuilder(ShelleyProtocol)
+		builder.AddUtxo(Utxo{
+			Address: payer,
+			TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+			Index:   0,
+			Amount:  10000000,
+		})
+		builder.AddOutput(receiver, 2000000)
+		builder.SetChangeAddress(payer)
+		builder.SetTtl(100)
+		builder.ReserveMetadata(hash, len(metadataBytes))
+		return builder
+	}
+
+	builder := newBuilder()
+	if err := builder.SignWith(resolver); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Metadata != nil {
+		t.Fatal("reserved metadata attached before signing")
+	}
+
+	other := transactionMetadata{674: MetadatumText("invoice 43")}
+	if err := tx.AttachMetadata(other); !errors.Is(err, ErrMetadataHashMismatch) {
+		t.Errorf("got %v want %v", err, ErrMetadataHashMismatch)
+	}
+	if err := tx.AttachMetadata(metadata); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.VerifyMetadataHash(); err != nil {
+		t.Error(err)
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		t.Error(err)
+	}
+	if got, want := tx.Body.Fee, CalculateFee(&tx, ShelleyProtocol); got < want {
+		t.Errorf("got fee %v want atleast %v", got, want)
+	}
+
+	builder = newBuilder()
+	builder.SetMetadata(other)
+	if _, err := builder.Build(); !errors.Is(err, ErrMetadataHashMismatch) {
+		t.Errorf("got %v want %v", err, ErrMetadataHashMismatch)
+	}
+}
+
+func TestTXBuilder_AddMint(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	key = crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	policyKey := crypto.NewExtendedSigningKey([]byte("policy"), "foo")
+
+	script := NewScriptAll(NewScriptPubKey(keyHash(policyKey.ExtendedVerificationKey())), NewScriptInvalidHereafter(1000))
+	policy, err := script.PolicyID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builder := NewTxBuilder(ShelleyProtocol)
+	builder.AddUtxo(Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  10000000,
+	})
+	if err := builder.AddMint(script, "nft", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := builder.AddMint(script, "token", 5); err != nil {
+		t.Fatal(err)
+	}
+	builder.AddOutputValue(receiver, Value{Coin: 2000000, Assets: MultiAsset{policy: {"nft": 1}}})
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(100)
+	if err := builder.SignWith(resolver); err != nil {
+		t.Fatal(err)
+	}
+	builder.Sign(policyKey)
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeTransaction(tx.CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := decoded.Body.Mint, (MintAssets{policy: {"nft": 1, "token": 5}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got mint %v want %v", got, want)
+	}
+	if got, want := len(decoded.WitnessSet.NativeScripts), 1; got != want {
+		t.Errorf("got %v native scripts want %v", got, want)
+	}
+	if got, want := len(decoded.WitnessSet.VKeyWitnessSet), 2; got != want {
+		t.Errorf("got %v vkey witnesses want %v", got, want)
+	}
+	if err := decoded.VerifySignatures(); err != nil {
+		t.Error(err)
+	}
+	if got, want := decoded.Body.Outputs[0].Assets, (MultiAsset{policy: {"token": 5}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got change assets %v want %v", got, want)
+	}
+	if got, want := tx.Body.Fee, CalculateFee(&tx, ShelleyProtocol); got < want {
+		t.Errorf("got fee %v want atleast %v", got, want)
+	}
+}
+
+func TestTXBuilder_ChangeAssets(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	key = crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	newBuilder := func(sent uint64) *TXBuilder {
+		builder := NewTxBuilder(ShelleyProtocol)
+		builder.AddUtxo(Utxo{
+			Address: payer,
+			TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+			Index:   0,
+			Amount:  10000000,
+			Assets:  MultiAsset{testPolicy: {"token": 10}},
+		})
+		builder.AddOutputValue(receiver, Value{Coin: 2000000, Assets: MultiAsset{testPolicy: {"token": sent}}})
+		builder.SetChangeAddress(payer)
+		builder.SetTtl(100)
+		if err := builder.SignWith(resolver); err != nil {
+			t.Fatal(err)
+		}
+		return builder
+	}
+
+	tx, err := newBuilder(3).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tx.Body.Outputs[0].Assets, (MultiAsset{testPolicy: {"token": 7}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got change assets %v want %v", got, want)
+	}
+
+	if _, err := newBuilder(11).Build(); !errors.Is(err, ErrInsufficientInput) {
+		t.Errorf("got error %v want %v", err, ErrInsufficientInput)
+	}
+}
+
+func TestTXBuilder_ConsolidatedChangeAssets(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	key = crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	builder := NewTxBuilder(ShelleyProtocol)
+	for i, quantity := range []uint64{4, 5, 6} {
+		builder.AddUtxo(Utxo{
+			Address: payer,
+			TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+			Index:   uint64(i),
+			Amount:  3000000,
+			Assets:  MultiAsset{testPolicy: {"token": quantity}},
+		})
+	}
+	builder.AddOutputValue(receiver, Value{Coin: 2000000, Assets: MultiAsset{testPolicy: {"token": 3}}})
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(100)
+	if err := builder.SignWith(resolver); err != nil {
+		t.Fatal(err)
+	}
+
+	// The quantities of the inputs are summed in a single entry
+	changeAssets, err := builder.changeAssets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := changeAssets, (MultiAsset{testPolicy: {"token": 12}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got change assets %v want %v", got, want)
+	}
+
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(tx.Body.Outputs), 2; got != want {
+		t.Fatalf("got %v outputs want %v", got, want)
+	}
+	change, err := tx.ChangeUtxo(payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := change.Assets, (MultiAsset{testPolicy: {"token": 12}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got change assets %v want %v", got, want)
+	}
+}
+
+func TestTXBuilder_AddOutputValueTokens(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	key = crypto.NewExtendedSigningKey([]byte("receiver 1"), "foo")
+	receiver1 := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	key = crypto.NewExtendedSigningKey([]byte("receiver 2"), "foo")
+	receiver2 := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	otherPolicy := PolicyID("00000000000000000000000000000000000000000000000000000002")
+
+	inputAmount := uint64(10000000)
+	builder := NewTxBuilder(ShelleyProtocol)
+	builder.AddUtxo(Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  inputAmount,
+		Assets:  MultiAsset{testPolicy: {"token": 10}, otherPolicy: {"coin": 20}},
+	})
+	builder.AddOutputValue(receiver1, Value{Coin: 2000000, Assets: MultiAsset{testPolicy: {"token": 3}}})
+	builder.AddOutputValue(receiver2, Value{Coin: 1500000, Assets: MultiAsset{otherPolicy: {"coin": 5}}})
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(100)
+	if err := builder.SignWith(resolver); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeTransaction(tx.CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	change, err := decoded.ChangeUtxo(payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := change.Assets, (MultiAsset{testPolicy: {"token": 7}, otherPolicy: {"coin": 15}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got change assets %v want %v", got, want)
+	}
+
+	outputAmount := decoded.Body.Fee
+	outputAssets := MultiAsset{}
+	for _, txOut := range decoded.Body.Outputs {
+		outputAmount += txOut.Amount
+		for policy, assets := range txOut.Assets {
+			if outputAssets[policy] == nil {
+				outputAssets[policy] = map[AssetName]uint64{}
+			}
+			for name, quantity := range assets {
+				outputAssets[policy][name] += quantity
+			}
+		}
+	}
+	if got, want := outputAmount, inputAmount; got != want {
+		t.Errorf("got outputs plus fee %v want %v", got, want)
+	}
+	if got, want := outputAssets, (MultiAsset{testPolicy: {"token": 10}, otherPolicy: {"coin": 20}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got output assets %v want %v", got, want)
+	}
+	if got, want := tx.Body.Fee, CalculateFee(&tx, ShelleyProtocol); got < want {
+		t.Errorf("got fee %v want atleast %v", got, want)
+	}
+}
+
+func TestTXBuilder_MintPolicies(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	firstKey := crypto.NewExtendedSigningKey([]byte("first policy"), "foo")
+	secondKey := crypto.NewExtendedSigningKey([]byte("second policy"), "foo")
+	first := NewScriptPubKey(keyHash(firstKey.ExtendedVerificationKey()))
+	second := NewScriptPubKey(keyHash(secondKey.ExtendedVerificationKey()))
+	firstPolicy, err := first.PolicyID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondPolicy, err := second.PolicyID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builder := NewTxBuilder(ShelleyProtocol)
+	builder.AddUtxo(Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  10000000,
+	})
+	if err := builder.AddMint(first, "a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := builder.AddMint(second, "b", 2); err != nil {
+		t.Fatal(err)
+	}
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(100)
+	if err := builder.SignWith(resolver); err != nil {
+		t.Fatal(err)
+	}
+	builder.Sign(firstKey)
+	builder.Sign(secondKey)
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeTransaction(tx.CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := decoded.Body.Mint, (MintAssets{firstPolicy: {"a": 1}, secondPolicy: {"b": 2}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got mint %v want %v", got, want)
+	}
+	if got, want := len(decoded.WitnessSet.NativeScripts), 2; got != want {
+		t.Errorf("got %v native scripts want %v", got, want)
+	}
+	if got, want := len(decoded.WitnessSet.VKeyWitnessSet), 3; got != want {
+		t.Errorf("got %v vkey witnesses want %v", got, want)
+	}
+
+	// A policy without its script is reported
+	builder.scripts = builder.scripts[:1]
+	_, err = builder.Build()
+	if !errors.Is(err, ErrMissingPolicyScript) {
+		t.Fatalf("got error %v want %v", err, ErrMissingPolicyScript)
+	}
+	if !strings.Contains(err.Error(), string(secondPolicy)) || strings.Contains(err.Error(), string(firstPolicy)) {
+		t.Errorf("got error %v want only policy %v", err, secondPolicy)
+	}
+}
+
+func TestTXBuilder_TimeLockedMint(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	policyKey := crypto.NewExtendedSigningKey([]byte("policy"), "foo")
+	script := NewTimeLockedMintPolicy(keyHash(policyKey.ExtendedVerificationKey()), 1000)
+
+	testcases := []struct {
+		name string
+		ttl  uint64
+		err  error
+	}{
+		{name: "before deadline", ttl: 999},
+		{name: "at deadline", ttl: 1000},
+		{name: "after deadline", ttl: 1001, err: ErrTimeLocked},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			builder := NewTxBuilder(ShelleyProtocol)
+			builder.AddUtxo(Utxo{
+				Address: payer,
+				TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+				Index:   0,
+				Amount:  10000000,
+			})
+			if err := builder.AddMint(script, "nft", 1); err != nil {
+				t.Fatal(err)
+			}
+			builder.SetChangeAddress(payer)
+			builder.SetTtl(tc.ttl)
+			if err := builder.SignWith(resolver); err != nil {
+				t.Fatal(err)
+			}
+			builder.Sign(policyKey)
+			tx, err := builder.Build()
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got error %v want %v", err, tc.err)
+			}
+			if err != nil {
+				return
+			}
+			if !script.IsSatisfiedBy(tx.WitnessSet.VKeyWitnessSet, 0, tx.Body.Ttl) {
+				t.Errorf("policy not satisfied by the transaction")
+			}
+		})
+	}
+}
+
+func TestTXBuilder_AddAssetOutput(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	key = crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	protocol := ShelleyProtocol
+	protocol.CoinsPerUTxOByte = 4310
+
+	builder := NewTxBuilder(protocol)
+	builder.AddUtxo(Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  10000000,
+		Assets:  MultiAsset{testPolicy: {"token": 150}},
+	})
+	builder.AddAssetOutput(receiver, testPolicy, "token", 100)
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(100)
+	if err := builder.SignWith(resolver); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := tx.Body.Outputs[1]
+	if got, want := output.Assets, (MultiAsset{testPolicy: {"token": 100}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got assets %v want %v", got, want)
+	}
+	if got, want := output.Amount, MinUTXO(output, protocol); got != want {
+		t.Errorf("got amount %v want %v", got, want)
+	}
+	if got, want := tx.Body.Outputs[0].Assets, (MultiAsset{testPolicy: {"token": 50}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got change assets %v want %v", got, want)
+	}
+	if got, want := tx.Body.Outputs[0].Amount+output.Amount+tx.Body.Fee, uint64(10000000); got != want {
+		t.Errorf("got outputs and fee %v want %v", got, want)
+	}
+}
+
+func TestTXBuilder_EmptyMint(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	policyKey := crypto.NewExtendedSigningKey([]byte("policy"), "foo")
+	script := NewScriptPubKey(keyHash(policyKey.ExtendedVerificationKey()))
+
+	testcases := []struct {
+		name string
+		mint func(builder *TXBuilder) error
+	}{
+		{
+			name: "policy without mint",
+			mint: func(builder *TXBuilder) error {
+				_, err := builder.AddPolicy(script)
+				return err
+			},
+		},
+		{
+			name: "mint and burn",
+			mint: func(builder *TXBuilder) error {
+				if err := builder.AddMint(script, "token", 5); err != nil {
+					return err
+				}
+				return builder.AddMint(script, "token", -5)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			builder := NewTxBuilder(ShelleyProtocol)
+			builder.AddUtxo(Utxo{
+				Address: payer,
+				TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+				Index:   0,
+				Amount:  10000000,
+			})
+			if err := tc.mint(builder); err != nil {
+				t.Fatal(err)
+			}
+			builder.SetChangeAddress(payer)
+			builder.SetTtl(100)
+			if err := builder.SignWith(resolver); err != nil {
+				t.Fatal(err)
+			}
+			tx, err := builder.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := tx.Body.RawField(9); got != nil {
+				t.Errorf("got mint field %x want none", []byte(got))
+			}
+			if got := tx.WitnessSet.NativeScripts; len(got) != 0 {
+				t.Errorf("got %v native scripts want none", len(got))
+			}
+			if got, want := len(tx.WitnessSet.VKeyWitnessSet), 1; got != want {
+				t.Errorf("got %v vkey witnesses want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestTransactionBody_EmptyMintCBOR(t *testing.T) {
+	body := TransactionBody{
+		Inputs:  []TransactionInput{{ID: make([]byte, 32), Index: 0}},
+		Outputs: []TransactionOutput{{Address: make([]byte, 29), Amount: 1000000}},
+		Fee:     200000,
+		Ttl:     100,
+	}
+	want := body.CborHex()
+	for _, mint := range []MintAssets{{}, {testPolicy: {}}, {testPolicy: {"token": 0}}} {
+		body.Mint = mint
+		if got := body.CborHex(); got != want {
+			t.Errorf("got %v want %v with mint %v", got, want, mint)
+		}
+	}
+}
+
+func TestTXBuilder_ChangeIndex(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	key = crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	testcases := []struct {
+		name   string
+		amount uint64
+		want   int
+	}{
+		{name: "change", amount: 2000000, want: 0},
+		{name: "burned change", amount: 9500000, want: -1},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			builder := NewTxBuilder(ShelleyProtocol)
+			builder.AddUtxo(Utxo{
+				Address: payer,
+				TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+				Index:   0,
+				Amount:  10000000,
+			})
+			builder.AddOutput(receiver, tc.amount)
+			builder.SetChangeAddress(payer)
+			builder.SetTtl(100)
+			if err := builder.SignWith(resolver); err != nil {
+				t.Fatal(err)
+			}
+			tx, err := builder.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := builder.ChangeIndex(), tc.want; got != want {
+				t.Fatalf("got change index %v want %v", got, want)
+			}
+			if tc.want < 0 {
+				return
+			}
+			change, err := tx.ChangeUtxo(payer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := change.Index, uint64(tc.want); got != want {
+				t.Errorf("got change utxo index %v want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestTXBuilder_SortOutputs(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+
+	builder := NewTxBuilder(ShelleyProtocol)
+	builder.AddUtxo(Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  20000000,
+	})
+	// The change to the payer sorts after some receivers
+	for _, seed := range []string{"receiver 1", "receiver 2", "receiver 3", "receiver 4"} {
+		key := crypto.NewExtendedSigningKey([]byte(seed), "foo")
+		builder.AddOutput(NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey())), 2000000)
+	}
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(100)
+	builder.SortOutputs()
+	if err := builder.SignWith(resolver); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i < len(tx.Body.Outputs); i++ {
+		prev, _ := canonicalEncMode.Marshal(tx.Body.Outputs[i-1])
+		output, _ := canonicalEncMode.Marshal(tx.Body.Outputs[i])
+		if bytes.Compare(prev, output) > 0 {
+			t.Errorf("output %v isn't sorted", i)
+		}
+	}
+	index := builder.ChangeIndex()
+	if index <= 0 {
+		t.Fatalf("got change index %v want sorted after the first output", index)
+	}
+	change, err := tx.ChangeUtxo(payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := change.Index, uint64(index); got != want {
+		t.Errorf("got change utxo index %v want %v", got, want)
+	}
+}
+
+func TestTXBuilder_MintTo(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	key = crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	policyKey := crypto.NewExtendedSigningKey([]byte("policy"), "foo")
+
+	builder := NewTxBuilder(ShelleyProtocol)
+	builder.AddUtxo(Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  10000000,
+	})
+	if err := builder.MintTo(receiver, PolicyID("00000000000000000000000000000000000000000000000000000001"), "nft", 1); err == nil {
+		t.Error("expected an error minting an unknown policy")
+	}
+	policy, err := builder.AddPolicy(NewScriptPubKey(keyHash(policyKey.ExtendedVerificationKey())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := builder.MintTo(receiver, policy, "nft", 1); err != nil {
+		t.Fatal(err)
+	}
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(100)
+	if err := builder.SignWith(resolver); err != nil {
+		t.Fatal(err)
+	}
+	builder.Sign(policyKey)
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := tx.Body.Mint, (MintAssets{policy: {"nft": 1}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got mint %v want %v", got, want)
+	}
+	if got, want := len(tx.Body.Outputs), 2; got != want {
+		t.Fatalf("got %v outputs want %v", got, want)
+	}
+	sent := tx.Body.Outputs[1]
+	if got, want := sent.Value(), (Value{Coin: ShelleyProtocol.MinimumUtxoValue, Assets: MultiAsset{policy: {"nft": 1}}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got sent value %v want %v", got, want)
+	}
+	if tx.Body.Outputs[0].Assets != nil {
+		t.Errorf("got change assets %v want none", tx.Body.Outputs[0].Assets)
+	}
+}
+
+func TestTXBuilder_MaxFee(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	key = crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	newBuilder := func(inputs int, maxFee uint64) *TXBuilder {
+		builder := NewTxBuilder(ShelleyProtocol)
+		for i := 0; i < inputs; i++ {
+			builder.AddUtxo(Utxo{
+				Address: payer,
+				TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+				Index:   uint64(i),
+				Amount:  3000000,
+			})
+		}
+		builder.AddOutput(receiver, 1000000)
+		builder.SetChangeAddress(payer)
+		builder.SetTtl(100)
+		builder.MaxFee(maxFee)
+		if err := builder.SignWith(resolver); err != nil {
+			t.Fatal(err)
+		}
+		return builder
+	}
+
+	tx, err := newBuilder(1, 200000).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Body.Fee > 200000 {
+		t.Errorf("got fee %v want atmost %v", tx.Body.Fee, 200000)
+	}
+
+	if _, err := newBuilder(50, 200000).Build(); !errors.Is(err, ErrFeeExceedsCap) {
+		t.Errorf("got error %v want %v", err, ErrFeeExceedsCap)
+	}
+}
+
+func TestTXBuilder_SetTTLFromTip(t *testing.T) {
+	node := &MockNode{tip: 98000000}
+
+	builder := NewTxBuilder(ShelleyProtocol)
+	if err := builder.SetTTLFromTip(context.Background(), node, 7200); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := builder.ttl, uint64(98007200); got != want {
+		t.Errorf("got ttl %v want %v", got, want)
+	}
+
+	// The tip is then the current slot of relative TTLs
+	builder.SetTTLIn(time.Hour)
+	if got, want := builder.ttl, uint64(98003600); got != want {
+		t.Errorf("got ttl %v want %v", got, want)
+	}
+
+	blocking := make(blockingNode)
+	defer close(blocking)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewTxBuilder(ShelleyProtocol).SetTTLFromTip(ctx, blocking, 7200); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v want %v", err, context.Canceled)
+	}
+}
+
+// blockingNode answers once closed.
+type blockingNode chan struct{}
+
+func (node blockingNode) Tip() (uint64, error) {
+	<-node
+	return 0, nil
+}
+
+func (node blockingNode) UTxOs(Address) ([]Utxo, error) {
+	return nil, nil
+}
+
+func (node blockingNode) SubmitTx(tx *Transaction) (TransactionID, error) {
+	return tx.ID(), nil
+}
+
+func TestTXBuilder_AddScriptInput(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	plutusScript := PlutusScript{Language: PlutusV2, Script: []byte{0x4e, 0x4d, 0x01, 0x00, 0x00}}
+	scriptHash, err := plutusScript.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := NewScriptAddress(Testnet, scriptHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	protocol := ShelleyProtocol
+	protocol.CostModels = CostModels{PlutusV1: {4, 5, 6}, PlutusV2: {1, 2, 3}}
+
+	scriptUtxo := Utxo{
+		Address: script,
+		TxId:    TransactionID("ff2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  5000000,
+	}
+	collateral := Utxo{
+		Address: payer,
+		TxId:    TransactionID("1e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  5000000,
+	}
+	builder := NewTxBuilder(protocol)
+	// Added first but sorted after the payer input
+	if err := builder.AddScriptInput(scriptUtxo, plutusScript, cbor.RawMessage{0x00}, ExUnits{Mem: 1000, Steps: 2000}, collateral); err != nil {
+		t.Fatal(err)
+	}
+	builder.AddUtxo(Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   1,
+		Amount:  5000000,
+	})
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(100)
+	if err := builder.SignWith(resolver); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := tx.Body.Inputs[1].ID, scriptUtxo.TxId.Bytes(); !bytes.Equal(got, want) {
+		t.Fatalf("got input %x want %x", got, want)
+	}
+	if got, want := len(tx.WitnessSet.Redeemers), 1; got != want {
+		t.Fatalf("got %v redeemers want %v", got, want)
+	}
+	if got, want := tx.WitnessSet.Redeemers[0].Index, uint64(1); got != want {
+		t.Errorf("got redeemer index %v want %v", got, want)
+	}
+	if got, want := len(tx.WitnessSet.VKeyWitnessSet), 1; got != want {
+		t.Errorf("got %v vkey witnesses want %v", got, want)
+	}
+	if got, want := tx.WitnessSet.PlutusV2Scripts, [][]byte{plutusScript.Script}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got plutus v2 scripts %x want %x", got, want)
+	}
+	if got, want := tx.Body.Collateral, []TransactionInput{{ID: collateral.TxId.Bytes(), Index: 0}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got collateral %v want %v", got, want)
+	}
+
+	// Only the cost model of the script language is in the language views
+	want, err := ScriptDataHash(tx.WitnessSet.Redeemers, nil, CostModels{PlutusV2: {1, 2, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tx.Body.ScriptDataHash; !bytes.Equal(got, want) {
+		t.Errorf("got script data hash %x want %x", got, want)
+	}
+	all, err := ScriptDataHash(tx.WitnessSet.Redeemers, nil, protocol.CostModels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(all, want) {
+		t.Errorf("got the same script data hash with all the cost models")
+	}
+	decoded, err := DecodeTransaction(tx.CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.VerifyScriptDataHash(protocol.CostModels); err != nil {
+		t.Error(err)
+	}
+	if got, want := tx.Body.Fee, CalculateFee(&tx, protocol); got < want {
+		t.Errorf("got fee %v want atleast %v", got, want)
+	}
+
+	if err := NewTxBuilder(protocol).AddScriptInput(Utxo{Address: payer}, plutusScript, nil, ExUnits{}, collateral); err == nil {
+		t.Errorf("expected error adding a key address as script input")
+	}
+	protocol.CostModels = CostModels{PlutusV1: {4, 5, 6}}
+	builder.protocol = protocol
+	if _, err := builder.Build(); err == nil {
+		t.Errorf("expected error without the cost model of the script language")
+	}
+}
+
+func TestTXBuilder_SetFixedFee(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	key = crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	newBuilder := func(fee uint64) *TXBuilder {
+		builder := NewTxBuilder(ShelleyProtocol)
+		builder.AddUtxo(Utxo{
+			Address: payer,
+			TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+			Index:   0,
+			Amount:  10000000,
+		})
+		builder.AddOutput(receiver, 2000000)
+		builder.SetChangeAddress(payer)
+		builder.SetTtl(100)
+		builder.SetFixedFee(fee)
+		if err := builder.SignWith(resolver); err != nil {
+			t.Fatal(err)
+		}
+		return builder
+	}
+
+	tx, err := newBuilder(500000).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tx.Body.Fee, uint64(500000); got != want {
+		t.Errorf("got fee %v want %v", got, want)
+	}
+	if got, want := len(tx.Body.Outputs), 2; got != want {
+		t.Fatalf("got %v outputs want %v", got, want)
+	}
+	if got, want := tx.Body.Outputs[0].Amount, uint64(10000000-2000000-500000); got != want {
+		t.Errorf("got change %v want %v", got, want)
+	}
+
+	if _, err := newBuilder(1000).Build(); !errors.Is(err, ErrFeeTooLow) {
+		t.Errorf("got error %v want %v", err, ErrFeeTooLow)
+	}
+	// The change can't be burned
+	if _, err := newBuilder(7500000).Build(); !errors.Is(err, ErrInsufficientInput) {
+		t.Errorf("got error %v want %v", err, ErrInsufficientInput)
+	}
+	if _, err := newBuilder(9000000).Build(); !errors.Is(err, ErrInsufficientInput) {
+		t.Errorf("got error %v want %v", err, ErrInsufficientInput)
+	}
+}
+
+func TestTXBuilder_SetValidityStart(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+
+	newBuilder := func(start uint64) *TXBuilder {
+		builder := NewTxBuilder(ShelleyProtocol)
+		builder.AddUtxo(Utxo{
+			Address: payer,
+			TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+			Index:   0,
+			Amount:  10000000,
+		})
+		builder.SetChangeAddress(payer)
+		builder.SetValidityStart(start)
+		builder.SetTtl(100)
+		if err := builder.SignWith(resolver); err != nil {
+			t.Fatal(err)
+		}
+		return builder
+	}
+
+	tx, err := newBuilder(50).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeTransaction(tx.CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Body.ValidityStart == nil || *decoded.Body.ValidityStart != 50 {
+		t.Errorf("got validity start %v want 50", decoded.Body.ValidityStart)
+	}
+	if got, want := decoded.CborHex(), tx.CborHex(); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	if _, err := newBuilder(100).Build(); err == nil {
+		t.Errorf("expected invalid validity interval error")
+	}
+}
+
+func TestTXBuilder_AddNativeScriptInput(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+
+	keys := []crypto.ExtendedSigningKey{
+		crypto.NewExtendedSigningKey([]byte("alice"), "foo"),
+		crypto.NewExtendedSigningKey([]byte("bob"), "foo"),
+		crypto.NewExtendedSigningKey([]byte("carol"), "foo"),
+	}
+	scripts := make([]NativeScript, len(keys))
+	for i, key := range keys {
+		scripts[i] = NewScriptPubKey(keyHash(key.ExtendedVerificationKey()))
+	}
+	multisig := NewScriptNOfK(2, scripts...)
+	hash := multisig.Hash()
+	multisigAddress, err := NewScriptAddress(Testnet, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builder := NewTxBuilder(ShelleyProtocol)
+	err = builder.AddNativeScriptInput(Utxo{
+		Address: multisigAddress,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  5000000,
+	}, multisig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder.AddUtxo(Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   1,
+		Amount:  2000000,
+	})
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(100)
+	if err := builder.SignWith(resolver); err != nil {
+		t.Fatal(err)
+	}
+	builder.Sign(keys[0])
+	builder.Sign(keys[2])
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeTransaction(tx.CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.WitnessSet.NativeScripts, []NativeScript{multisig}) {
+		t.Errorf("got scripts %+v want %+v", decoded.WitnessSet.NativeScripts, multisig)
+	}
+	if !multisig.IsSatisfiedBy(decoded.WitnessSet.VKeyWitnessSet, 0, decoded.Body.Ttl) {
+		t.Errorf("multisig isn't satisfied by the witnesses")
+	}
+	if err := decoded.VerifySignatures(); err != nil {
+		t.Error(err)
+	}
+	if got, want := tx.Body.Fee, CalculateFee(&tx, ShelleyProtocol); got < want {
+		t.Errorf("got fee %v want atleast %v", got, want)
+	}
+
+	if err := builder.AddNativeScriptInput(Utxo{Address: payer}, multisig); err == nil {
+		t.Errorf("expected script address mismatch error")
+	}
+}
+
+func TestTXBuilder_NativeScriptValidityInterval(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	lockKey := crypto.NewExtendedSigningKey([]byte("alice"), "foo")
+	// Funds locked until slot 500
+	lock := NewScriptAll(NewScriptPubKey(keyHash(lockKey.ExtendedVerificationKey())), NewScriptInvalidBefore(500))
+	hash := lock.Hash()
+	lockAddress, err := NewScriptAddress(Testnet, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		name  string
+		start *uint64
+		ttl   uint64
+		err   error
+	}{
+		{name: "unbounded start", ttl: 1000, err: ErrTimeLocked},
+		{name: "start before the lock", start: new(uint64), ttl: 1000, err: ErrTimeLocked},
+		{name: "start after the lock", start: func() *uint64 { slot := uint64(600); return &slot }(), ttl: 1000},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			builder := NewTxBuilder(ShelleyProtocol)
+			err := builder.AddNativeScriptInput(Utxo{
+				Address: lockAddress,
+				TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+				Index:   0,
+				Amount:  5000000,
+			}, lock)
+			if err != nil {
+				t.Fatal(err)
+			}
+			builder.SetChangeAddress(payer)
+			builder.SetTtl(tc.ttl)
+			if tc.start != nil {
+				builder.SetValidityStart(*tc.start)
+			}
+			if err := builder.SignWith(resolver); err != nil {
+				t.Fatal(err)
+			}
+			builder.Sign(lockKey)
+			tx, err := builder.Build()
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got error %v want %v", err, tc.err)
+			}
+			if err == nil && !lock.IsSatisfiedBy(tx.WitnessSet.VKeyWitnessSet, *tx.Body.ValidityStart, tx.Body.Ttl) {
+				t.Errorf("lock not satisfied by the transaction")
+			}
+		})
+	}
+}
+
+func TestTXBuilder_CheckSize(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	resolver := mapResolver{payer: key}
+	key = crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	newBuilder := func(outputs int) *TXBuilder {
+		builder := NewTxBuilder(ShelleyProtocol)
+		builder.AddUtxo(Utxo{
+			Address: payer,
+			TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+			Amount:  1000000000,
+		})
+		for i := 0; i < outputs; i++ {
+			builder.AddOutput(receiver, 1000000)
+		}
+		builder.SetChangeAddress(payer)
+		builder.SetTtl(100)
+		builder.CheckSize()
+		if err := builder.SignWith(resolver); err != nil {
+			t.Fatal(err)
+		}
+		return builder
+	}
+
+	tx, err := newBuilder(10).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.ValidateSize(ShelleyProtocol); err != nil {
+		t.Error(err)
+	}
+
+	// 500 outputs of 37 bytes are over the 16KB limit
+	if _, err := newBuilder(500).Build(); !errors.Is(err, ErrTxTooLarge) {
+		t.Errorf("got error %v want %v", err, ErrTxTooLarge)
+	}
+
+	protocol := ShelleyProtocol
+	protocol.MaxTxSize = uint64(len(tx.Bytes())) - 10
+	if err := tx.ValidateSize(protocol); !errors.Is(err, ErrTxTooLarge) || !strings.Contains(err.Error(), "10 bytes over") {
+		t.Errorf("got error %v want %v by 10 bytes", err, ErrTxTooLarge)
+	}
+}
@@ -1,7 +1,16 @@
 package cardano
 
 import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/tclairet/cardano-go/crypto"
+	"golang.org/x/crypto/blake2b"
 )
 
 func TestAddress(t *testing.T) {
@@ -19,3 +28,855 @@ func TestAddress(t *testing.T) {
 		}
 	}
 }
+
+func TestDecodeTransactionBytes(t *testing.T) {
+	tx := &Transaction{
+		Body: TransactionBody{
+			Inputs:  []TransactionInput{{ID: make([]byte, 32), Index: 1}},
+			Outputs: []TransactionOutput{{Address: make([]byte, 29), Amount: 1000000}},
+			Fee:     170000,
+			Ttl:     100,
+		},
+	}
+
+	got, err := DecodeTransactionBytes(tx.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), tx.Bytes()) {
+		t.Errorf("got %x want %x", got.Bytes(), tx.Bytes())
+	}
+
+	fromHex, err := DecodeTransaction(tx.CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromHex, got) {
+		t.Errorf("got %v want %v", fromHex, got)
+	}
+	if got, want := fromHex.ID(), tx.ID(); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	if _, err := DecodeTransactionBytes([]byte{0x83, 0x00}); err == nil {
+		t.Errorf("expected error decoding malformed bytes")
+	}
+
+	tx.WitnessSet.VKeyWitnessSet = []VKeyWitness{{VKey: []byte{0x01}, Signature: make([]byte, 64)}}
+	if _, err := DecodeTransactionBytes(tx.Bytes()); err == nil {
+		t.Errorf("expected invalid vkey length error")
+	}
+}
+
+func TestDecodeTransactionBytes_IndefiniteLength(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	body, err := hex.DecodeString(
+		"a4" +
+			"00" + "9f" + "825820" + "6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1" + "01" + "ff" +
+			"01" + "81" + "82581d60" + "00000000000000000000000000000000000000000000000000000000" + "1a000f4240" +
+			"02" + "1a00029810" +
+			"03" + "1864")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bodyHash := blake2b.Sum256(body)
+	witnessSet := TransactionWitnessSet{VKeyWitnessSet: []VKeyWitness{{
+		VKey:      key.VerificationKey(),
+		Signature: key.Sign(bodyHash[:]),
+	}}}
+	witnessSetBytes, err := cbor.Marshal(witnessSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txBytes := append(append(append([]byte{0x83}, body...), witnessSetBytes...), 0xf6)
+
+	tx, err := DecodeTransactionBytes(txBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(tx.Body.Inputs), 1; got != want {
+		t.Fatalf("got %v inputs want %v", got, want)
+	}
+	if got, want := tx.ID(), TransactionID(hex.EncodeToString(bodyHash[:])); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		t.Errorf("VerifySignatures() error = %v", err)
+	}
+	if got := tx.Bytes(); !bytes.Equal(got, txBytes) {
+		t.Errorf("got %x want %x", got, txBytes)
+	}
+
+	// Modifying the body drops the original bytes
+	tx.Body.Fee++
+	if tx.ID() == TransactionID(hex.EncodeToString(bodyHash[:])) {
+		t.Errorf("modified body kept the original id")
+	}
+	if err := tx.VerifySignatures(); err == nil {
+		t.Errorf("expected invalid signature error")
+	}
+}
+
+func TestDecodeTransactionBytes_Alonzo(t *testing.T) {
+	// A plutus script spend with the is_valid flag of the Alonzo transactions
+	txBytes, err := hex.DecodeString(
+		"84" +
+			"a6" +
+			"00" + "81" + "825820" + "6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1" + "00" +
+			"01" + "81" + "82581d60" + "000102030405060708090a0b0c0d0e0f101112131415161718191a1b" + "1a00493e00" +
+			"02" + "1a00030d40" +
+			"03" + "1a02612180" +
+			"0b" + "5820" + "0000000000000000000000000000000000000000000000000000000000000000" +
+			"0d" + "81" + "825820" + "1e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1" + "01" +
+			"a3" +
+			"00" + "81" + "825820" + "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f" +
+			"5840" + "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"03" + "81" + "4f" + "4e4d01000033222220051200120011" +
+			"05" + "81" + "84" + "00" + "00" + "80" + "82" + "1903e8" + "1907d0" +
+			"f5" +
+			"f6")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := DecodeTransactionBytes(txBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.IsValid == nil || !*tx.IsValid {
+		t.Errorf("got is_valid %v want true", tx.IsValid)
+	}
+	if got, want := len(tx.Body.Collateral), 1; got != want {
+		t.Errorf("got %v collateral inputs want %v", got, want)
+	}
+	if got, want := len(tx.WitnessSet.PlutusV1Scripts), 1; got != want {
+		t.Errorf("got %v plutus v1 scripts want %v", got, want)
+	}
+	if got, want := len(tx.WitnessSet.Redeemers), 1; got != want {
+		t.Errorf("got %v redeemers want %v", got, want)
+	}
+	if got := tx.Bytes(); !bytes.Equal(got, txBytes) {
+		t.Errorf("got %x want %x", got, txBytes)
+	}
+
+	// Without the flag the transaction keeps the 3 elements encoding
+	tx.IsValid = nil
+	decoded, err := DecodeTransactionBytes(tx.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tx.Bytes()[0], byte(0x83); got != want {
+		t.Errorf("got array head %x want %x", got, want)
+	}
+	if decoded.IsValid != nil {
+		t.Errorf("got is_valid %v want nil", *decoded.IsValid)
+	}
+
+	if _, err := DecodeTransactionBytes(append([]byte{0x85}, append(txBytes[1:], 0xf6)...)); err == nil {
+		t.Errorf("expected error decoding a 5 elements transaction")
+	}
+}
+
+func TestTransaction_WitnessField(t *testing.T) {
+	tx := &Transaction{
+		Body: TransactionBody{
+			Inputs:  []TransactionInput{{ID: make([]byte, 32), Index: 1}},
+			Outputs: []TransactionOutput{{Address: make([]byte, 29), Amount: 1000000}},
+			Fee:     170000,
+			Ttl:     100,
+		},
+		WitnessSet: TransactionWitnessSet{
+			VKeyWitnessSet: []VKeyWitness{{VKey: make([]byte, 32), Signature: make([]byte, 64)}},
+		},
+	}
+	script := cbor.RawMessage{0x81, 0x43, 0x01, 0x02, 0x03} // [h'010203']
+	tx.SetWitnessField(7, script)
+
+	decoded, err := DecodeTransactionBytes(tx.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := decoded.WitnessField(7)
+	if !ok {
+		t.Fatalf("missing witness field 7")
+	}
+	if !bytes.Equal(got, script) {
+		t.Errorf("got %x want %x", got, script)
+	}
+	if got, want := len(decoded.WitnessSet.VKeyWitnessSet), 1; got != want {
+		t.Errorf("got %v vkey witnesses want %v", got, want)
+	}
+	if _, ok := decoded.WitnessField(1); ok {
+		t.Errorf("unexpected witness field 1")
+	}
+	if _, ok := decoded.WitnessField(0); !ok {
+		t.Errorf("missing vkey witness field 0")
+	}
+	if got, want := decoded.Bytes(), tx.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("got %x want %x", got, want)
+	}
+}
+
+func TestTransaction_IsFullySigned(t *testing.T) {
+	keys := []crypto.ExtendedSigningKey{
+		crypto.NewExtendedSigningKey([]byte("cosigner 1"), "foo"),
+		crypto.NewExtendedSigningKey([]byte("cosigner 2"), "foo"),
+		crypto.NewExtendedSigningKey([]byte("cosigner 3"), "foo"),
+	}
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(keys[0].ExtendedVerificationKey()))
+	input := TransactionInput{ID: make([]byte, 32), Index: 2}
+	tx := &Transaction{
+		Body: TransactionBody{
+			Inputs:  []TransactionInput{input},
+			Outputs: []TransactionOutput{{Address: payer.Bytes(), Amount: 1000000}},
+			Fee:     170000,
+			Ttl:     100,
+			RequiredSigners: [][]byte{
+				keyHash(keys[1].ExtendedVerificationKey()),
+				keyHash(keys[2].ExtendedVerificationKey()),
+			},
+		},
+	}
+	txHash := blake2b.Sum256(tx.Body.Bytes())
+	for _, key := range keys[:2] {
+		tx.WitnessSet.VKeyWitnessSet = append(tx.WitnessSet.VKeyWitnessSet, VKeyWitness{
+			VKey:      key.VerificationKey(),
+			Signature: key.Sign(txHash[:]),
+		})
+	}
+	resolved := map[string]Address{input.String(): payer}
+
+	signed, missing, err := tx.IsFullySigned(resolved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed {
+		t.Errorf("partially signed transaction reported as fully signed")
+	}
+	if want := [][]byte{keyHash(keys[2].ExtendedVerificationKey())}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("got missing %x want %x", missing, want)
+	}
+
+	tx.WitnessSet.VKeyWitnessSet = append(tx.WitnessSet.VKeyWitnessSet, VKeyWitness{
+		VKey:      keys[2].VerificationKey(),
+		Signature: keys[2].Sign(txHash[:]),
+	})
+	signed, missing, err = tx.IsFullySigned(resolved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signed || len(missing) != 0 {
+		t.Errorf("got (%v, %x) want fully signed", signed, missing)
+	}
+
+	if _, _, err := tx.IsFullySigned(map[string]Address{}); err == nil {
+		t.Errorf("expected unresolved input error")
+	}
+}
+
+func TestTransactionBody_NoMetadataHash(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	builder := NewTxBuilder(ShelleyProtocol)
+	builder.AddUtxo(Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Amount:  5000000,
+	})
+	builder.AddOutput(payer, 2000000)
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(100)
+	if err := builder.SignWith(mapResolver{payer: key}); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeTransaction(tx.CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Body.MetadataHash != nil {
+		t.Errorf("got metadata hash %x want nil", *decoded.Body.MetadataHash)
+	}
+	body := map[uint64]cbor.RawMessage{}
+	if err := cbor.Unmarshal(decoded.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if raw, ok := body[7]; ok {
+		t.Errorf("got body key 7 %x want none", raw)
+	}
+
+	invalid := []byte{0x01}
+	tx.Body.MetadataHash = &invalid
+	if _, err := DecodeTransaction(tx.CborHex()); err == nil {
+		t.Errorf("expected invalid metadata hash length error")
+	}
+}
+
+func TestTransactionBody_MarshalCBOR(t *testing.T) {
+	body := &TransactionBody{
+		Inputs:  []TransactionInput{{ID: make([]byte, 32), Index: 1}},
+		Outputs: []TransactionOutput{{Address: make([]byte, 29), Amount: 1000000}},
+		Fee:     170000,
+		Ttl:     100,
+	}
+	data, err := body.MarshalCBOR()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := blake2b.Sum256(data)
+	if got, want := TransactionID(hex.EncodeToString(hash[:])), body.ID(); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := body.CborHex(), hex.EncodeToString(data); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestTransactionBody_RawField(t *testing.T) {
+	body := &TransactionBody{
+		Inputs:  []TransactionInput{{ID: make([]byte, 32), Index: 1}},
+		Outputs: []TransactionOutput{{Address: make([]byte, 29), Amount: 1000000}},
+		Fee:     170000,
+		Ttl:     100,
+	}
+	outputs, err := cbor.Marshal(body.Outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := body.RawOutputs(); !bytes.Equal(got, outputs) {
+		t.Errorf("got %x want %x", got, outputs)
+	}
+	inputs, err := cbor.Marshal(body.Inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := body.RawInputs(); !bytes.Equal(got, inputs) {
+		t.Errorf("got %x want %x", got, inputs)
+	}
+	if got, want := body.RawField(2), (cbor.RawMessage{0x1a, 0x00, 0x02, 0x98, 0x10}); !bytes.Equal(got, want) {
+		t.Errorf("got %x want %x", got, want)
+	}
+	if got := body.RawCertificates(); got != nil {
+		t.Errorf("got %x want nil", got)
+	}
+}
+
+func FuzzDecodeTransaction(f *testing.F) {
+	tx := &Transaction{
+		Body: TransactionBody{
+			Inputs:  []TransactionInput{{ID: make([]byte, 32), Index: 1}},
+			Outputs: []TransactionOutput{{Address: make([]byte, 29), Amount: 1000000}},
+			Fee:     170000,
+			Ttl:     100,
+		},
+		WitnessSet: TransactionWitnessSet{
+			VKeyWitnessSet: []VKeyWitness{{VKey: make([]byte, 32), Signature: make([]byte, 64)}},
+		},
+	}
+	f.Add(tx.Bytes())
+	valid := true
+	alonzo := *tx
+	alonzo.IsValid = &valid
+	f.Add(alonzo.Bytes())
+	f.Add([]byte{0x83, 0xa0, 0xa0, 0xf6})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tx, err := DecodeTransactionBytes(data)
+		if err != nil {
+			return
+		}
+		tx.ID()
+		tx.CborHex()
+		for _, output := range tx.Body.Outputs {
+			DecodeAddress(output.Address)
+		}
+		txHash := blake2b.Sum256(tx.Body.Bytes())
+		for _, witness := range tx.WitnessSet.VKeyWitnessSet {
+			vkey := crypto.ExtendedVerificationKey(witness.VKey)
+			vkey.Verify(txHash[:], witness.Signature)
+		}
+		tx.TotalExUnits()
+		tx.VerifyScriptDataHash(CostModels{PlutusV1: {1}, PlutusV2: {1}})
+		if _, err := tx.SizeBreakdown(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestTransaction_SpendsProduces(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	key = crypto.NewExtendedSigningKey([]byte("change address"), "foo")
+	change := NewEnterpriseAddress(Mainnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	inputs := []TransactionInput{{ID: make([]byte, 32), Index: 3}, {ID: make([]byte, 32), Index: 7}}
+	tx := &Transaction{
+		Body: TransactionBody{
+			Inputs: inputs,
+			Outputs: []TransactionOutput{
+				{Address: change.Bytes(), Amount: 3000000},
+				{Address: receiver.Bytes(), Amount: 1000000},
+			},
+			Fee: 170000,
+			Ttl: 100,
+		},
+	}
+
+	if got := tx.Spends(); !reflect.DeepEqual(got, inputs) {
+		t.Errorf("got %v want %v", got, inputs)
+	}
+
+	want := []Utxo{
+		{Address: change, TxId: tx.ID(), Amount: 3000000, Index: 0},
+		{Address: receiver, TxId: tx.ID(), Amount: 1000000, Index: 1},
+	}
+	if got := tx.Produces(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	// Byron outputs keep their base58 address
+	byron := newByronAddress(t, key.ExtendedVerificationKey())
+	byronBytes, err := base58Decode(string(byron))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Body.Outputs = append(tx.Body.Outputs, TransactionOutput{Address: byronBytes, Amount: 2000000})
+	if got, want := tx.Produces()[2].Address, byron; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestDecodeTransaction_BootstrapWitness(t *testing.T) {
+	shelleyKey := crypto.NewExtendedSigningKey([]byte("shelley"), "foo")
+	byronKey := crypto.NewExtendedSigningKey([]byte("byron"), "foo")
+	body := TransactionBody{
+		Inputs:  []TransactionInput{{ID: make([]byte, 32), Index: 0}, {ID: make([]byte, 32), Index: 1}},
+		Outputs: []TransactionOutput{{Address: make([]byte, 29), Amount: 1000000}},
+		Fee:     170000,
+		Ttl:     100,
+	}
+	txHash := blake2b.Sum256(body.Bytes())
+	tx := &Transaction{
+		Body: body,
+		WitnessSet: TransactionWitnessSet{
+			VKeyWitnessSet: []VKeyWitness{{VKey: shelleyKey.VerificationKey(), Signature: shelleyKey.Sign(txHash[:])}},
+			Bootstrap: []BootstrapWitness{{
+				VKey:       byronKey.VerificationKey(),
+				Signature:  byronKey.Sign(txHash[:]),
+				ChainCode:  byronKey.ExtendedVerificationKey()[32:],
+				Attributes: []byte{0xa0},
+			}},
+		},
+	}
+
+	decoded, err := DecodeTransaction(tx.CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(decoded.WitnessSet.VKeyWitnessSet), 1; got != want {
+		t.Errorf("got %v vkey witnesses want %v", got, want)
+	}
+	if got, want := decoded.WitnessSet.Bootstrap, tx.WitnessSet.Bootstrap; !reflect.DeepEqual(got, want) {
+		t.Errorf("got bootstrap witnesses %+v want %+v", got, want)
+	}
+	if _, ok := decoded.WitnessSet.fields[2]; ok {
+		t.Errorf("bootstrap witnesses kept as an unknown field")
+	}
+	if err := decoded.VerifySignatures(); err != nil {
+		t.Error(err)
+	}
+	if got, want := decoded.Bytes(), tx.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("got %x want %x", got, want)
+	}
+
+	decoded.WitnessSet.Bootstrap[0].Signature = shelleyKey.Sign(txHash[:])
+	if err := decoded.VerifySignatures(); err == nil {
+		t.Error("expected an invalid bootstrap witness signature error")
+	}
+}
+
+func TestTransaction_SizeBreakdown(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	tx := &Transaction{
+		Body: TransactionBody{
+			Inputs: []TransactionInput{{ID: make([]byte, 32), Index: 0}},
+			Outputs: []TransactionOutput{
+				{Address: receiver.Bytes(), Amount: 3000000},
+				NewTransactionOutput(receiver, Value{Coin: 2000000, Assets: MultiAsset{testPolicy: {"token": 1}}}),
+			},
+			Fee: 170000,
+			Ttl: 100,
+		},
+		WitnessSet: TransactionWitnessSet{
+			VKeyWitnessSet: []VKeyWitness{{VKey: make([]byte, 32), Signature: make([]byte, 64)}},
+		},
+		Metadata: &transactionMetadata{674: MetadatumText("invoice 42")},
+	}
+
+	sizes, err := tx.SizeBreakdown()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sizes["header"]+sizes["body"]+sizes["witness_set"]+sizes["metadata"], len(tx.Bytes()); got != want {
+		t.Errorf("got components size %v want total %v", got, want)
+	}
+	if got, want := sizes["total"], len(tx.Bytes()); got != want {
+		t.Errorf("got total %v want %v", got, want)
+	}
+	metadata, err := cbor.Marshal(tx.Metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sizes["is_valid"]; ok {
+		t.Errorf("got is_valid size without IsValid")
+	}
+	if got, want := sizes["metadata"], len(metadata); got != want {
+		t.Errorf("got metadata size %v want %v", got, want)
+	}
+	if sizes["output/0"] == 0 || sizes["output/1"] <= sizes["output/0"] {
+		t.Errorf("got output sizes %v and %v", sizes["output/0"], sizes["output/1"])
+	}
+	if sizes["output/0"]+sizes["output/1"] >= sizes["body"] {
+		t.Errorf("got outputs size larger than the body %v", sizes["body"])
+	}
+}
+
+func TestTransaction_SizeBreakdownIsValid(t *testing.T) {
+	valid := true
+	tx := &Transaction{
+		Body: TransactionBody{
+			Inputs:  []TransactionInput{{ID: make([]byte, 32), Index: 0}},
+			Outputs: []TransactionOutput{{Address: make([]byte, 29), Amount: 1000000}},
+			Fee:     170000,
+			Ttl:     100,
+		},
+		IsValid: &valid,
+	}
+
+	sizes, err := tx.SizeBreakdown()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sizes["is_valid"], 1; got != want {
+		t.Errorf("got is_valid size %v want %v", got, want)
+	}
+	if got, want := sizes["metadata"], 1; got != want {
+		t.Errorf("got metadata size %v want %v", got, want)
+	}
+	if got, want := sizes["header"]+sizes["body"]+sizes["witness_set"]+sizes["is_valid"]+sizes["metadata"], sizes["total"]; got != want {
+		t.Errorf("got components size %v want total %v", got, want)
+	}
+}
+
+func TestTransaction_Verify(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	signer := crypto.NewExtendedSigningKey([]byte("signer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	body := TransactionBody{
+		Inputs:          []TransactionInput{{ID: make([]byte, 32), Index: 0}},
+		Outputs:         []TransactionOutput{{Address: payer.Bytes(), Amount: 1000000}},
+		Fee:             170000,
+		Ttl:             100,
+		RequiredSigners: [][]byte{keyHash(signer.ExtendedVerificationKey())},
+	}
+	txHash := blake2b.Sum256(body.Bytes())
+
+	tx := &Transaction{Body: body}
+	if err := tx.Verify(); err == nil {
+		t.Errorf("expected missing witnesses error")
+	}
+
+	tx.WitnessSet.VKeyWitnessSet = []VKeyWitness{{VKey: key.VerificationKey(), Signature: key.Sign(txHash[:])}}
+	if err := tx.Verify(); err == nil {
+		t.Errorf("expected missing required signer error")
+	}
+
+	tx.WitnessSet.VKeyWitnessSet = append(tx.WitnessSet.VKeyWitnessSet, VKeyWitness{
+		VKey:      signer.VerificationKey(),
+		Signature: signer.Sign([]byte("not the body")),
+	})
+	if err := tx.Verify(); err == nil || !strings.Contains(err.Error(), "witness 1") {
+		t.Errorf("got error %v want invalid witness 1 signature", err)
+	}
+
+	tx.WitnessSet.VKeyWitnessSet[1].Signature = signer.Sign(txHash[:])
+	if err := tx.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTransaction_VerifyInputs(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	signer := crypto.NewExtendedSigningKey([]byte("signer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	body := TransactionBody{
+		Inputs:          []TransactionInput{{ID: make([]byte, 32), Index: 0}},
+		Outputs:         []TransactionOutput{{Address: payer.Bytes(), Amount: 1000000}},
+		Fee:             170000,
+		Ttl:             100,
+		RequiredSigners: [][]byte{keyHash(signer.ExtendedVerificationKey())},
+	}
+	txHash := blake2b.Sum256(body.Bytes())
+	resolved := map[string]Address{body.Inputs[0].String(): payer}
+
+	// The required signer witness doesn't witness the input
+	tx := &Transaction{Body: body}
+	tx.WitnessSet.VKeyWitnessSet = []VKeyWitness{{VKey: signer.VerificationKey(), Signature: signer.Sign(txHash[:])}}
+	if err := tx.Verify(); err != nil {
+		t.Error(err)
+	}
+	if err := tx.VerifyInputs(resolved); err == nil || !strings.Contains(err.Error(), body.Inputs[0].String()) {
+		t.Errorf("got error %v want missing witness of input %v", err, body.Inputs[0])
+	}
+
+	tx.WitnessSet.VKeyWitnessSet = append(tx.WitnessSet.VKeyWitnessSet, VKeyWitness{VKey: key.VerificationKey(), Signature: key.Sign(txHash[:])})
+	if err := tx.VerifyInputs(resolved); err != nil {
+		t.Error(err)
+	}
+	if err := tx.VerifyInputs(map[string]Address{}); err == nil {
+		t.Errorf("expected unresolved input error")
+	}
+}
+
+func TestMinUTXO(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	adaOnly := TransactionOutput{Address: receiver.Bytes(), Amount: 1}
+	tokens := NewTransactionOutput(receiver, Value{Coin: 1, Assets: MultiAsset{testPolicy: {"token": 1}}})
+
+	if got, want := MinUTXO(tokens, ShelleyProtocol), ShelleyProtocol.MinimumUtxoValue; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	protocol := ShelleyProtocol
+	protocol.CoinsPerUTxOByte = 4310
+	// 1 byte array header, 31 bytes address and 9 bytes amount
+	if got, want := MinUTXO(adaOnly, protocol), uint64(160+41)*4310; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if MinUTXO(tokens, protocol) <= MinUTXO(adaOnly, protocol) {
+		t.Errorf("got token output min %v want more than %v", MinUTXO(tokens, protocol), MinUTXO(adaOnly, protocol))
+	}
+
+	// A change below the fixed min utxo value but above the size based one is
+	// kept
+	body := TransactionBody{Inputs: []TransactionInput{{ID: make([]byte, 32), Index: 0}}}
+	if err := body.addFee(1100000, receiver, nil, protocol, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(body.Outputs), 1; got != want {
+		t.Fatalf("got %v outputs want %v", got, want)
+	}
+	if got, want := body.Outputs[0].Amount+body.Fee, uint64(1100000); got != want {
+		t.Errorf("got change plus fee %v want %v", got, want)
+	}
+}
+
+func TestMinUTXOWord(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	otherPolicy := PolicyID(strings.Repeat("ab", 28))
+	thirdPolicy := PolicyID(strings.Repeat("cd", 28))
+
+	// The Alonzo min ada values with 34482 lovelace per word
+	testcases := []struct {
+		name   string
+		assets MultiAsset
+		want   uint64
+	}{
+		{name: "lovelace only", want: 999978},
+		{name: "one policy, one empty name", assets: MultiAsset{testPolicy: {"": 1}}, want: 1310316},
+		{name: "one policy, one 1 char name", assets: MultiAsset{testPolicy: {"a": 1}}, want: 1344798},
+		{name: "one policy, three 1 char names", assets: MultiAsset{testPolicy: {"a": 1, "b": 1, "c": 1}}, want: 1448244},
+		{name: "two policies, one empty name", assets: MultiAsset{testPolicy: {"": 1}, otherPolicy: {"": 1}}, want: 1482726},
+		{name: "two policies, one 1 char name", assets: MultiAsset{testPolicy: {"a": 1}, otherPolicy: {"b": 1}}, want: 1517208},
+		{name: "three policies, 96 1 char names", assets: func() MultiAsset {
+			assets := MultiAsset{}
+			for i, policy := range []PolicyID{testPolicy, otherPolicy, thirdPolicy} {
+				for j := 0; j < 32; j++ {
+					assets.set(policy, AssetName([]byte{byte(i*32 + j)}), 1)
+				}
+			}
+			return assets
+		}(), want: 6896400},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			output := NewTransactionOutput(receiver, Value{Coin: 1, Assets: tc.assets})
+			if got := MinUTXOWord(output, 34482); got != tc.want {
+				t.Errorf("got %v want %v", got, tc.want)
+			}
+			protocol := ShelleyProtocol
+			protocol.CoinsPerUTxOWord = 34482
+			if got := MinUTXO(output, protocol); got != tc.want {
+				t.Errorf("got %v with the protocol want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTransactionBody_CanonicalInputs(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	inputs := []TransactionInput{
+		{ID: bytes.Repeat([]byte{0x02}, 32), Index: 0},
+		{ID: bytes.Repeat([]byte{0x01}, 32), Index: 1},
+		{ID: bytes.Repeat([]byte{0x01}, 32), Index: 0},
+	}
+	newBody := func(inputs ...TransactionInput) *TransactionBody {
+		return &TransactionBody{
+			Inputs:  inputs,
+			Outputs: []TransactionOutput{NewTransactionOutput(receiver, Value{Coin: 2000000, Assets: MultiAsset{testPolicy: {"b": 1, "a": 2}}})},
+			Fee:     170000,
+			Ttl:     100,
+		}
+	}
+	body := newBody(inputs...)
+	reversed := newBody(inputs[2], inputs[1], inputs[0])
+
+	if got, want := reversed.CborHex(), body.CborHex(); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := reversed.ID(), body.ID(); got != want {
+		t.Errorf("got id %v want %v", got, want)
+	}
+	decoded, err := DecodeTransaction((&Transaction{Body: *body}).CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []TransactionInput{inputs[2], inputs[1], inputs[0]}; !reflect.DeepEqual(decoded.Body.Inputs, want) {
+		t.Errorf("got inputs %v want %v", decoded.Body.Inputs, want)
+	}
+	if got, want := body.Inputs[0], inputs[0]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got body input %v want %v unchanged", got, want)
+	}
+}
+
+func TestTransactionBody_AddSignaturesErrors(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	body := &TransactionBody{
+		Inputs:  []TransactionInput{{ID: make([]byte, 32), Index: 0}},
+		Outputs: []TransactionOutput{{Address: make([]byte, 29), Amount: 1000000}},
+		Fee:     170000,
+		Ttl:     100,
+	}
+	txHash := blake2b.Sum256(body.Bytes())
+	publicKey, signature := key.VerificationKey(), key.Sign(txHash[:])
+
+	if _, err := body.AddSignatures([][]byte{publicKey}, nil); !errors.Is(err, ErrWitnessCountMismatch) {
+		t.Errorf("got error %v want %v", err, ErrWitnessCountMismatch)
+	}
+	if _, err := body.AddSignatures([][]byte{publicKey, publicKey}, [][]byte{signature, signature}); !errors.Is(err, ErrWitnessCountMismatch) {
+		t.Errorf("got error %v want %v", err, ErrWitnessCountMismatch)
+	}
+	if _, err := body.AddSignatures([][]byte{publicKey}, [][]byte{signature[:63]}); !errors.Is(err, ErrInvalidSignatureLength) {
+		t.Errorf("got error %v want %v", err, ErrInvalidSignatureLength)
+	}
+	if _, err := body.AddSignatures([][]byte{publicKey}, [][]byte{signature}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTransactionBody_Sign(t *testing.T) {
+	keys := []crypto.ExtendedSigningKey{
+		crypto.NewExtendedSigningKey([]byte("input 0"), "foo"),
+		crypto.NewExtendedSigningKey([]byte("input 1"), "foo"),
+		crypto.NewExtendedSigningKey([]byte("unused"), "foo"),
+	}
+	var utxos []Utxo
+	for i, key := range keys[:2] {
+		utxos = append(utxos, Utxo{
+			Address: NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey())),
+			TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+			Index:   uint64(i),
+			Amount:  2000000,
+		})
+	}
+	// A second input of the first key needs a single witness
+	utxos = append(utxos, Utxo{Address: utxos[0].Address, TxId: utxos[0].TxId, Index: 2, Amount: 2000000})
+	body, err := TXBodyBuilder{Protocol: ShelleyProtocol, TTL: 100}.Build(utxos[1].Address, utxos, 3000000, utxos[0].Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The keys are in any order
+	tx, err := body.Sign(utxos, []crypto.ExtendedSigningKey{keys[2], keys[1], keys[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(tx.WitnessSet.VKeyWitnessSet), 2; got != want {
+		t.Errorf("got %v witnesses want %v", got, want)
+	}
+	resolved := map[string]Address{}
+	for _, utxo := range utxos {
+		resolved[TransactionInput{ID: utxo.TxId.Bytes(), Index: utxo.Index}.String()] = utxo.Address
+	}
+	if err := tx.Verify(); err != nil {
+		t.Error(err)
+	}
+	if err := tx.VerifyInputs(resolved); err != nil {
+		t.Error(err)
+	}
+	if signed, missing, err := tx.IsFullySigned(resolved); err != nil || !signed {
+		t.Errorf("got (%v, %x, %v) want fully signed", signed, missing, err)
+	}
+
+	if _, err := body.Sign(utxos, keys[:1]); err == nil {
+		t.Errorf("expected missing signing key error")
+	}
+	if _, err := body.Sign(utxos[:1], keys); err == nil {
+		t.Errorf("expected unresolved input error")
+	}
+}
+
+func TestTransactionBody_AssetBalance(t *testing.T) {
+	utxos := []Utxo{{
+		Address: Address("addr_test1vqgjd0t02q9yglcjwdc8dht9tz6gkfpqqm7evs5csrklakcqmwv40"),
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  5000000,
+		Assets:  MultiAsset{testPolicy: {"token": 10, "nft": 1}},
+	}}
+	body := TransactionBody{
+		Inputs: []TransactionInput{{ID: utxos[0].TxId.Bytes(), Index: 0}},
+		Outputs: []TransactionOutput{{
+			Address: utxos[0].Address.Bytes(),
+			Amount:  4800000,
+			// The leftover tokens and the nft aren't returned as change
+			Assets: MultiAsset{testPolicy: {"token": 7, "minted": 3}},
+		}},
+		Fee:  200000,
+		Ttl:  100,
+		Mint: MintAssets{testPolicy: {"minted": 2}},
+	}
+
+	got, err := body.AssetBalance(utxos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[AssetID]int64{
+		{Policy: testPolicy, Name: "token"}:  3,
+		{Policy: testPolicy, Name: "nft"}:    1,
+		{Policy: testPolicy, Name: "minted"}: -1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	body.Outputs[0].Assets = MultiAsset{testPolicy: {"token": 10, "nft": 1, "minted": 2}}
+	got, err = body.AssetBalance(utxos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %v want a balanced transaction", got)
+	}
+
+	if _, err := body.AssetBalance(nil); err == nil {
+		t.Errorf("expected unresolved input error")
+	}
+}
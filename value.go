@@ -0,0 +1,451 @@
+package cardano
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// PolicyID is the hex encoded hash of a minting policy script.
+type PolicyID string
+
+func (id PolicyID) Bytes() []byte {
+	bytes, err := hex.DecodeString(string(id))
+	if err != nil {
+		panic(err)
+	}
+	return bytes
+}
+
+// AssetName is the name of a native token, up to 32 bytes.
+type AssetName string
+
+func (name AssetName) Bytes() []byte {
+	return []byte(name)
+}
+
+// MultiAsset holds the native token quantities by policy and asset name.
+type MultiAsset map[PolicyID]map[AssetName]uint64
+
+// AssetID identifies a native token by its policy and name.
+type AssetID struct {
+	Policy PolicyID
+	Name   AssetName
+}
+
+// Value is an amount of lovelace and native tokens.
+type Value struct {
+	Coin   uint64
+	Assets MultiAsset
+}
+
+// NewValue returns a lovelace only value.
+func NewValue(coin uint64) Value {
+	return Value{Coin: coin}
+}
+
+// AmountUnit is an amount in the node APIs JSON, e.g. Blockfrost. The unit
+// is lovelace or the hex policy id followed by the hex asset name.
+type AmountUnit struct {
+	Unit     string `json:"unit"`
+	Quantity string `json:"quantity"`
+}
+
+// ParseValueFromUnits returns the value of the amounts, the quantities of a
+// repeated unit are added.
+func ParseValueFromUnits(units []AmountUnit) (Value, error) {
+	value := Value{}
+	for _, amount := range units {
+		quantity, err := strconv.ParseUint(amount.Quantity, 10, 64)
+		if err != nil {
+			return Value{}, fmt.Errorf("invalid %v quantity %v: %w", amount.Unit, amount.Quantity, err)
+		}
+
+		unitValue := NewValue(quantity)
+		if amount.Unit != "lovelace" {
+			if len(amount.Unit) < 56 {
+				return Value{}, fmt.Errorf("invalid unit %v", amount.Unit)
+			}
+			policy, name := amount.Unit[:56], amount.Unit[56:]
+			if _, err := hex.DecodeString(policy); err != nil {
+				return Value{}, fmt.Errorf("invalid unit %v policy id: %w", amount.Unit, err)
+			}
+			nameBytes, err := hex.DecodeString(name)
+			if err != nil {
+				return Value{}, fmt.Errorf("invalid unit %v asset name: %w", amount.Unit, err)
+			}
+			if len(nameBytes) > 32 {
+				return Value{}, fmt.Errorf("invalid unit %v asset name length %v", amount.Unit, len(nameBytes))
+			}
+			unitValue = Value{Assets: MultiAsset{PolicyID(policy): {AssetName(nameBytes): quantity}}}
+		}
+
+		if value, err = value.Add(unitValue); err != nil {
+			return Value{}, err
+		}
+	}
+	return value, nil
+}
+
+// IsZero reports whether the value has neither lovelace nor tokens.
+func (v Value) IsZero() bool {
+	return v.Coin == 0 && len(v.normalizedAssets()) == 0
+}
+
+// Add returns the sum of the values, or an error on overflow.
+func (v Value) Add(other Value) (Value, error) {
+	if v.Coin+other.Coin < v.Coin {
+		return Value{}, fmt.Errorf("lovelace overflow")
+	}
+	sum := Value{Coin: v.Coin + other.Coin, Assets: v.Assets.clone()}
+	for policy, assets := range other.Assets {
+		for name, quantity := range assets {
+			current := sum.Assets[policy][name]
+			if current+quantity < current {
+				return Value{}, fmt.Errorf("asset %v.%x overflow", policy, name)
+			}
+			sum.Assets.set(policy, name, current+quantity)
+		}
+	}
+	sum.Assets = sum.normalizedAssets()
+	return sum, nil
+}
+
+// Sub returns the value minus other, or an error if other has more lovelace
+// or tokens than the value.
+func (v Value) Sub(other Value) (Value, error) {
+	if v.Coin < other.Coin {
+		return Value{}, fmt.Errorf("insufficient lovelace, got %v want atleast %v", v.Coin, other.Coin)
+	}
+	diff := Value{Coin: v.Coin - other.Coin, Assets: v.Assets.clone()}
+	for policy, assets := range other.Assets {
+		for name, quantity := range assets {
+			current := diff.Assets[policy][name]
+			if current < quantity {
+				return Value{}, fmt.Errorf("insufficient asset %v.%x, got %v want atleast %v", policy, name, current, quantity)
+			}
+			diff.Assets.set(policy, name, current-quantity)
+		}
+	}
+	diff.Assets = diff.normalizedAssets()
+	return diff, nil
+}
+
+// normalizedAssets returns the assets without zero quantities, or nil.
+func (v Value) normalizedAssets() MultiAsset {
+	var normalized MultiAsset
+	for policy, assets := range v.Assets {
+		for name, quantity := range assets {
+			if quantity != 0 {
+				normalized.set(policy, name, quantity)
+			}
+		}
+	}
+	return normalized
+}
+
+func (ma MultiAsset) clone() MultiAsset {
+	var cloned MultiAsset
+	for policy, assets := range ma {
+		for name, quantity := range assets {
+			cloned.set(policy, name, quantity)
+		}
+	}
+	return cloned
+}
+
+// set sets the quantity of an asset, allocating the maps if needed.
+func (ma *MultiAsset) set(policy PolicyID, name AssetName, quantity uint64) {
+	if *ma == nil {
+		*ma = MultiAsset{}
+	}
+	if (*ma)[policy] == nil {
+		(*ma)[policy] = map[AssetName]uint64{}
+	}
+	(*ma)[policy][name] = quantity
+}
+
+// MarshalCBOR implements cbor.Marshaler, a lovelace only value is encoded as
+// a bare coin and otherwise as [coin, multiasset] with the policies and asset
+// names in canonical order.
+func (v Value) MarshalCBOR() ([]byte, error) {
+	assets := v.normalizedAssets()
+	if len(assets) == 0 {
+		return cborHead(cborMajorUint, v.Coin), nil
+	}
+
+	names := map[PolicyID][]AssetName{}
+	for policy, policyAssets := range assets {
+		for name := range policyAssets {
+			names[policy] = append(names[policy], name)
+		}
+	}
+	encodedAssets, err := encodeAssets(names, func(policy PolicyID, name AssetName) []byte {
+		return cborHead(cborMajorUint, assets[policy][name])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := cborHead(cborMajorArray, 2)
+	out = append(out, cborHead(cborMajorUint, v.Coin)...)
+	return append(out, encodedAssets...), nil
+}
+
+// UnmarshalCBOR implements cbor.Unmarshaler.
+func (v *Value) UnmarshalCBOR(data []byte) error {
+	major, arg, indefinite, n, err := readCborHead(data)
+	if err != nil {
+		return err
+	}
+	if major == cborMajorUint {
+		*v = NewValue(arg)
+		return nil
+	}
+	if major != cborMajorArray || (!indefinite && arg != 2) {
+		return fmt.Errorf("invalid value, want a coin or [coin, multiasset]")
+	}
+
+	coinMajor, coin, _, coinLength, err := readCborHead(data[n:])
+	if err != nil {
+		return err
+	}
+	if coinMajor != cborMajorUint {
+		return fmt.Errorf("invalid value coin major type %v", coinMajor)
+	}
+	n += coinLength
+
+	decoded := Value{Coin: coin}
+	assetsLength, err := decodeAssets(data[n:], func(policy PolicyID, name AssetName, major byte, quantity uint64) error {
+		if major != cborMajorUint {
+			return fmt.Errorf("invalid asset quantity major type %v", major)
+		}
+		decoded.Assets.set(policy, name, quantity)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	n += assetsLength
+	if indefinite {
+		if n >= len(data) || data[n] != 0xff {
+			return fmt.Errorf("invalid indefinite length value")
+		}
+		n++
+	}
+	if n != len(data) {
+		return fmt.Errorf("unexpected data after value")
+	}
+	*v = decoded
+	return nil
+}
+
+// encodeAssets encodes the map of policies to asset names to quantities in
+// canonical order, quantity returns the encoded quantity of an asset.
+func encodeAssets(names map[PolicyID][]AssetName, quantity func(PolicyID, AssetName) []byte) ([]byte, error) {
+	policies := make([][]byte, 0, len(names))
+	for policy := range names {
+		policyBytes, err := hex.DecodeString(string(policy))
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy id %v: %w", policy, err)
+		}
+		if len(policyBytes) != 28 {
+			return nil, fmt.Errorf("invalid policy id %v length %v", policy, len(policyBytes))
+		}
+		policies = append(policies, policyBytes)
+	}
+	sortCanonical(policies)
+
+	out := cborHead(cborMajorMap, uint64(len(policies)))
+	for _, policyBytes := range policies {
+		policy := PolicyID(hex.EncodeToString(policyBytes))
+		policyNames := [][]byte{}
+		for _, name := range names[policy] {
+			if len(name) > 32 {
+				return nil, fmt.Errorf("invalid asset name %x length %v", name, len(name))
+			}
+			policyNames = append(policyNames, []byte(name))
+		}
+		sortCanonical(policyNames)
+
+		out = append(out, cborHead(cborMajorBytes, uint64(len(policyBytes)))...)
+		out = append(out, policyBytes...)
+		out = append(out, cborHead(cborMajorMap, uint64(len(policyNames)))...)
+		for _, name := range policyNames {
+			out = append(out, cborHead(cborMajorBytes, uint64(len(name)))...)
+			out = append(out, name...)
+			out = append(out, quantity(policy, AssetName(name))...)
+		}
+	}
+	return out, nil
+}
+
+// decodeAssets decodes the map of policies to asset names to quantities at the
+// start of data, calling set with the major type and argument of each integer
+// quantity. The length of the map is returned.
+func decodeAssets(data []byte, set func(policy PolicyID, name AssetName, major byte, quantity uint64) error) (int, error) {
+	return readCborMap(data, func(entry []byte) (int, error) {
+		policyBytes, policyLength, err := readCborBytes(entry)
+		if err != nil {
+			return 0, err
+		}
+		if len(policyBytes) != 28 {
+			return 0, fmt.Errorf("invalid policy id length %v", len(policyBytes))
+		}
+		policy := PolicyID(hex.EncodeToString(policyBytes))
+		assetsLength, err := readCborMap(entry[policyLength:], func(asset []byte) (int, error) {
+			nameBytes, nameLength, err := readCborBytes(asset)
+			if err != nil {
+				return 0, err
+			}
+			if len(nameBytes) > 32 {
+				return 0, fmt.Errorf("invalid asset name length %v", len(nameBytes))
+			}
+			major, quantity, indefinite, quantityLength, err := readCborHead(asset[nameLength:])
+			if err != nil {
+				return 0, err
+			}
+			if indefinite {
+				return 0, fmt.Errorf("invalid asset quantity")
+			}
+			if err := set(policy, AssetName(nameBytes), major, quantity); err != nil {
+				return 0, err
+			}
+			return nameLength + quantityLength, nil
+		})
+		if err != nil {
+			return 0, err
+		}
+		return policyLength + assetsLength, nil
+	})
+}
+
+// readCborMap reads the map at the start of data, calling entry with the data
+// starting at each key. entry returns the length of the key and its value, the
+// length of the map is returned.
+func readCborMap(data []byte, entry func(data []byte) (int, error)) (int, error) {
+	major, length, indefinite, n, err := readCborHead(data)
+	if err != nil {
+		return 0, err
+	}
+	if major != cborMajorMap {
+		return 0, fmt.Errorf("invalid cbor major type %v, want a map", major)
+	}
+	for i := uint64(0); indefinite || i < length; i++ {
+		if n >= len(data) {
+			return 0, fmt.Errorf("unexpected end of map")
+		}
+		if indefinite && data[n] == 0xff {
+			n++
+			break
+		}
+		entryLength, err := entry(data[n:])
+		if err != nil {
+			return 0, err
+		}
+		n += entryLength
+	}
+	return n, nil
+}
+
+// readCborBytes reads the definite length byte string at the start of data.
+func readCborBytes(data []byte) ([]byte, int, error) {
+	major, length, indefinite, n, err := readCborHead(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	if major != cborMajorBytes || indefinite {
+		return nil, 0, fmt.Errorf("invalid cbor major type %v, want a byte string", major)
+	}
+	if uint64(len(data)-n) < length {
+		return nil, 0, fmt.Errorf("unexpected end of byte string")
+	}
+	return data[n : n+int(length)], n + int(length), nil
+}
+
+// sortCanonical sorts byte strings as canonical cbor map keys, shorter first
+// then bytewise.
+func sortCanonical(keys [][]byte) {
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) < len(keys[j])
+		}
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+}
+
+// MintAssets holds the quantities of native tokens minted, or burned when
+// negative, by policy and asset name.
+type MintAssets map[PolicyID]map[AssetName]int64
+
+// MarshalCBOR implements cbor.Marshaler, zero quantities are omitted.
+func (mint MintAssets) MarshalCBOR() ([]byte, error) {
+	names := map[PolicyID][]AssetName{}
+	for policy, assets := range mint {
+		for name, quantity := range assets {
+			if quantity != 0 {
+				names[policy] = append(names[policy], name)
+			}
+		}
+	}
+	return encodeAssets(names, func(policy PolicyID, name AssetName) []byte {
+		quantity := mint[policy][name]
+		if quantity < 0 {
+			return cborHead(cborMajorNegative, uint64(-(quantity + 1)))
+		}
+		return cborHead(cborMajorUint, uint64(quantity))
+	})
+}
+
+// UnmarshalCBOR implements cbor.Unmarshaler.
+func (mint *MintAssets) UnmarshalCBOR(data []byte) error {
+	decoded := MintAssets{}
+	n, err := decodeAssets(data, func(policy PolicyID, name AssetName, major byte, quantity uint64) error {
+		if (major != cborMajorUint && major != cborMajorNegative) || quantity > maxInt64 {
+			return fmt.Errorf("invalid mint quantity of %v.%x", policy, name)
+		}
+		if decoded[policy] == nil {
+			decoded[policy] = map[AssetName]int64{}
+		}
+		decoded[policy][name] = int64(quantity)
+		if major == cborMajorNegative {
+			decoded[policy][name] = -int64(quantity) - 1
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if n != len(data) {
+		return fmt.Errorf("unexpected data after mint")
+	}
+	*mint = decoded
+	return nil
+}
+
+// IsEmpty reports whether the mint has no non zero quantity.
+func (mint MintAssets) IsEmpty() bool {
+	for _, assets := range mint {
+		for _, quantity := range assets {
+			if quantity != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Minted returns the positive quantities of the mint.
+func (mint MintAssets) Minted() MultiAsset {
+	var minted MultiAsset
+	for policy, assets := range mint {
+		for name, quantity := range assets {
+			if quantity > 0 {
+				minted.set(policy, name, uint64(quantity))
+			}
+		}
+	}
+	return minted
+}
@@ -0,0 +1,166 @@
+package cardano
+
+import (
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/tclairet/cardano-go/crypto"
+)
+
+const testPolicy = PolicyID("00000000000000000000000000000000000000000000000000000001")
+
+func TestValue_AddSub(t *testing.T) {
+	a := Value{Coin: 5000000, Assets: MultiAsset{testPolicy: {"token": 10}}}
+	b := Value{Coin: 2000000, Assets: MultiAsset{testPolicy: {"token": 10, "other": 1}}}
+
+	sum, err := a.Add(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Value{Coin: 7000000, Assets: MultiAsset{testPolicy: {"token": 20, "other": 1}}}
+	if !reflect.DeepEqual(sum, want) {
+		t.Errorf("got sum %v want %v", sum, want)
+	}
+
+	diff, err := sum.Sub(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(diff, a) {
+		t.Errorf("got difference %v want %v", diff, a)
+	}
+	if _, err := a.Sub(b); err == nil {
+		t.Error("expected an error subtracting missing tokens")
+	}
+	if _, err := NewValue(maxUint64).Add(NewValue(1)); err == nil {
+		t.Error("expected an overflow error")
+	}
+
+	zero, err := a.Sub(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !zero.IsZero() {
+		t.Errorf("got %v want a zero value", zero)
+	}
+	if a.IsZero() {
+		t.Errorf("got a zero value for %v", a)
+	}
+}
+
+func TestTransactionOutput_CBOR(t *testing.T) {
+	key := crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	address := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	testcases := []struct {
+		name    string
+		output  TransactionOutput
+		cborHex string
+	}{
+		{
+			name:    "coin only",
+			output:  TransactionOutput{Address: address.Bytes(), Amount: 1000000},
+			cborHex: "82581d" + hex.EncodeToString(address.Bytes()) + "1a000f4240",
+		},
+		{
+			name:   "multiasset",
+			output: NewTransactionOutput(address, Value{Coin: 1000000, Assets: MultiAsset{testPolicy: {"b": 2, "aa": 1}}}),
+			cborHex: "82581d" + hex.EncodeToString(address.Bytes()) +
+				"821a000f4240a1581c" + string(testPolicy) + "a2416202426161" + "01",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			encoded, err := cbor.Marshal(tc.output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := hex.EncodeToString(encoded), tc.cborHex; got != want {
+				t.Errorf("got %v want %v", got, want)
+			}
+
+			decoded := TransactionOutput{}
+			if err := cbor.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded, tc.output) {
+				t.Errorf("got output %+v want %+v", decoded, tc.output)
+			}
+		})
+	}
+}
+
+func TestValue_UnmarshalCBOR_Invalid(t *testing.T) {
+	testcases := []string{
+		"8201",         // missing multiasset
+		"8301a000",     // too many items
+		"8201a14100a0", // short policy id
+		"8201a1581c" + strings.Repeat("00", 28) + "a1412020", // negative quantity
+	}
+	for _, cborHex := range testcases {
+		data, err := hex.DecodeString(cborHex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		value := Value{}
+		if err := value.UnmarshalCBOR(data); err == nil {
+			t.Errorf("expected an error decoding %v", cborHex)
+		}
+	}
+}
+
+func TestParseValueFromUnits(t *testing.T) {
+	otherPolicy := PolicyID("00000000000000000000000000000000000000000000000000000002")
+	units := []AmountUnit{
+		{Unit: "lovelace", Quantity: "42000000"},
+		{Unit: string(testPolicy) + hex.EncodeToString([]byte("token")), Quantity: "12"},
+		{Unit: string(otherPolicy), Quantity: "1"},
+	}
+
+	value, err := ParseValueFromUnits(units)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Value{Coin: 42000000, Assets: MultiAsset{
+		testPolicy:  {"token": 12},
+		otherPolicy: {"": 1},
+	}}
+	if !reflect.DeepEqual(value, want) {
+		t.Errorf("got %v want %v", value, want)
+	}
+
+	invalid := [][]AmountUnit{
+		{{Unit: "lovelace", Quantity: "-1"}},
+		{{Unit: "0000", Quantity: "1"}},
+		{{Unit: string(testPolicy) + "zz", Quantity: "1"}},
+	}
+	for _, units := range invalid {
+		if _, err := ParseValueFromUnits(units); err == nil {
+			t.Errorf("expected an error parsing %v", units)
+		}
+	}
+}
+
+func TestMintAssets_CBOR(t *testing.T) {
+	mint := MintAssets{testPolicy: {"burn": -2, "mint": 1}}
+	encoded, err := cbor.Marshal(mint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a1581c" + string(testPolicy) + "a2446275726e21446d696e7401"
+	if got := hex.EncodeToString(encoded); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	decoded := MintAssets{}
+	if err := cbor.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, mint) {
+		t.Errorf("got mint %v want %v", decoded, mint)
+	}
+}
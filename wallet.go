@@ -16,6 +16,7 @@ const (
 	coinTypeIndex      uint32 = 1815 + 0x80000000
 	accountIndex       uint32 = 0x80000000
 	externalChainIndex uint32 = 0x0
+	stakingChainIndex  uint32 = 0x2
 	walleIDAlphabet           = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
 )
 
@@ -25,7 +26,7 @@ type Wallet struct {
 	skeys   []crypto.ExtendedSigningKey
 	pkeys   []crypto.ExtendedVerificationKey
 	rootKey crypto.ExtendedSigningKey
-	node    cardanoNode
+	node    Node
 	network Network
 }
 
@@ -63,45 +64,40 @@ func (w *Wallet) Transfer(receiver Address, amount uint64) error {
 		MinFeeB:          155381,
 	})
 
-	keys := make(map[int]crypto.ExtendedSigningKey)
-	for i, utxo := range pickedUtxos {
-		for _, key := range w.skeys {
-			vkey := key.ExtendedVerificationKey()
-			address := NewEnterpriseAddress(vkey, w.network)
-			if address == utxo.Address {
-				keys[i] = key
-			}
-		}
-	}
-
-	if len(keys) != len(pickedUtxos) {
-		panic("not enough keys")
-	}
-
-	for i, utxo := range pickedUtxos {
-		skey := keys[i]
-		vkey := skey.ExtendedVerificationKey()
-		builder.AddInput(vkey, utxo.TxId, utxo.Index, utxo.Amount)
+	for _, utxo := range pickedUtxos {
+		builder.AddUtxo(utxo)
 	}
 	builder.AddOutput(receiver, amount)
 
 	// Calculate and set ttl
-	tip, err := w.node.QueryTip()
+	slot, err := w.node.Tip()
 	if err != nil {
 		return err
 	}
-	builder.SetTtl(tip.Slot + 1200)
+	builder.SetTip(NodeTip{Slot: slot})
+	builder.SetTTLIn(slotMargin * shelleySlotLength)
 
-	changeAddress := pickedUtxos[0].Address
-	err = builder.AddFee(changeAddress)
+	builder.SetChangeAddress(pickedUtxos[0].Address)
+	if err := builder.SignWith(w); err != nil {
+		return err
+	}
+	tx, err := builder.Build()
 	if err != nil {
 		return err
 	}
-	for _, key := range keys {
-		builder.Sign(key)
+	_, err = w.node.SubmitTx(&tx)
+	return err
+}
+
+// KeyFor returns the wallet's signing key controlling the address.
+func (w *Wallet) KeyFor(addr Address) (Signer, bool) {
+	for i := range w.skeys {
+		key := &w.skeys[i]
+		if NewEnterpriseAddress(w.network, NewKeyCredential(key.ExtendedVerificationKey())) == addr {
+			return key, true
+		}
 	}
-	tx := builder.Build()
-	return w.node.SubmitTx(tx)
+	return nil, false
 }
 
 // Balance returns the total lovelace amount of the wallet.
@@ -121,7 +117,7 @@ func (w *Wallet) findUtxos() ([]Utxo, error) {
 	addresses := w.Addresses()
 	walletUtxos := []Utxo{}
 	for _, addr := range addresses {
-		addrUtxos, err := w.node.QueryUtxos(addr)
+		addrUtxos, err := w.node.UTxOs(addr)
 		if err != nil {
 			return nil, err
 		}
@@ -135,14 +131,14 @@ func (w *Wallet) AddAddress() Address {
 	index := uint32(len(w.skeys))
 	newKey := crypto.DeriveSigningKey(w.rootKey, index)
 	w.skeys = append(w.skeys, newKey)
-	return NewEnterpriseAddress(newKey.ExtendedVerificationKey(), w.network)
+	return NewEnterpriseAddress(w.network, NewKeyCredential(newKey.ExtendedVerificationKey()))
 }
 
 // Addresses returns all wallet's addresss.
 func (w *Wallet) Addresses() []Address {
 	addresses := make([]Address, len(w.skeys))
 	for i, key := range w.skeys {
-		addresses[i] = NewEnterpriseAddress(key.ExtendedVerificationKey(), w.network)
+		addresses[i] = NewEnterpriseAddress(w.network, NewKeyCredential(key.ExtendedVerificationKey()))
 	}
 	return addresses
 }
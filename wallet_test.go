@@ -1,9 +1,11 @@
 package cardano
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/echovl/bech32"
+	"github.com/tclairet/cardano-go/crypto"
 	"github.com/tyler-smith/go-bip39"
 )
 
@@ -46,19 +48,22 @@ func TestGenerateAddress(t *testing.T) {
 }
 
 type MockNode struct {
-	utxos []Utxo
+	utxos     []Utxo
+	tip       uint64
+	submitted []Transaction
 }
 
-func (prov *MockNode) QueryUtxos(addr Address) ([]Utxo, error) {
+func (prov *MockNode) UTxOs(addr Address) ([]Utxo, error) {
 	return prov.utxos, nil
 }
 
-func (prov *MockNode) QueryTip() (NodeTip, error) {
-	return NodeTip{}, nil
+func (prov *MockNode) Tip() (uint64, error) {
+	return prov.tip, nil
 }
 
-func (prov *MockNode) SubmitTx(tx Transaction) error {
-	return nil
+func (prov *MockNode) SubmitTx(tx *Transaction) (TransactionID, error) {
+	prov.submitted = append(prov.submitted, *tx)
+	return tx.ID(), nil
 }
 
 func TestWalletBalance(t *testing.T) {
@@ -84,3 +89,30 @@ func bech32From(hrp string, bytes []byte) string {
 	enc, _ := bech32.EncodeFromBase256(hrp, bytes)
 	return enc
 }
+
+func TestWithNetworkGuard(t *testing.T) {
+	node := &MockNode{}
+	client := NewClient(WithDB(&MockDB{}), WithNode(node), WithNetworkGuard(Testnet))
+	defer client.Close()
+
+	key := crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	testnet := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	mainnet := NewEnterpriseAddress(Mainnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	tx := Transaction{Body: TransactionBody{
+		Inputs:  []TransactionInput{{ID: make([]byte, 32), Index: 0}},
+		Outputs: []TransactionOutput{{Address: testnet.Bytes(), Amount: 1000000}},
+		Fee:     170000,
+		Ttl:     100,
+	}}
+	if _, err := client.node.SubmitTx(&tx); err != nil {
+		t.Fatal(err)
+	}
+
+	tx.Body.Outputs = append(tx.Body.Outputs, TransactionOutput{Address: mainnet.Bytes(), Amount: 1000000})
+	if _, err := client.node.SubmitTx(&tx); !errors.Is(err, ErrNetworkMismatch) {
+		t.Errorf("got error %v want %v", err, ErrNetworkMismatch)
+	}
+	if got, want := len(node.submitted), 1; got != want {
+		t.Errorf("got %v submitted transactions want %v", got, want)
+	}
+}
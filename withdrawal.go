@@ -0,0 +1,124 @@
+package cardano
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/echovl/bech32"
+	"github.com/tclairet/cardano-go/crypto"
+	"golang.org/x/crypto/blake2b"
+)
+
+// NewWithdrawalWitness signs the transaction body with the stake key of the
+// reward address, returning an error if the key doesn't control the reward
+// account.
+func NewWithdrawalWitness(rewardAddress Address, stakeKey crypto.ExtendedSigningKey, body *TransactionBody) (VKeyWitness, error) {
+	_, addressBytes, err := bech32.DecodeToBase256(string(rewardAddress))
+	if err != nil {
+		return VKeyWitness{}, err
+	}
+	if len(addressBytes) != 29 || addressBytes[0]>>4 != 0x0E {
+		return VKeyWitness{}, fmt.Errorf("invalid reward address %v", rewardAddress)
+	}
+
+	xvk := stakeKey.ExtendedVerificationKey()
+	if got, want := keyHash(xvk), addressBytes[1:]; !bytes.Equal(got, want) {
+		return VKeyWitness{}, fmt.Errorf("stake key hash %x doesn't match reward account %x", got, want)
+	}
+
+	txHash := blake2b.Sum256(body.Bytes())
+	return VKeyWitness{VKey: xvk.VerificationKey(), Signature: stakeKey.Sign(txHash[:])}, nil
+}
+
+// Withdrawals maps the reward addresses to the withdrawn lovelace.
+type Withdrawals map[Address]uint64
+
+// rewardAccount returns the bytes of a reward address.
+func rewardAccount(rewardAddress Address) ([]byte, error) {
+	_, addressBytes, err := bech32.DecodeToBase256(string(rewardAddress))
+	if err != nil {
+		return nil, err
+	}
+	if len(addressBytes) != 29 || addressBytes[0]>>4 != 0x0E && addressBytes[0]>>4 != 0x0F {
+		return nil, fmt.Errorf("invalid reward address %v", rewardAddress)
+	}
+	return addressBytes, nil
+}
+
+// rewardAddressFromAccount returns the reward address of a reward account,
+// with the prefix of its header network.
+func rewardAddressFromAccount(account []byte) (Address, error) {
+	if len(account) != 29 || account[0]>>4 != 0x0E && account[0]>>4 != 0x0F {
+		return "", fmt.Errorf("invalid reward account %x", account)
+	}
+	rewardAddress, err := bech32.EncodeFromBase256(getStakeHrp(Network(account[0]&0x0F)), account)
+	if err != nil {
+		return "", err
+	}
+	return Address(rewardAddress), nil
+}
+
+// MarshalCBOR implements cbor.Marshaler, the reward accounts are encoded in
+// canonical order.
+func (withdrawals Withdrawals) MarshalCBOR() ([]byte, error) {
+	accounts := make([][]byte, 0, len(withdrawals))
+	amounts := map[string]uint64{}
+	for rewardAddress, amount := range withdrawals {
+		account, err := rewardAccount(rewardAddress)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+		amounts[string(account)] = amount
+	}
+	sortCanonical(accounts)
+
+	out := cborHead(cborMajorMap, uint64(len(accounts)))
+	for _, account := range accounts {
+		out = append(out, cborHead(cborMajorBytes, uint64(len(account)))...)
+		out = append(out, account...)
+		out = append(out, cborHead(cborMajorUint, amounts[string(account)])...)
+	}
+	return out, nil
+}
+
+// UnmarshalCBOR implements cbor.Unmarshaler.
+func (withdrawals *Withdrawals) UnmarshalCBOR(data []byte) error {
+	decoded := Withdrawals{}
+	n, err := readCborMap(data, func(entry []byte) (int, error) {
+		account, accountLength, err := readCborBytes(entry)
+		if err != nil {
+			return 0, err
+		}
+		rewardAddress, err := rewardAddressFromAccount(account)
+		if err != nil {
+			return 0, err
+		}
+		major, amount, _, amountLength, err := readCborHead(entry[accountLength:])
+		if err != nil {
+			return 0, err
+		}
+		if major != cborMajorUint {
+			return 0, fmt.Errorf("invalid withdrawal amount major type %v", major)
+		}
+		decoded[rewardAddress] = amount
+		return accountLength + amountLength, nil
+	})
+	if err != nil {
+		return err
+	}
+	if n != len(data) {
+		return fmt.Errorf("unexpected data after withdrawals")
+	}
+	*withdrawals = decoded
+	return nil
+}
+
+// total returns the withdrawn lovelace.
+func (withdrawals Withdrawals) total() uint64 {
+	total := uint64(0)
+	for _, amount := range withdrawals {
+		total += amount
+	}
+	return total
+}
@@ -0,0 +1,173 @@
+package cardano
+
+import (
+	"testing"
+
+	"github.com/tclairet/cardano-go/crypto"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/blake2b"
+)
+
+func TestNewWithdrawalWitness(t *testing.T) {
+	entropy, err := bip39.EntropyFromMnemonic(addressTestMnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := crypto.NewExtendedSigningKey(entropy, "")
+	rewardAddress, err := StakeAddressFromRoot(root, 0, Testnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	purposeKey := crypto.DeriveSigningKey(root, purposeIndex)
+	coinKey := crypto.DeriveSigningKey(purposeKey, coinTypeIndex)
+	accountKey := crypto.DeriveSigningKey(coinKey, accountIndex)
+	stakeKey := crypto.DeriveSigningKey(crypto.DeriveSigningKey(accountKey, stakingChainIndex), 0)
+
+	body := &TransactionBody{
+		Inputs:  []TransactionInput{{ID: make([]byte, 32), Index: 0}},
+		Outputs: []TransactionOutput{{Address: make([]byte, 29), Amount: 1000000}},
+		Fee:     170000,
+		Ttl:     100,
+	}
+
+	witness, err := NewWithdrawalWitness(rewardAddress, stakeKey, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txHash := blake2b.Sum256(body.Bytes())
+	vkey := crypto.ExtendedVerificationKey(witness.VKey)
+	if !vkey.Verify(txHash[:], witness.Signature) {
+		t.Errorf("invalid withdrawal witness signature")
+	}
+
+	wrongKey := crypto.DeriveSigningKey(crypto.DeriveSigningKey(accountKey, externalChainIndex), 0)
+	if _, err := NewWithdrawalWitness(rewardAddress, wrongKey, body); err == nil {
+		t.Errorf("expected stake key mismatch error")
+	}
+
+	paymentAddress := NewEnterpriseAddress(Testnet, NewKeyCredential(wrongKey.ExtendedVerificationKey()))
+	if _, err := NewWithdrawalWitness(paymentAddress, wrongKey, body); err == nil {
+		t.Errorf("expected invalid reward address error")
+	}
+}
+
+func TestTXBuilder_AddWithdrawal(t *testing.T) {
+	entropy, err := bip39.EntropyFromMnemonic(addressTestMnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := crypto.NewExtendedSigningKey(entropy, "")
+	rewardAddress, err := StakeAddressFromRoot(root, 0, Testnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	purposeKey := crypto.DeriveSigningKey(root, purposeIndex)
+	coinKey := crypto.DeriveSigningKey(purposeKey, coinTypeIndex)
+	accountKey := crypto.DeriveSigningKey(coinKey, accountIndex)
+	stakeKey := crypto.DeriveSigningKey(crypto.DeriveSigningKey(accountKey, stakingChainIndex), 0)
+
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+
+	inputAmount, rewards := uint64(3000000), uint64(5000000)
+	builder := NewTxBuilder(ShelleyProtocol)
+	builder.AddUtxo(Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  inputAmount,
+	})
+	if err := builder.AddWithdrawal(payer, rewards); err == nil {
+		t.Error("expected an error withdrawing from a payment address")
+	}
+	if err := builder.AddWithdrawal(rewardAddress, rewards); err != nil {
+		t.Fatal(err)
+	}
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(100)
+	if err := builder.SignWith(mapResolver{payer: key}); err != nil {
+		t.Fatal(err)
+	}
+	builder.Sign(stakeKey)
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeTransaction(tx.CborHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := decoded.Body.Withdrawals[rewardAddress], rewards; got != want {
+		t.Errorf("got withdrawal %v want %v", got, want)
+	}
+	if got, want := decoded.Body.Outputs[0].Amount+decoded.Body.Fee, inputAmount+rewards; got != want {
+		t.Errorf("got change plus fee %v want %v", got, want)
+	}
+	if got, want := len(decoded.WitnessSet.VKeyWitnessSet), 2; got != want {
+		t.Errorf("got %v vkey witnesses want %v", got, want)
+	}
+	if err := decoded.VerifySignatures(); err != nil {
+		t.Error(err)
+	}
+	if got, want := tx.Body.Fee, CalculateFee(&tx, ShelleyProtocol); got < want {
+		t.Errorf("got fee %v want atleast %v", got, want)
+	}
+}
+
+func TestTXBuilder_WithdrawalFundedChange(t *testing.T) {
+	entropy, err := bip39.EntropyFromMnemonic(addressTestMnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := crypto.NewExtendedSigningKey(entropy, "")
+	rewardAddress, err := StakeAddressFromRoot(root, 0, Testnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	purposeKey := crypto.DeriveSigningKey(root, purposeIndex)
+	coinKey := crypto.DeriveSigningKey(purposeKey, coinTypeIndex)
+	accountKey := crypto.DeriveSigningKey(coinKey, accountIndex)
+	stakeKey := crypto.DeriveSigningKey(crypto.DeriveSigningKey(accountKey, stakingChainIndex), 0)
+
+	key := crypto.NewExtendedSigningKey([]byte("payer"), "foo")
+	payer := NewEnterpriseAddress(Testnet, NewKeyCredential(key.ExtendedVerificationKey()))
+	receiverKey := crypto.NewExtendedSigningKey([]byte("receiver address"), "foo")
+	receiver := NewEnterpriseAddress(Testnet, NewKeyCredential(receiverKey.ExtendedVerificationKey()))
+
+	// The input is fully sent to the receiver, the rewards pay the fee and
+	// the change
+	inputAmount, rewards := uint64(2000000), uint64(5000000)
+	builder := NewTxBuilder(ShelleyProtocol)
+	builder.AddUtxo(Utxo{
+		Address: payer,
+		TxId:    TransactionID("6e2a7cfb2e3d0d7d3f7c0fd1fcf9a63bbef0ac79e5b1b6b9cf5b34d6b4a3d2c1"),
+		Index:   0,
+		Amount:  inputAmount,
+	})
+	builder.AddOutput(receiver, inputAmount)
+	if err := builder.AddWithdrawal(rewardAddress, rewards); err != nil {
+		t.Fatal(err)
+	}
+	builder.SetChangeAddress(payer)
+	builder.SetTtl(100)
+	if err := builder.SignWith(mapResolver{payer: key}); err != nil {
+		t.Fatal(err)
+	}
+	builder.Sign(stakeKey)
+	tx, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	change, err := tx.ChangeUtxo(payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := change.Amount, rewards-tx.Body.Fee; got != want {
+		t.Errorf("got change %v want %v", got, want)
+	}
+	if got, want := tx.Body.Fee, CalculateFee(&tx, ShelleyProtocol); got < want {
+		t.Errorf("got fee %v want atleast %v", got, want)
+	}
+}